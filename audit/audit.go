@@ -0,0 +1,71 @@
+// Package audit records who changed what in the append-only audit log
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"ledger-service/models"
+	"ledger-service/store"
+)
+
+// originKey is the context key of the origin of a change
+type originKey struct{}
+
+// NewContext returns a copy of ctx carrying the origin of the changes made with it
+func NewContext(ctx context.Context, origin models.AuditOrigin) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFrom returns the origin ctx carries, or models.SystemOrigin for
+// changes the service makes on its own
+func OriginFrom(ctx context.Context) models.AuditOrigin {
+	if origin, ok := ctx.Value(originKey{}).(models.AuditOrigin); ok {
+		return origin
+	}
+	return models.SystemOrigin
+}
+
+// NewEntry builds an entry for action on a target by the origin of ctx.
+// before and after are snapshotted as JSON; nil leaves them out.
+func NewEntry(ctx context.Context, action, targetType, targetID string, before, after interface{}) (models.AuditEntry, error) {
+	entry := models.AuditEntry{
+		AuditID:     models.GenerateAuditID(),
+		Timestamp:   models.GenerateTimestamp(),
+		AuditOrigin: OriginFrom(ctx),
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+	}
+	var err error
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return models.AuditEntry{}, err
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return models.AuditEntry{}, err
+		}
+	}
+	return entry, nil
+}
+
+// Record writes an entry for a change made in tx, so that the entry is only
+// kept if the change is committed
+func Record(ctx context.Context, tx store.Tx, action, targetType, targetID string, before, after interface{}) error {
+	entry, err := NewEntry(ctx, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	return tx.InsertAuditEntry(entry)
+}
+
+// Log writes an entry for a change made outside a session, once the change
+// has been made
+func Log(ctx context.Context, auditStore store.AuditStore, action, targetType, targetID string, before, after interface{}) error {
+	entry, err := NewEntry(ctx, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	return auditStore.InsertAuditEntry(ctx, entry)
+}
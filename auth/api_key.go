@@ -0,0 +1,174 @@
+// Package auth authenticates API requests and decides what they may do
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"ledger-service/store"
+	"log"
+	"strings"
+	"time"
+)
+
+// LastUsedResolution is how stale the recorded last use of a key may get
+// before a request refreshes it, so busy keys do not write on every request
+const LastUsedResolution = time.Minute
+
+// MinSecretLength is the shortest secret accepted when registering a key with a chosen secret
+const MinSecretLength = 32
+
+// ErrInvalidAPIKey is returned when a secret matches no key or a revoked one
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// ErrInvalidScope is returned when granting a scope that does not exist
+var ErrInvalidScope = errors.New("unknown scope")
+
+// ErrNameRequired is returned when creating a key without a name
+var ErrNameRequired = errors.New("name is required")
+
+// ErrScopesRequired is returned when creating a key without any scope
+var ErrScopesRequired = errors.New("at least one scope is required")
+
+// ErrSecretTooShort is returned when registering a chosen secret shorter than MinSecretLength
+var ErrSecretTooShort = fmt.Errorf("secret must be at least %d characters", MinSecretLength)
+
+// IssueAPIKey creates a key with a new random secret and returns it with
+// the secret, which is not stored and cannot be retrieved again
+func IssueAPIKey(ctx context.Context, keys store.APIKeyStore, name string, scopes []string) (models.APIKey, string, error) {
+	secret, err := models.GenerateAPIKeySecret()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	key, err := RegisterAPIKey(ctx, keys, name, secret, scopes)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// RegisterAPIKey creates a key for a secret chosen by the caller, such as
+// one provisioned from configuration. If a key with the secret exists it is
+// returned unchanged.
+func RegisterAPIKey(ctx context.Context, keys store.APIKeyStore, name, secret string, scopes []string) (models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIKey{}, ErrNameRequired
+	}
+	if len(secret) < MinSecretLength {
+		return models.APIKey{}, ErrSecretTooShort
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	hash := models.HashAPIKeySecret(secret)
+	if existing, err := keys.FindAPIKeyByHash(ctx, hash); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, store.ErrAPIKeyNotFound) {
+		return models.APIKey{}, err
+	}
+	key := models.APIKey{
+		KeyID:     models.GenerateAPIKeyID(),
+		Name:      name,
+		Prefix:    models.APIKeyDisplayPrefix(secret),
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: models.GenerateTimestamp(),
+	}
+	if err := keys.InsertAPIKey(ctx, key); err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// RotateAPIKey replaces the secret of a key that is not revoked with a new
+// random one and returns the key with the new secret. The old secret stops
+// working at once; the ID, name and scopes are kept.
+func RotateAPIKey(ctx context.Context, keys store.APIKeyStore, keyID string) (models.APIKey, string, error) {
+	key, err := keys.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	if key.IsRevoked() {
+		return models.APIKey{}, "", models.ErrAPIKeyRevoked
+	}
+	secret, err := models.GenerateAPIKeySecret()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	now := models.GenerateTimestamp()
+	key.Prefix = models.APIKeyDisplayPrefix(secret)
+	key.Hash = models.HashAPIKeySecret(secret)
+	key.RotatedAt = &now
+	if err := keys.UpdateAPIKey(ctx, key); err != nil {
+		return models.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// RevokeAPIKey revokes a key for good. Revoked keys are kept so their use
+// can still be traced.
+func RevokeAPIKey(ctx context.Context, keys store.APIKeyStore, keyID string) (models.APIKey, error) {
+	key, err := keys.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if key.IsRevoked() {
+		return models.APIKey{}, models.ErrAPIKeyRevoked
+	}
+	now := models.GenerateTimestamp()
+	key.RevokedAt = &now
+	if err := keys.UpdateAPIKey(ctx, key); err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// Authenticate returns the key a secret belongs to, failing with
+// ErrInvalidAPIKey if there is none or it was revoked, and records its use.
+// A failure to record the use is logged and does not fail the request.
+func Authenticate(ctx context.Context, keys store.APIKeyStore, secret string) (models.APIKey, error) {
+	key, err := keys.FindAPIKeyByHash(ctx, models.HashAPIKeySecret(secret))
+	if errors.Is(err, store.ErrAPIKeyNotFound) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if key.IsRevoked() {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	now := models.GenerateTimestamp()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= LastUsedResolution {
+		if err := keys.TouchAPIKey(ctx, key.KeyID, now); err != nil {
+			log.Printf("failed to record use of API key %s: %v", key.KeyID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// normalizeScopes checks that scopes is a non-empty list of known scopes and
+// returns it without duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrScopesRequired
+	}
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !models.IsScope(scope) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []string
+		wantErr error
+	}{
+		{name: "valid", keyName: "backend", scopes: []string{models.ScopeCustomersRead, models.ScopeCustomersRead}},
+		{name: "missing name", keyName: " ", scopes: []string{models.ScopeAdmin}, wantErr: ErrNameRequired},
+		{name: "no scopes", keyName: "backend", wantErr: ErrScopesRequired},
+		{name: "unknown scope", keyName: "backend", scopes: []string{"customers:delete"}, wantErr: ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+			key, secret, err := IssueAPIKey(context.Background(), s, tt.keyName, tt.scopes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IssueAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !strings.HasPrefix(secret, models.APIKeySecretPrefix) || !strings.HasPrefix(secret, key.Prefix) || key.Hash == secret {
+				t.Errorf("IssueAPIKey() = %+v with secret %q, want a prefixed secret stored only as a hash", key, secret)
+			}
+			if len(key.Scopes) != 1 {
+				t.Errorf("Scopes = %v, want duplicates dropped", key.Scopes)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := store.NewMemoryStore()
+	ctx := context.Background()
+	key, secret, err := IssueAPIKey(ctx, s, "backend", []string{models.ScopeCustomersRead})
+	if err != nil {
+		t.Fatalf("IssueAPIKey() error = %v", err)
+	}
+
+	authenticated, err := Authenticate(ctx, s, secret)
+	if err != nil || authenticated.KeyID != key.KeyID {
+		t.Fatalf("Authenticate() = %+v, %v, want key %s", authenticated, err, key.KeyID)
+	}
+	stored, _ := s.GetAPIKey(ctx, key.KeyID)
+	if stored.LastUsedAt == nil {
+		t.Error("LastUsedAt not recorded")
+	}
+	if _, err := Authenticate(ctx, s, secret+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() with a wrong secret error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+
+	rotated, newSecret, err := RotateAPIKey(ctx, s, key.KeyID)
+	if err != nil || rotated.KeyID != key.KeyID || rotated.RotatedAt == nil {
+		t.Fatalf("RotateAPIKey() = %+v, %v, want the same key rotated", rotated, err)
+	}
+	if _, err := Authenticate(ctx, s, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() with the old secret error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if _, err := Authenticate(ctx, s, newSecret); err != nil {
+		t.Errorf("Authenticate() with the new secret error = %v", err)
+	}
+
+	if _, err := RevokeAPIKey(ctx, s, key.KeyID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+	if _, err := Authenticate(ctx, s, newSecret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() with a revoked key error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if _, err := RevokeAPIKey(ctx, s, key.KeyID); !errors.Is(err, models.ErrAPIKeyRevoked) {
+		t.Errorf("Revoking twice error = %v, want %v", err, models.ErrAPIKeyRevoked)
+	}
+	if _, _, err := RotateAPIKey(ctx, s, key.KeyID); !errors.Is(err, models.ErrAPIKeyRevoked) {
+		t.Errorf("Rotating a revoked key error = %v, want %v", err, models.ErrAPIKeyRevoked)
+	}
+	if _, _, err := RotateAPIKey(ctx, s, "missing"); !errors.Is(err, store.ErrAPIKeyNotFound) {
+		t.Errorf("Rotating a missing key error = %v, want %v", err, store.ErrAPIKeyNotFound)
+	}
+}
+
+func TestAuthenticateThrottlesLastUsed(t *testing.T) {
+	s := store.NewMemoryStore()
+	ctx := context.Background()
+	key, secret, _ := IssueAPIKey(ctx, s, "backend", []string{models.ScopeAdmin})
+	recent := time.Now().Add(-LastUsedResolution / 2)
+	s.TouchAPIKey(ctx, key.KeyID, recent)
+
+	Authenticate(ctx, s, secret)
+	if stored, _ := s.GetAPIKey(ctx, key.KeyID); !stored.LastUsedAt.Equal(recent) {
+		t.Errorf("LastUsedAt = %v, want %v kept within the resolution", stored.LastUsedAt, recent)
+	}
+}
+
+func TestRegisterAPIKey(t *testing.T) {
+	s := store.NewMemoryStore()
+	ctx := context.Background()
+	secret := strings.Repeat("s", MinSecretLength)
+
+	first, err := RegisterAPIKey(ctx, s, "bootstrap", secret, []string{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("RegisterAPIKey() error = %v", err)
+	}
+	again, err := RegisterAPIKey(ctx, s, "bootstrap", secret, []string{models.ScopeAdmin})
+	if err != nil || again.KeyID != first.KeyID {
+		t.Errorf("Registering the same secret again = %+v, %v, want the existing key %s", again, err, first.KeyID)
+	}
+	if _, err := RegisterAPIKey(ctx, s, "bootstrap", secret[1:], []string{models.ScopeAdmin}); !errors.Is(err, ErrSecretTooShort) {
+		t.Errorf("RegisterAPIKey() with a short secret error = %v, want %v", err, ErrSecretTooShort)
+	}
+}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// OperatorRole is the value of the role claim that lets a token see every customer
+const OperatorRole = "operator"
+
+// TokenLeeway is how far token time claims may be off to allow for clock skew
+const TokenLeeway = 30 * time.Second
+
+// MinHMACSecretLength is the shortest HS256 secret accepted, in bytes
+const MinHMACSecretLength = 32
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// ErrInvalidToken is returned for a bearer token that is malformed, not
+// signed by a configured key, expired or not meant for this service
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenConfig configures which bearer tokens a TokenVerifier accepts
+type TokenConfig struct {
+	// HMACSecret verifies HS256 tokens; HS256 is refused when empty
+	HMACSecret []byte
+	// JWKSPath is a JSON Web Key Set file with the RSA and P-256 keys that verify RS256 and ES256 tokens
+	JWKSPath string
+	// Issuer, when set, must equal the iss claim
+	Issuer string
+	// Audience, when set, must be one of the aud claim
+	Audience string
+}
+
+// TokenVerifier verifies JWT bearer tokens against locally configured keys
+type TokenVerifier struct {
+	hmacSecret []byte
+	keys       []publicKey
+	issuer     string
+	audience   string
+	now        func() time.Time
+}
+
+// publicKey is a verification key of a JSON Web Key Set
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// Claims are the JWT claims the service reads. The subject is the customer
+// an end-user token acts for.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	// Role is OperatorRole for service tokens that may see every customer
+	Role string `json:"role"`
+	// Scope is a space-separated list of scopes
+	Scope string `json:"scope"`
+}
+
+// audience is the aud claim, which is either a string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// NewTokenVerifier creates a verifier from config, loading its key set
+func NewTokenVerifier(config TokenConfig) (*TokenVerifier, error) {
+	if len(config.HMACSecret) > 0 && len(config.HMACSecret) < MinHMACSecretLength {
+		return nil, fmt.Errorf("HS256 secret must be at least %d bytes", MinHMACSecretLength)
+	}
+	verifier := &TokenVerifier{
+		hmacSecret: config.HMACSecret,
+		issuer:     config.Issuer,
+		audience:   config.Audience,
+		now:        time.Now,
+	}
+	if config.JWKSPath != "" {
+		keys, err := loadJWKS(config.JWKSPath)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys = keys
+	}
+	if len(verifier.hmacSecret) == 0 && len(verifier.keys) == 0 {
+		return nil, errors.New("no token verification keys are configured")
+	}
+	return verifier, nil
+}
+
+// Verify checks the signature and time, issuer and audience claims of a
+// compact JWT and returns its claims. Every failure wraps ErrInvalidToken.
+func (v *TokenVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: not a compact JWT", ErrInvalidToken)
+	}
+	var header struct {
+		Alg  string          `json:"alg"`
+		Kid  string          `json:"kid"`
+		Crit json.RawMessage `json:"crit"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if header.Crit != nil {
+		return Claims{}, fmt.Errorf("%w: critical header parameters are not supported", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// verifySignature checks signature over signingInput with the key for alg and kid
+func (v *TokenVerifier) verifySignature(alg, kid, signingInput string, signature []byte) error {
+	if alg == AlgHS256 {
+		if len(v.hmacSecret) == 0 {
+			return fmt.Errorf("%w: HS256 is not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.hmacSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	if alg != AlgRS256 && alg != AlgES256 {
+		return fmt.Errorf("%w: algorithm %q is not accepted", ErrInvalidToken, alg)
+	}
+
+	key, err := v.keyFor(alg, kid)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		// JWS encodes ES256 signatures as the 32-byte r and s concatenated
+		if len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			if ecdsa.Verify(key, digest[:], r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+}
+
+// keyFor returns the key of the set that verifies alg: the one named kid, or
+// the only key for alg when the token names none
+func (v *TokenVerifier) keyFor(alg, kid string) (crypto.PublicKey, error) {
+	var match crypto.PublicKey
+	matches := 0
+	for _, candidate := range v.keys {
+		if candidate.alg != alg || (kid != "" && candidate.kid != kid) {
+			continue
+		}
+		match = candidate.key
+		matches++
+	}
+	switch {
+	case matches == 0:
+		return nil, fmt.Errorf("%w: no %s key matches kid %q", ErrInvalidToken, alg, kid)
+	case matches > 1:
+		return nil, fmt.Errorf("%w: several %s keys match kid %q", ErrInvalidToken, alg, kid)
+	}
+	return match, nil
+}
+
+// checkClaims checks the time, issuer and audience claims and that the token has a subject
+func (v *TokenVerifier) checkClaims(claims Claims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(TokenLeeway)) {
+		return fmt.Errorf("%w: token has expired", ErrInvalidToken)
+	}
+	if claims.NotBefore != nil && now.Add(TokenLeeway).Before(numericDate(*claims.NotBefore)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.Audience.contains(v.audience) {
+		return fmt.Errorf("%w: token is not meant for this service", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, candidate := range a {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal returns the principal of a request authenticated with a token
+// bearing claims. Tokens with the operator role may see every customer; any
+// other token is an end-user token restricted to its subject, which may read
+// and take money out of its account but never credit it, reverse or settle
+// postings, manage customers or use admin routes. Tokens without a scope
+// claim may read.
+func (c Claims) Principal() Principal {
+	var scopes []string
+	for _, scope := range strings.Fields(c.Scope) {
+		if models.IsScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = []string{models.ScopeCustomersRead}
+	}
+	if c.Role == OperatorRole {
+		return Principal{Kind: PrincipalToken, ID: c.Subject, Scopes: scopes}
+	}
+
+	restricted := scopes[:0]
+	for _, scope := range scopes {
+		if scope == models.ScopeCustomersRead || scope == models.ScopeTransactionsPost {
+			restricted = append(restricted, scope)
+		}
+	}
+	return Principal{Kind: PrincipalToken, ID: c.Subject, Scopes: restricted, CustomerID: c.Subject}
+}
+
+// numericDate converts a JWT NumericDate, seconds since the epoch, to a time
+func numericDate(seconds float64) time.Time {
+	whole := math.Floor(seconds)
+	return time.Unix(int64(whole), int64((seconds-whole)*float64(time.Second)))
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into out
+func decodeSegment(segment string, out interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// loadJWKS reads the RSA and P-256 EC signing keys of a JSON Web Key Set file.
+// Keys of other types or meant for encryption are skipped.
+func loadJWKS(path string) ([]publicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	var keys []publicKey
+	for i, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key publicKey
+		switch jwk.Kty {
+		case "RSA":
+			key = publicKey{kid: jwk.Kid, alg: AlgRS256}
+			key.key, err = rsaPublicKey(jwk.N, jwk.E)
+		case "EC":
+			key = publicKey{kid: jwk.Kid, alg: AlgES256}
+			key.key, err = p256PublicKey(jwk.Crv, jwk.X, jwk.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d of %s: %w", i+1, path, err)
+		}
+		if jwk.Alg != "" && jwk.Alg != key.alg {
+			return nil, fmt.Errorf("key %d of %s: %s key cannot be used with %s", i+1, path, jwk.Kty, jwk.Alg)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no RSA or P-256 signing keys", path)
+	}
+	return keys, nil
+}
+
+// rsaPublicKey builds an RSA public key of at least 2048 bits from its base64url modulus and exponent
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("malformed modulus: %w", err)
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("malformed exponent")
+	}
+	key := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must have at least 2048 bits")
+	}
+	return key, nil
+}
+
+// p256PublicKey builds a P-256 public key from its base64url coordinates,
+// checking that the point is on the curve
+func p256PublicKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	if crv != "P-256" {
+		return nil, fmt.Errorf("curve %q is not supported", crv)
+	}
+	xBytes, errX := base64.RawURLEncoding.DecodeString(x)
+	yBytes, errY := base64.RawURLEncoding.DecodeString(y)
+	if errX != nil || errY != nil || len(xBytes) != 32 || len(yBytes) != 32 {
+		return nil, errors.New("malformed coordinates")
+	}
+	point := append(append([]byte{4}, xBytes...), yBytes...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"ledger-service/models"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testHMACSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signToken builds a compact JWT with header and claims, signed by sign
+func signToken(t *testing.T, header, claims map[string]interface{}, sign func(signingInput []byte) []byte) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Failed to encode token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signingInput := encode(header) + "." + encode(claims)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signingInput)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		digest := sha256.Sum256(signingInput)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return signature
+	}
+}
+
+func es256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signingInput []byte) []byte {
+		digest := sha256.Sum256(signingInput)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature
+	}
+}
+
+// writeJWKS writes a key set with the public keys of rsaKey and ecKey
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	coordinate := func(n *big.Int) string { return b64(n.FillBytes(make([]byte, 32))) }
+	set := map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": coordinate(ecKey.X), "y": coordinate(ecKey.Y)},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		},
+	}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("Failed to encode key set: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("Failed to write key set: %v", err)
+	}
+	return path
+}
+
+func TestTokenVerifierVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	verifier, err := NewTokenVerifier(TokenConfig{
+		HMACSecret: testHMACSecret,
+		JWKSPath:   writeJWKS(t, rsaKey, ecKey),
+		Issuer:     "https://id.example.com",
+		Audience:   "ledger",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	now := time.Date(2025, 4, 6, 10, 0, 0, 0, time.UTC)
+	verifier.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "c1",
+			"iss": "https://id.example.com",
+			"aud": []string{"other", "ledger"},
+			"exp": now.Add(time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	hsHeader := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"HS256", signToken(t, hsHeader, claims(nil), hs256(testHMACSecret)), false},
+		{"RS256 by kid", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims(nil), rs256(t, rsaKey)), false},
+		{"RS256 without kid", signToken(t, map[string]interface{}{"alg": "RS256"}, claims(nil), rs256(t, rsaKey)), false},
+		{"ES256", signToken(t, map[string]interface{}{"alg": "ES256", "kid": "ec-1"}, claims(nil), es256(t, ecKey)), false},
+		{"single audience", signToken(t, hsHeader, claims(map[string]interface{}{"aud": "ledger"}), hs256(testHMACSecret)), false},
+		{"expired within leeway", signToken(t, hsHeader, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}), hs256(testHMACSecret)), false},
+		{"expired", signToken(t, hsHeader, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}), hs256(testHMACSecret)), true},
+		{"not yet valid", signToken(t, hsHeader, claims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), hs256(testHMACSecret)), true},
+		{"no expiry", signToken(t, hsHeader, claims(map[string]interface{}{"exp": nil}), hs256(testHMACSecret)), true},
+		{"no subject", signToken(t, hsHeader, claims(map[string]interface{}{"sub": nil}), hs256(testHMACSecret)), true},
+		{"wrong issuer", signToken(t, hsHeader, claims(map[string]interface{}{"iss": "https://evil.example.com"}), hs256(testHMACSecret)), true},
+		{"wrong audience", signToken(t, hsHeader, claims(map[string]interface{}{"aud": "billing"}), hs256(testHMACSecret)), true},
+		{"wrong secret", signToken(t, hsHeader, claims(nil), hs256([]byte("fedcba9876543210fedcba9876543210"))), true},
+		{"wrong RSA key", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims(nil), rs256(t, otherRSAKey)), true},
+		{"unknown kid", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, claims(nil), rs256(t, rsaKey)), true},
+		{"kid of another algorithm", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "ec-1"}, claims(nil), rs256(t, rsaKey)), true},
+		{"encryption key", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "enc-1"}, claims(nil), rs256(t, rsaKey)), true},
+		{"none", signToken(t, map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), true},
+		{"unsupported algorithm", signToken(t, map[string]interface{}{"alg": "HS512"}, claims(nil), hs256(testHMACSecret)), true},
+		{"critical header", signToken(t, map[string]interface{}{"alg": "HS256", "crit": []string{"exp"}}, claims(nil), hs256(testHMACSecret)), true},
+		{"tampered token", signToken(t, hsHeader, claims(nil), hs256(testHMACSecret))[:10] + "x" + signToken(t, hsHeader, claims(nil), hs256(testHMACSecret))[11:], true},
+		{"not a JWT", "abc.def", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got.Subject != "c1" {
+				t.Errorf("Verify() subject = %q, want c1", got.Subject)
+			}
+		})
+	}
+}
+
+func TestNewTokenVerifier(t *testing.T) {
+	if _, err := NewTokenVerifier(TokenConfig{}); err == nil {
+		t.Error("Expected an error without keys")
+	}
+	if _, err := NewTokenVerifier(TokenConfig{HMACSecret: []byte("short")}); err == nil {
+		t.Error("Expected an error for a short HS256 secret")
+	}
+	if _, err := NewTokenVerifier(TokenConfig{JWKSPath: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("Expected an error for a missing key set")
+	}
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	if _, err := NewTokenVerifier(TokenConfig{JWKSPath: writeJWKS(t, smallKey, ecKey)}); err == nil {
+		t.Error("Expected an error for an RSA key under 2048 bits")
+	}
+}
+
+func TestClaimsPrincipal(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         Claims
+		wantScopes     []string
+		wantCustomerID string
+	}{
+		{"end user without scope", Claims{Subject: "c1"}, []string{models.ScopeCustomersRead}, "c1"},
+		{"end user posting", Claims{Subject: "c1", Scope: "customers:read transactions:post"}, []string{models.ScopeCustomersRead, models.ScopeTransactionsPost}, "c1"},
+		{"end user asking for admin", Claims{Subject: "c1", Scope: "admin customers:write transactions:post"}, []string{models.ScopeTransactionsPost}, "c1"},
+		{"end user with unknown scopes", Claims{Subject: "c1", Scope: "openid profile"}, []string{models.ScopeCustomersRead}, "c1"},
+		{"operator", Claims{Subject: "svc", Role: OperatorRole, Scope: "admin"}, []string{models.ScopeAdmin}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := tt.claims.Principal()
+			if principal.Kind != PrincipalToken || principal.ID != tt.claims.Subject || principal.CustomerID != tt.wantCustomerID {
+				t.Errorf("Principal() = %+v, want customer %q", principal, tt.wantCustomerID)
+			}
+			if len(principal.Scopes) != len(tt.wantScopes) {
+				t.Fatalf("Principal() scopes = %v, want %v", principal.Scopes, tt.wantScopes)
+			}
+			for i := range tt.wantScopes {
+				if principal.Scopes[i] != tt.wantScopes[i] {
+					t.Errorf("Principal() scopes = %v, want %v", principal.Scopes, tt.wantScopes)
+				}
+			}
+		})
+	}
+}
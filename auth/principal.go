@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"net/http"
+	"strings"
+)
+
+// Principal kinds
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalToken  = "token"
+)
+
+// ErrInsufficientScope is returned when a principal lacks the scope a request needs
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// ErrCustomerAccessDenied is returned when a principal restricted to one customer requests another's data
+var ErrCustomerAccessDenied = errors.New("access to the customer is denied")
+
+// ErrOperatorOnly is returned when a principal restricted to one customer
+// makes a request only services and operators may make, even for that customer
+var ErrOperatorOnly = errors.New("only API keys and operator tokens may do this")
+
+// Principal is who a request was authenticated as
+type Principal struct {
+	// Kind is PrincipalAPIKey or PrincipalToken
+	Kind string
+	// ID is the ID of the API key or the subject of the token
+	ID string
+	// Scopes are what the principal may do; ScopeAdmin grants every other scope
+	Scopes []string
+	// CustomerID restricts the principal to the data of one customer when set
+	CustomerID string
+}
+
+// APIKeyPrincipal returns the principal of a request authenticated with key.
+// API keys belong to services and are not restricted to a customer.
+func APIKeyPrincipal(key models.APIKey) Principal {
+	return Principal{Kind: PrincipalAPIKey, ID: key.KeyID, Scopes: key.Scopes}
+}
+
+// HasScope reports whether the principal is granted scope, either directly or through ScopeAdmin
+func (p Principal) HasScope(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRestricted reports whether the principal may only see one customer's data
+func (p Principal) IsRestricted() bool {
+	return p.CustomerID != ""
+}
+
+// CanAccessCustomer reports whether the principal may see and act on the data of customerID
+func (p Principal) CanAccessCustomer(customerID string) bool {
+	return !p.IsRestricted() || p.CustomerID == customerID
+}
+
+// CanPost reports whether the principal may post a transaction of
+// transactionType. Principals restricted to a customer may only take money
+// out of their account, never credit it.
+func (p Principal) CanPost(transactionType string) bool {
+	return !p.IsRestricted() || transactionType == "debit"
+}
+
+// Authorize checks that the principal may make a request with method to
+// path. Beyond the scope of the route, principals restricted to a customer
+// may only use the customer routes of that customer, cannot list or create
+// customers, and cannot reverse transactions or capture or void holds.
+// Resources addressed by their own ID, such as holds, are checked by their
+// handlers once the owner is known.
+func (p Principal) Authorize(method, path string) error {
+	scope, public := RequiredScope(method, path)
+	if public {
+		return nil
+	}
+	if !p.HasScope(scope) {
+		return fmt.Errorf("%w: %s is required", ErrInsufficientScope, scope)
+	}
+	if !p.IsRestricted() {
+		return nil
+	}
+	if isOperatorAction(method, path) {
+		return ErrOperatorOnly
+	}
+	if customerID, collection, ok := customerOfPath(path); ok && (collection || !p.CanAccessCustomer(customerID)) {
+		return ErrCustomerAccessDenied
+	}
+	return nil
+}
+
+// isOperatorAction reports whether a request settles or undoes a posting,
+// which moves money into an account: reversing a transaction or capturing
+// or voiding a hold
+func isOperatorAction(method, path string) bool {
+	if method != http.MethodPost {
+		return false
+	}
+	segments := strings.Split(strings.Trim(strings.ToLower(path), "/"), "/")
+	if len(segments) != 3 {
+		return false
+	}
+	switch segments[0] {
+	case "transactions":
+		return segments[2] == "reverse"
+	case "holds":
+		return segments[2] == "capture" || segments[2] == "void"
+	}
+	return false
+}
+
+// customerOfPath returns the customer a /customers path refers to, or
+// collection true for /customers itself
+func customerOfPath(path string) (customerID string, collection bool, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if !strings.EqualFold(segments[0], "customers") {
+		return "", false, false
+	}
+	if len(segments) == 1 {
+		return "", true, true
+	}
+	return segments[1], false, true
+}
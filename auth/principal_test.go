@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"errors"
+	"ledger-service/models"
+	"testing"
+)
+
+func TestPrincipalAuthorize(t *testing.T) {
+	service := Principal{Kind: PrincipalAPIKey, ID: "k1", Scopes: []string{models.ScopeCustomersRead}}
+	customer := Principal{Kind: PrincipalToken, ID: "c1", Scopes: []string{models.ScopeCustomersRead, models.ScopeTransactionsPost}, CustomerID: "c1"}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		method    string
+		path      string
+		wantErr   error
+	}{
+		{"public route", Principal{}, "GET", "/health", nil},
+		{"service reads any customer", service, "GET", "/customers/c2/balance", nil},
+		{"service lists customers", service, "GET", "/customers", nil},
+		{"service without scope", service, "POST", "/transactions", ErrInsufficientScope},
+		{"customer reads own balance", customer, "GET", "/customers/c1/balance", nil},
+		{"customer reads own customer", customer, "GET", "/Customers/c1/", nil},
+		{"customer reads another balance", customer, "GET", "/customers/c2/balance", ErrCustomerAccessDenied},
+		{"customer lists customers", customer, "GET", "/customers/", ErrCustomerAccessDenied},
+		{"customer posts", customer, "POST", "/transactions", nil},
+		{"customer transfers", customer, "POST", "/transfers", nil},
+		{"customer places a hold", customer, "POST", "/holds", nil},
+		{"customer reverses", customer, "POST", "/transactions/t1/reverse", ErrOperatorOnly},
+		{"customer captures a hold", customer, "POST", "/Holds/h1/capture/", ErrOperatorOnly},
+		{"customer voids a hold", customer, "POST", "/holds/h1/void", ErrOperatorOnly},
+		{"service reverses", Principal{Kind: PrincipalAPIKey, ID: "k2", Scopes: []string{models.ScopeTransactionsPost}}, "POST", "/transactions/t1/reverse", nil},
+		{"customer on admin route", customer, "GET", "/admin/dead-letters", ErrInsufficientScope},
+		{"customer renames itself", customer, "PUT", "/customers/c1", ErrInsufficientScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.principal.Authorize(tt.method, tt.path); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrincipalCanPost(t *testing.T) {
+	service := Principal{Kind: PrincipalAPIKey, ID: "k1", Scopes: []string{models.ScopeTransactionsPost}}
+	customer := Principal{Kind: PrincipalToken, ID: "c1", Scopes: []string{models.ScopeTransactionsPost}, CustomerID: "c1"}
+	if !service.CanPost("credit") || !service.CanPost("debit") {
+		t.Error("Expected a service to post credits and debits")
+	}
+	if customer.CanPost("credit") || !customer.CanPost("debit") {
+		t.Error("Expected a customer to post debits only")
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"ledger-service/models"
+	"net/http"
+	"strings"
+)
+
+// scopeRule assigns a scope to the requests with a method, or any method
+// when empty, to a path under prefix, or makes them public
+type scopeRule struct {
+	method string
+	prefix string
+	scope  string
+	public bool
+}
+
+// scopeRules are checked in order and the first match applies
+var scopeRules = []scopeRule{
+	{prefix: "/health", public: true},
+	{prefix: "/swagger", public: true},
+	{prefix: "/admin", scope: models.ScopeAdmin},
+	{prefix: "/ledger", scope: models.ScopeAdmin},
+	{prefix: "/audit", scope: models.ScopeAdmin},
+	{method: http.MethodGet, prefix: "/", scope: models.ScopeCustomersRead},
+	{prefix: "/customers", scope: models.ScopeCustomersWrite},
+	{method: http.MethodPost, prefix: "/transactions", scope: models.ScopeTransactionsPost},
+	{method: http.MethodPost, prefix: "/transfers", scope: models.ScopeTransactionsPost},
+	{method: http.MethodPost, prefix: "/holds", scope: models.ScopeTransactionsPost},
+	{method: http.MethodPost, prefix: "/fx/quotes", scope: models.ScopeTransactionsPost},
+	{method: http.MethodPost, prefix: "/conversions", scope: models.ScopeTransactionsPost},
+}
+
+// RequiredScope returns the scope a request needs, or public true for a
+// request that needs no credentials. Requests no rule covers need
+// models.ScopeAdmin. Paths are compared ignoring case and a trailing slash,
+// as the router does.
+func RequiredScope(method, path string) (scope string, public bool) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	path = strings.ToLower(path)
+	for _, rule := range scopeRules {
+		if rule.method != "" && rule.method != method {
+			continue
+		}
+		if !hasPathPrefix(path, rule.prefix) {
+			continue
+		}
+		return rule.scope, rule.public
+	}
+	return models.ScopeAdmin, false
+}
+
+// hasPathPrefix reports whether path is prefix or a path below it
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"ledger-service/models"
+	"testing"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method     string
+		path       string
+		wantScope  string
+		wantPublic bool
+	}{
+		{"GET", "/health", "", true},
+		{"GET", "/swagger/index.html", "", true},
+		{"GET", "/customers", models.ScopeCustomersRead, false},
+		{"HEAD", "/customers/c1/balance", models.ScopeCustomersRead, false},
+		{"GET", "/transactions/t1", models.ScopeCustomersRead, false},
+		{"POST", "/customers", models.ScopeCustomersWrite, false},
+		{"PUT", "/customers/c1", models.ScopeCustomersWrite, false},
+		{"DELETE", "/customers/c1/", models.ScopeCustomersWrite, false},
+		{"POST", "/transactions", models.ScopeTransactionsPost, false},
+		{"POST", "/transactions/t1/reverse", models.ScopeTransactionsPost, false},
+		{"POST", "/holds/h1/capture", models.ScopeTransactionsPost, false},
+		{"POST", "/conversions", models.ScopeTransactionsPost, false},
+		{"GET", "/admin/dead-letters", models.ScopeAdmin, false},
+		{"GET", "/ADMIN/dead-letters", models.ScopeAdmin, false},
+		{"POST", "/admin/fx/rates", models.ScopeAdmin, false},
+		{"GET", "/ledger/trial-balance", models.ScopeAdmin, false},
+		{"GET", "/audit", models.ScopeAdmin, false},
+		{"GET", "/healthz", models.ScopeCustomersRead, false},
+		{"POST", "/transfersx", models.ScopeAdmin, false},
+		{"PATCH", "/holds/h1", models.ScopeAdmin, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			scope, public := RequiredScope(tt.method, tt.path)
+			if scope != tt.wantScope || public != tt.wantPublic {
+				t.Errorf("RequiredScope() = %q, %v, want %q, %v", scope, public, tt.wantScope, tt.wantPublic)
+			}
+		})
+	}
+}
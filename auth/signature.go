@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ledger-service/store"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSignatureWindow is how far the timestamp of a signed request may be
+// from the server clock, in either direction
+const DefaultSignatureWindow = 5 * time.Minute
+
+// MinSigningSecretLength is the shortest signing secret accepted, in bytes
+const MinSigningSecretLength = 32
+
+// Bounds of the length of a request nonce
+const (
+	MinNonceLength = 16
+	MaxNonceLength = 128
+)
+
+// ErrInvalidSignature is returned for a request that is not signed, names an
+// unknown client or carries a signature that does not match
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrStaleTimestamp is returned for a signed request whose timestamp is outside the replay window
+var ErrStaleTimestamp = errors.New("timestamp outside the replay window")
+
+// ErrReplayedNonce is returned for a signed request whose nonce the client already used
+var ErrReplayedNonce = errors.New("nonce already used")
+
+// SigningClient is a client that signs its requests with a shared secret
+type SigningClient struct {
+	ClientID string `json:"client_id"`
+	Secret   string `json:"secret"`
+}
+
+// SignedRequest is what a request signature is checked against
+type SignedRequest struct {
+	ClientID  string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	// Target is the path and query string as sent by the client
+	Target string
+	Body   []byte
+}
+
+// RequestVerifier checks the HMAC signatures of requests and rejects replays
+type RequestVerifier struct {
+	secrets map[string][]byte
+	nonces  store.NonceStore
+	window  time.Duration
+	now     func() time.Time
+}
+
+// NewRequestVerifier creates a verifier for the requests of clients that
+// remembers their nonces in nonceStore. Timestamps may be off by up to window.
+func NewRequestVerifier(clients []SigningClient, nonceStore store.NonceStore, window time.Duration) (*RequestVerifier, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("no signing clients are configured")
+	}
+	if window <= 0 {
+		return nil, errors.New("signature window must be positive")
+	}
+	secrets := make(map[string][]byte, len(clients))
+	for i, client := range clients {
+		if client.ClientID == "" {
+			return nil, fmt.Errorf("signing client %d: client_id is required", i)
+		}
+		if _, exists := secrets[client.ClientID]; exists {
+			return nil, fmt.Errorf("signing client %s is listed twice", client.ClientID)
+		}
+		if len(client.Secret) < MinSigningSecretLength {
+			return nil, fmt.Errorf("signing client %s: secret must be at least %d bytes", client.ClientID, MinSigningSecretLength)
+		}
+		secrets[client.ClientID] = []byte(client.Secret)
+	}
+	return &RequestVerifier{secrets: secrets, nonces: nonceStore, window: window, now: time.Now}, nil
+}
+
+// LoadSigningClients reads the signing clients of a JSON file holding an
+// array of objects with a client_id and a secret
+func LoadSigningClients(path string) ([]SigningClient, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var clients []SigningClient
+	if err := json.Unmarshal(raw, &clients); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return clients, nil
+}
+
+// StringToSign returns the canonical form of a request that is signed: the
+// method, target, timestamp and nonce and the hex SHA-256 hash of the body,
+// one per line
+func StringToSign(method, target, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), target, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+// SignRequest returns the hex HMAC-SHA256 signature of a request with secret
+func SignRequest(secret []byte, method, target, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(StringToSign(method, target, timestamp, nonce, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of req, then that its timestamp is within the
+// replay window, and finally claims its nonce. The nonce is only claimed for
+// correctly signed requests, so that others cannot use up a client's nonces.
+// Failures wrap ErrInvalidSignature, ErrStaleTimestamp or ErrReplayedNonce.
+func (v *RequestVerifier) Verify(ctx context.Context, req SignedRequest) error {
+	if req.ClientID == "" || req.Timestamp == "" || req.Nonce == "" || req.Signature == "" {
+		return fmt.Errorf("%w: the client ID, timestamp, nonce and signature are required", ErrInvalidSignature)
+	}
+	if len(req.Nonce) < MinNonceLength || len(req.Nonce) > MaxNonceLength {
+		return fmt.Errorf("%w: the nonce must be %d to %d characters", ErrInvalidSignature, MinNonceLength, MaxNonceLength)
+	}
+	seconds, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: the timestamp must be in seconds since the epoch", ErrInvalidSignature)
+	}
+
+	secret, ok := v.secrets[req.ClientID]
+	if !ok {
+		return ErrInvalidSignature
+	}
+	signature, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: the signature must be hex encoded", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(StringToSign(req.Method, req.Target, req.Timestamp, req.Nonce, req.Body)))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	if skew := v.now().Sub(signedAt); skew > v.window || skew < -v.window {
+		return ErrStaleTimestamp
+	}
+	// The nonce is remembered for as long as the timestamp is accepted
+	claimed, err := v.nonces.ClaimNonce(ctx, req.ClientID, req.Nonce, signedAt.Add(v.window))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrReplayedNonce
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"ledger-service/store"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRequestVerifierVerify(t *testing.T) {
+	secret := "back-office-secret-0123456789abcdef"
+	verifier, err := NewRequestVerifier([]SigningClient{{ClientID: "back-office", Secret: secret}}, store.NewMemoryStore(), DefaultSignatureWindow)
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+
+	body := []byte(`{"customer_id": "c1", "type": "credit", "amount": 10}`)
+	signed := func(timestamp time.Time, nonce string) SignedRequest {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		return SignedRequest{
+			ClientID:  "back-office",
+			Timestamp: ts,
+			Nonce:     nonce,
+			Signature: SignRequest([]byte(secret), "POST", "/transactions", ts, nonce, body),
+			Method:    "POST",
+			Target:    "/transactions",
+			Body:      body,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		req     func() SignedRequest
+		wantErr error
+	}{
+		{"valid", func() SignedRequest { return signed(now, "nonce-0000000001") }, nil},
+		{"slightly ahead", func() SignedRequest { return signed(now.Add(time.Minute), "nonce-0000000002") }, nil},
+		{"replayed nonce", func() SignedRequest { return signed(now, "nonce-0000000001") }, ErrReplayedNonce},
+		{"stale", func() SignedRequest { return signed(now.Add(-6*time.Minute), "nonce-0000000003") }, ErrStaleTimestamp},
+		{"too far ahead", func() SignedRequest { return signed(now.Add(6*time.Minute), "nonce-0000000004") }, ErrStaleTimestamp},
+		{"tampered body", func() SignedRequest {
+			req := signed(now, "nonce-0000000005")
+			req.Body = []byte(`{"customer_id": "c1", "type": "credit", "amount": 1000}`)
+			return req
+		}, ErrInvalidSignature},
+		{"tampered target", func() SignedRequest {
+			req := signed(now, "nonce-0000000006")
+			req.Target = "/transactions?mode=async"
+			return req
+		}, ErrInvalidSignature},
+		{"tampered method", func() SignedRequest {
+			req := signed(now, "nonce-0000000007")
+			req.Method = "PUT"
+			return req
+		}, ErrInvalidSignature},
+		{"swapped nonce", func() SignedRequest {
+			req := signed(now, "nonce-0000000001")
+			req.Nonce = "nonce-0000000008"
+			return req
+		}, ErrInvalidSignature},
+		{"unknown client", func() SignedRequest {
+			req := signed(now, "nonce-0000000009")
+			req.ClientID = "someone-else"
+			return req
+		}, ErrInvalidSignature},
+		{"signature not hex", func() SignedRequest {
+			req := signed(now, "nonce-0000000010")
+			req.Signature = "not-hex"
+			return req
+		}, ErrInvalidSignature},
+		{"no signature", func() SignedRequest {
+			req := signed(now, "nonce-0000000011")
+			req.Signature = ""
+			return req
+		}, ErrInvalidSignature},
+		{"short nonce", func() SignedRequest { return signed(now, "abc") }, ErrInvalidSignature},
+		{"timestamp not in seconds", func() SignedRequest {
+			req := signed(now, "nonce-0000000012")
+			req.Timestamp = now.Format(time.RFC3339)
+			return req
+		}, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(context.Background(), tt.req()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A stale request does not use up its nonce, and a badly signed one neither
+	if err := verifier.Verify(context.Background(), signed(now, "nonce-0000000003")); err != nil {
+		t.Errorf("Verify() error = %v for the nonce of a stale request, want nil", err)
+	}
+	if err := verifier.Verify(context.Background(), signed(now, "nonce-0000000005")); err != nil {
+		t.Errorf("Verify() error = %v for the nonce of a badly signed request, want nil", err)
+	}
+}
+
+func TestNewRequestVerifier(t *testing.T) {
+	nonces := store.NewMemoryStore()
+	secret := "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name    string
+		clients []SigningClient
+		window  time.Duration
+	}{
+		{"no clients", nil, DefaultSignatureWindow},
+		{"no client ID", []SigningClient{{Secret: secret}}, DefaultSignatureWindow},
+		{"short secret", []SigningClient{{ClientID: "a", Secret: "short"}}, DefaultSignatureWindow},
+		{"duplicate client", []SigningClient{{ClientID: "a", Secret: secret}, {ClientID: "a", Secret: secret}}, DefaultSignatureWindow},
+		{"no window", []SigningClient{{ClientID: "a", Secret: secret}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRequestVerifier(tt.clients, nonces, tt.window); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -23,9 +23,49 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/customers": {
+        "/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists every API key, revoked ones included, oldest first, with when each was last used. Secrets are\nnever listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIKeyListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Creates a new customer with an optional initial balance (defaults to 0)",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an API key with the given scopes and returns its secret. The secret is not stored and is\nshown only in this response. Scopes are customers:read, customers:write, transactions:post and admin,\nwhich grants every other scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -33,25 +73,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "customers"
+                    "admin"
                 ],
-                "summary": "Create a new customer",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "description": "Customer details",
-                        "name": "customer",
+                        "description": "Key details",
+                        "name": "api_key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateCustomerRequest"
+                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Customer created successfully",
+                        "description": "API key created successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Customer"
+                            "$ref": "#/definitions/handlers.APIKeySecretResponse"
                         }
                     },
                     "400": {
@@ -69,43 +109,95 @@ const docTemplate = `{
                 }
             }
         },
-        "/customers/{customer_id}/balance": {
+        "/admin/api-keys/{key_id}": {
             "get": {
-                "description": "Retrieves the current balance of a customer",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Retrieves an API key without its secret",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "customers"
+                    "admin"
                 ],
-                "summary": "Get customer balance",
+                "summary": "Get an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customer_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Customer balance retrieved successfully",
+                        "description": "API key retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.BalanceResponse"
+                            "$ref": "#/definitions/models.APIKey"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes an API key for good. The key is kept, so its last use can still be looked up.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKey"
+                        }
                     },
                     "404": {
-                        "description": "Customer not found",
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "API key was already revoked",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -119,46 +211,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/customers/{customer_id}/transactions": {
-            "get": {
-                "description": "Retrieves the transaction history for a customer",
-                "consumes": [
-                    "application/json"
+        "/admin/api-keys/{key_id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Replaces the secret of an API key and returns the new one. The old secret stops working at once;\nthe key keeps its ID, name and scopes.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "customers"
+                    "admin"
                 ],
-                "summary": "Get transaction history",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Customer ID",
-                        "name": "customer_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction history retrieved successfully",
+                        "description": "API key rotated successfully",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.TransactionHistoryResponse"
-                            }
+                            "$ref": "#/definitions/handlers.APIKeySecretResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "404": {
+                        "description": "API key not found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Customer not found",
+                    "409": {
+                        "description": "API key was revoked",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -172,9 +266,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions": {
-            "post": {
-                "description": "Creates a new credit or debit transaction for a customer",
+        "/admin/customers/{customer_id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves a customer account between active, frozen and closed. Active and frozen accounts may move to any other state;\nclosed is final and requires a zero balance. Frozen accounts reject debits, and credits too unless the service allows them.\nEvery change is recorded in the account's status history.",
                 "consumes": [
                     "application/json"
                 ],
@@ -182,25 +284,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "admin"
                 ],
-                "summary": "Create a new transaction",
+                "summary": "Change account status",
                 "parameters": [
                     {
-                        "description": "Transaction details",
-                        "name": "transaction",
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status change",
+                        "name": "status",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateTransactionRequest"
+                            "$ref": "#/definitions/handlers.UpdateAccountStatusRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction processed successfully",
+                        "description": "Account status changed",
                         "schema": {
-                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                            "$ref": "#/definitions/models.Customer"
                         }
                     },
                     "400": {
@@ -215,6 +324,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Transition not allowed or balance not zero",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -223,101 +338,3448 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CreateCustomerRequest": {
-            "description": "Request body for creating a new customer",
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "balance": {
-                    "type": "number",
-                    "example": 100.5
-                },
-                "name": {
-                    "type": "string",
-                    "example": "John Doe"
-                }
-            }
         },
-        "handlers.CreateTransactionRequest": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 100
-                },
-                "customer_id": {
-                    "type": "string",
-                    "example": "ef48ae68-182f-4f2f-bb62-8a0016a9ca94"
-                },
-                "type": {
-                    "type": "string",
-                    "example": "credit"
+        "/admin/customers/{customer_id}/status-history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists every state change of a customer account, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get account status history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status history retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AccountStatusChange"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handlers.TransactionHistoryResponse": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 100
-                },
-                "timestamp": {
-                    "type": "string",
-                    "example": "2025-04-27T11:03:15Z"
-                },
-                "transaction_id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                },
-                "type": {
-                    "type": "string",
-                    "example": "credit"
+        "/admin/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists transactions that could not be posted after all retries, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List dead letters",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of dead letters to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letters retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeadLetterListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dead-letters/{transaction_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a dead-lettered transaction together with its attempt count and last error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letter retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeadLetter"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a dead-lettered transaction without posting it. Its status stays failed.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Discard dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Dead letter discarded"
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dead-letters/{transaction_id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Resubmits a dead-lettered transaction under its original transaction ID and removes it from the dead letters. Poll the returned Location for the outcome.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Replay dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Transaction resubmitted",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the transaction status resource"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Transaction queue unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/fx/rates": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds the rate of a currency pair taking effect at effective_from, now unless given. A rate for the\nsame pair and effective time is replaced. The spread is the fraction of the rate kept by the service.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Add an exchange rate",
+                "parameters": [
+                    {
+                        "description": "Rate details",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateExchangeRateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Rate added successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExchangeRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rate or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the audit entries of state-changing operations, newest first, with who made each change, from\nwhich request and address, and the target before and after it. ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` take RFC 3339\ntimestamps or YYYY-MM-DD dates; a date as ` + "`" + `to` + "`" + ` includes that whole day.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Query the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only entries by this API key ID or token subject",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries about this target ID",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries of this action, such as customer.update",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit entries retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuditListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conversions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Debits one currency balance of a customer and credits another atomically. Send quote_id to execute\na quote at its locked rate, or customer_id, from_currency, to_currency and amount to convert at\nthe rate in effect now. The conversion records the rate and spread it was executed at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Convert between currencies",
+                "parameters": [
+                    {
+                        "description": "Quote to execute or conversion details",
+                        "name": "conversion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateConversionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Conversion executed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConversionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of another customer",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer or quote not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Quote expired or already executed, or the account is frozen or closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate is in effect, or insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conversions/{conversion_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a conversion with the quote, rate and spread it was executed at and the IDs of its two legs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Get a conversion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversion ID",
+                        "name": "conversion_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversion retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversion"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversion not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists customers ordered by ID, optionally filtered by name. Closed customers are included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "List customers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the customer name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of customers to return (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Customers retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.CustomerListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a new customer with an optional initial balance and overdraft limit (both default to 0)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Create a new customer",
+                "parameters": [
+                    {
+                        "description": "Customer details",
+                        "name": "customer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Customer created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a customer, including its account state and version",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Get a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Customer retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Customer"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renames a customer or changes its overdraft limit. The update only applies if the customer is still at the given version;\notherwise it fails with 409 and the client should fetch the customer again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Update a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Customer changes",
+                        "name": "customer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Customer updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Customer was modified since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-closes a customer account. The balance must be zero and no holds may be active. The customer and its transaction history are kept for audit,\nbut the account accepts no further transactions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Close a customer account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why the account is closed, recorded in its status history",
+                        "name": "reason",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Customer account closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Customer"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Balance is not zero or the account is already closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/balance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the current ledger balance of a customer and its available balance, which excludes\nfunds reserved by active holds, with its overdraft limit and the part of it still available\nas credit. With as_of it returns the ledger balance at that moment\ntogether with the last transaction included in it. Without a currency the balance in USD is\nreturned together with a list of the balances in every currency the customer holds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Get customer balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code of the currency to report",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, or a YYYY-MM-DD date meaning the end of that day in UTC",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Customer balance retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.BalanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/conversions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the conversions of a customer, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "List customer conversions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversions retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConversionListResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/holds": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the holds of a customer, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "List customer holds",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "captured",
+                            "voided",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Only holds in this state",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Holds retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HoldListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/statements": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Builds a statement of a customer's account for the period from ` + "`" + `from` + "`" + ` up to, but excluding, ` + "`" + `to` + "`" + `,\nwith the opening balance, every transaction with its running balance, the credit and debit totals\nand the closing balance. A YYYY-MM-DD date as ` + "`" + `to` + "`" + ` includes that whole day. A statement covers\none currency, USD unless another is given.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/html"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Get account statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the period, as an RFC 3339 timestamp or a YYYY-MM-DD date",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the period, as an RFC 3339 timestamp or a YYYY-MM-DD date",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code of the currency to report (default USD)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "html"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statement built successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of a customer's transactions, ordered by timestamp, with the running balance after each one.\nPass the returned next token as cursor, with the same filters, to fetch the following page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Get transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "credit",
+                            "debit"
+                        ],
+                        "type": "string",
+                        "description": "Only credits or only debits",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only transactions in this ISO 4217 currency",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum amount, inclusive",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum amount, inclusive",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest timestamp, inclusive (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest timestamp, exclusive (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Timestamp order (default asc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of transactions to return (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next token of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction history retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransactionHistoryPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/fx/quotes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Prices converting an amount of a customer's balance in one currency into another at the rate in\neffect, less its spread, and locks that price until the quote expires. The converted amount is\ntruncated to the minor units of the target currency. Funds are checked when the quote is executed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Quote a conversion",
+                "parameters": [
+                    {
+                        "description": "Conversion to quote",
+                        "name": "quote",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Quote created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.FXQuote"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of another customer",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate is in effect for the currency pair",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/fx/quotes/{quote_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a quote and its state; open quotes past their expiry time are reported as expired",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Get a quote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quote ID",
+                        "name": "quote_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quote retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.FXQuote"
+                        }
+                    },
+                    "404": {
+                        "description": "Quote not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/fx/rates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the entries of the rate table by currency pair and effective time. A rate applies from its\neffective time until the next rate of the same pair takes effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "List exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only rates selling this currency",
+                        "name": "base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rates buying this currency",
+                        "name": "quote",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rates retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ExchangeRateListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reserves funds of a customer. Held funds stay in the ledger balance but no longer count towards\nthe available balance until the hold is captured, voided or expires. The currency defaults to USD.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Place a hold",
+                "parameters": [
+                    {
+                        "description": "Hold details",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Hold placed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of another customer",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Account is frozen or closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{hold_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a hold and its current state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Get a hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold ID",
+                        "name": "hold_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hold retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Hold"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{hold_id}/capture": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Releases an active hold and debits the captured amount, all of the hold unless a smaller amount\nis given, in the currency of the hold. The debit appears in the customer's transaction history with the hold ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Capture a hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold ID",
+                        "name": "hold_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount to capture",
+                        "name": "capture",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CaptureHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hold captured successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CaptureHoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or amount exceeds the hold",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of a customer rather than an operator",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is no longer active, has expired, or the account is frozen or closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Currency does not match the hold",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{hold_id}/void": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Releases an active hold without debiting anything",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Void a hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold ID",
+                        "name": "hold_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hold voided successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Hold"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of a customer rather than an operator",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is no longer active",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledger/customers/{customer_id}/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compares the stored balance of a customer in one currency with the balance derived from its journal\naccount, and checks that its transaction sequence has no gaps and its balance snapshots are continuous",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Reconcile customer balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code of the balance to compare (default USD)",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reconciliation computed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReconciliationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledger/trial-balance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the net balance of every journal account. Debits are positive and credits negative, so a balanced ledger totals zero.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Get trial balance",
+                "responses": {
+                    "200": {
+                        "description": "Trial balance retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrialBalanceResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a new credit or debit transaction for a customer in an ISO 4217 currency, USD unless given.\nSend an Idempotency-Key header to make retries safe: a repeated request returns the original result.\nUse ?mode=async or a \"Prefer: respond-async\" header to get a 202 right away and poll GET /transactions/{transaction_id}.\nFailures carry a machine-readable code: VALIDATION_FAILED or UNSUPPORTED_CURRENCY (400), CUSTOMER_NOT_FOUND (404), ACCOUNT_FROZEN, ACCOUNT_CLOSED or IDEMPOTENCY_KEY_CONFLICT (409),\nINSUFFICIENT_FUNDS (422), PROCESSING_TIMEOUT (408), STORAGE_UNAVAILABLE or QUEUE_UNAVAILABLE (503) and INTERNAL_ERROR (500).\nOnce a transaction was accepted its failures are reported as a TransactionStatusResponse with error_code and failure_reason.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Create a new transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique key identifying this request (max 255 characters)",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Send respond-async to process the transaction asynchronously",
+                        "name": "Prefer",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "sync",
+                            "async"
+                        ],
+                        "type": "string",
+                        "description": "Set to async to process the transaction asynchronously",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Transaction details",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction processed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Transaction accepted for asynchronous processing, or a request with the same Idempotency-Key is still processing",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (VALIDATION_FAILED) or unsupported currency (UNSUPPORTED_CURRENCY)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of another customer (CUSTOMER_ACCESS_DENIED), or a customer's token posting a credit (INSUFFICIENT_SCOPE)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found (CUSTOMER_NOT_FOUND)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Outcome not known in time (PROCESSING_TIMEOUT)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Account frozen or closed (ACCOUNT_FROZEN, ACCOUNT_CLOSED) or Idempotency-Key reused with a different request (IDEMPOTENCY_KEY_CONFLICT)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Debit exceeds the balance (INSUFFICIENT_FUNDS)",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (INTERNAL_ERROR)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Ledger or queue unavailable (STORAGE_UNAVAILABLE, QUEUE_UNAVAILABLE)",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{transaction_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reports whether a submitted transaction is pending, completed or failed, with the error code and failure reason or resulting balance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Get transaction status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction status retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionStatusRecord"
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{transaction_id}/reverse": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Posts a compensating transaction of the opposite type that references the original, and records\nthe reversed amount and reversal status on the original. Without an amount everything not yet\nreversed is reversed. Reversals are in the currency of the original and never add up to more than\nthe original amount. Reversals and the legs of transfers and conversions cannot be reversed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Reverse a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the transaction to reverse",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reversal details",
+                        "name": "reversal",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReverseTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Transaction reversed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReversalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of a customer rather than an operator",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Transaction not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Transaction is fully reversed or not reversible, or the account is frozen or closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Reversal exceeds the original amount, currency does not match the original, or insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Debits the source customer and credits the destination customer atomically, in one currency that defaults to USD. Both legs share the transfer ID and appear in each customer's transaction history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Transfer funds between customers",
+                "parameters": [
+                    {
+                        "description": "Transfer details",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfer completed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Token of a customer other than the source customer",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Account is frozen or closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKey"
+                    }
+                }
+            }
+        },
+        "handlers.APIKeySecretResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "secret": {
+                    "description": "Secret is only ever returned here; store it safely",
+                    "type": "string",
+                    "example": "lsk_Xy3kQ9aBv7Lm2Qw8Rt5Yu1Io4Pa6Sd9Fg3Hj0Kl2Zx"
+                }
+            }
+        },
+        "handlers.CaptureHoldRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount defaults to the whole hold; anything less releases the rest",
+                    "type": "number",
+                    "example": 35
+                },
+                "currency": {
+                    "description": "Currency, if given, must be the currency of the hold",
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "handlers.CaptureHoldResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 65
+                },
+                "hold": {
+                    "$ref": "#/definitions/models.Hold"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                }
+            }
+        },
+        "handlers.ConversionListResponse": {
+            "type": "object",
+            "properties": {
+                "conversions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Conversion"
+                    }
+                }
+            }
+        },
+        "handlers.ConversionResponse": {
+            "type": "object",
+            "properties": {
+                "conversion": {
+                    "$ref": "#/definitions/models.Conversion"
+                },
+                "from_balance": {
+                    "type": "number",
+                    "example": 150
+                },
+                "to_balance": {
+                    "type": "number",
+                    "example": 358.14
+                }
+            }
+        },
+        "handlers.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "payments backend"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "customers:read",
+                        "transactions:post"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateConversionRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "from_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "quote_id": {
+                    "description": "QuoteID executes a quote at its locked rate",
+                    "type": "string",
+                    "example": "9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "handlers.CreateCustomerRequest": {
+            "description": "Request body for creating a new customer",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "overdraft_limit": {
+                    "type": "number",
+                    "example": 500
+                }
+            }
+        },
+        "handlers.CreateExchangeRateRequest": {
+            "type": "object",
+            "properties": {
+                "base_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "effective_from": {
+                    "description": "EffectiveFrom defaults to now",
+                    "type": "string",
+                    "example": "2025-04-01T00:00:00Z"
+                },
+                "quote_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.0842
+                },
+                "source": {
+                    "type": "string",
+                    "example": "treasury desk"
+                },
+                "spread": {
+                    "type": "number",
+                    "example": 0.0025
+                }
+            }
+        },
+        "handlers.CreateHoldRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 40
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Card authorization 4821"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt defaults to seven days from now",
+                    "type": "string",
+                    "example": "2025-04-13T10:45:00Z"
+                }
+            }
+        },
+        "handlers.CreateQuoteRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "from_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "handlers.CreateTransactionRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "ef48ae68-182f-4f2f-bb62-8a0016a9ca94"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
+                }
+            }
+        },
+        "handlers.CreateTransferRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "from_customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "to_customer_id": {
+                    "type": "string",
+                    "example": "ef48ae68-182f-4f2f-bb62-8a0016a9ca94"
+                }
+            }
+        },
+        "handlers.ExchangeRateListResponse": {
+            "type": "object",
+            "properties": {
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExchangeRate"
+                    }
+                }
+            }
+        },
+        "handlers.HoldListResponse": {
+            "type": "object",
+            "properties": {
+                "holds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Hold"
+                    }
+                }
+            }
+        },
+        "handlers.ReverseTransactionRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount defaults to everything not reversed yet",
+                    "type": "number",
+                    "example": 25
+                },
+                "currency": {
+                    "description": "Currency, if given, must be the currency of the original transaction",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "duplicate charge"
+                }
+            }
+        },
+        "handlers.StatementResponse": {
+            "type": "object",
+            "properties": {
+                "closing_balance": {
+                    "type": "number",
+                    "example": 275
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.TransactionHistoryResponse"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-04-01T00:00:00Z"
+                },
+                "opening_balance": {
+                    "type": "number",
+                    "example": 100
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-05-01T00:00:00Z"
+                },
+                "total_credits": {
+                    "type": "number",
+                    "example": 250
+                },
+                "total_debits": {
+                    "type": "number",
+                    "example": 75
+                }
+            }
+        },
+        "handlers.TransactionHistoryPage": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string",
+                    "example": "eyJ0IjoiMjAyNS0wNC0yN1QxMTowMzoxNVoiLCJpZCI6IjEyMyJ9"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.TransactionHistoryResponse"
+                    }
+                }
+            }
+        },
+        "handlers.TransactionHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "balance_before": {
+                    "type": "number",
+                    "example": 150
+                },
+                "conversion_id": {
+                    "type": "string",
+                    "example": "4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "hold_id": {
+                    "type": "string",
+                    "example": "3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7"
+                },
+                "reversal_of": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "reversal_status": {
+                    "type": "string",
+                    "example": "partially_reversed"
+                },
+                "reversed_amount": {
+                    "type": "number",
+                    "example": 25
+                },
+                "running_balance": {
+                    "type": "number",
+                    "example": 250
+                },
+                "sequence": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-27T11:03:15Z"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "transfer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
+                }
+            }
+        },
+        "handlers.UpdateAccountStatusRequest": {
+            "description": "Request body for freezing, unfreezing or closing a customer account",
+            "type": "object",
+            "required": [
+                "actor",
+                "reason",
+                "status"
+            ],
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "ops@example.com"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "fraud investigation"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "frozen",
+                        "closed"
+                    ],
+                    "example": "frozen"
+                }
+            }
+        },
+        "handlers.UpdateCustomerRequest": {
+            "description": "Request body for updating a customer. Omitted fields are left unchanged. The balance can only change through transactions.",
+            "type": "object",
+            "required": [
+                "version"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Jane Doe"
+                },
+                "overdraft_limit": {
+                    "type": "number",
+                    "example": 500
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.APIKey": {
+            "description": "APIKey is a credential with a set of scopes; the secret itself is only returned when the key is created or rotated",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "key_id": {
+                    "type": "string",
+                    "example": "0f6e5d4c-3b2a-4198-8776-5544332211ff"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-04-07T08:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "payments backend"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "lsk_Xy3kQ9aB"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-06-06T10:45:00Z"
+                },
+                "rotated_at": {
+                    "type": "string",
+                    "example": "2025-05-06T10:45:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "customers:read",
+                        "transactions:post"
+                    ]
+                }
+            }
+        },
+        "models.AccountBalance": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "system:cash"
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 100
+                }
+            }
+        },
+        "models.AccountStatusChange": {
+            "description": "AccountStatusChange records who changed the state of an account, when and why",
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "ops@example.com"
+                },
+                "change_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "fraud investigation"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "frozen"
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "description": "AuditEntry records who changed what, when and from where, with the target before and after the change",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "customer.update"
+                },
+                "actor_id": {
+                    "description": "ActorID is the API key ID or the token subject",
+                    "type": "string",
+                    "example": "9f8e7d6c-5b4a-4321-8fed-cba987654321"
+                },
+                "actor_type": {
+                    "type": "string",
+                    "enum": [
+                        "api_key",
+                        "token",
+                        "anonymous",
+                        "system"
+                    ],
+                    "example": "api_key"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "audit_id": {
+                    "type": "string",
+                    "example": "01890a5d-ac96-774b-bcce-b302099a8057"
+                },
+                "before": {
+                    "description": "Before and After are JSON snapshots of the target, absent for targets\nthat did not exist before or after the change",
+                    "type": "object"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "0b9a8c7d-6e5f-4a3b-9c2d-1e0f9a8b7c6d"
+                },
+                "source_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "target_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "target_type": {
+                    "type": "string",
+                    "example": "customer"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                }
+            }
+        },
+        "models.AuditListResponse": {
+            "description": "AuditListResponse lists audit entries newest first. Pass next_cursor as cursor to fetch the next page.",
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntry"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "01890a5d-ac96-774b-bcce-b302099a8057"
                 }
             }
         },
         "models.BalanceResponse": {
             "type": "object",
             "properties": {
-                "balance": {
+                "as_of": {
+                    "type": "string",
+                    "example": "2025-04-06T23:59:59Z"
+                },
+                "available_balance": {
+                    "type": "number",
+                    "example": 60.5
+                },
+                "available_credit": {
+                    "type": "number",
+                    "example": 500
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "balances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CurrencyBalanceResponse"
+                    }
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "last_transaction_id": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "ledger_balance": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "overdraft_limit": {
+                    "type": "number",
+                    "example": 500
+                }
+            }
+        },
+        "models.Conversion": {
+            "description": "Conversion debits one currency balance of a customer and credits another at a quoted rate",
+            "type": "object",
+            "properties": {
+                "conversion_id": {
+                    "type": "string",
+                    "example": "4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a"
+                },
+                "credit_transaction_id": {
+                    "type": "string",
+                    "example": "6c2d1f0e-9d5e-4b64-8e1f-1e1d54f1d7b2"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "customer_rate": {
+                    "type": "number",
+                    "example": 1.0814895
+                },
+                "debit_transaction_id": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "from_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "quote_id": {
+                    "type": "string",
+                    "example": "9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.0842
+                },
+                "rate_id": {
+                    "type": "string",
+                    "example": "EUR/USD@2025-04-01T00:00:00Z"
+                },
+                "source_amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "spread": {
+                    "type": "number",
+                    "example": 0.0025
+                },
+                "spread_amount": {
+                    "type": "number",
+                    "example": 0.28
+                },
+                "target_amount": {
+                    "type": "number",
+                    "example": 108.14
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:12Z"
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "models.CurrencyBalance": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 250
+                },
+                "held_balance": {
+                    "type": "number",
+                    "example": 0
+                }
+            }
+        },
+        "models.CurrencyBalanceResponse": {
+            "type": "object",
+            "properties": {
+                "available_balance": {
+                    "type": "number",
+                    "example": 250
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "ledger_balance": {
+                    "type": "number",
+                    "example": 250
+                }
+            }
+        },
+        "models.Customer": {
+            "description": "Customer represents a financial account that can hold balance and perform transactions",
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 1000
+                },
+                "balances": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.CurrencyBalance"
+                    }
+                },
+                "closed_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "held_balance": {
+                    "type": "number",
+                    "example": 40
+                },
+                "last_posted_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "last_sequence": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "overdraft_limit": {
+                    "type": "number",
+                    "example": 500
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "frozen",
+                        "closed"
+                    ],
+                    "example": "active"
+                },
+                "status_changed_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "status_changed_by": {
+                    "type": "string",
+                    "example": "ops@example.com"
+                },
+                "status_reason": {
+                    "type": "string",
+                    "example": "fraud investigation"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.CustomerListResponse": {
+            "description": "CustomerListResponse lists customers ordered by ID. Pass next_cursor as cursor to fetch the next page.",
+            "type": "object",
+            "properties": {
+                "customers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Customer"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "models.DeadLetter": {
+            "description": "DeadLetter holds a transaction that could not be posted, for operators to inspect, replay or discard",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "failed_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "server selection error: context deadline exceeded"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/models.Transaction"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "models.DeadLetterListResponse": {
+            "description": "DeadLetterListResponse lists transactions that could not be posted",
+            "type": "object",
+            "properties": {
+                "dead_letters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeadLetter"
+                    }
+                }
+            }
+        },
+        "models.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "VALIDATION_FAILED",
+                "UNSUPPORTED_CURRENCY",
+                "CURRENCY_MISMATCH",
+                "CUSTOMER_NOT_FOUND",
+                "INSUFFICIENT_FUNDS",
+                "ACCOUNT_FROZEN",
+                "ACCOUNT_CLOSED",
+                "TRANSACTION_NOT_FOUND",
+                "NOT_REVERSIBLE",
+                "REVERSAL_EXCEEDS_ORIGINAL",
+                "EXCHANGE_RATE_NOT_FOUND",
+                "QUOTE_NOT_FOUND",
+                "QUOTE_EXPIRED",
+                "QUOTE_ALREADY_EXECUTED",
+                "CONVERSION_NOT_FOUND",
+                "AUTHENTICATION_REQUIRED",
+                "INVALID_API_KEY",
+                "INVALID_TOKEN",
+                "INVALID_SIGNATURE",
+                "STALE_TIMESTAMP",
+                "REPLAYED_NONCE",
+                "INSUFFICIENT_SCOPE",
+                "CUSTOMER_ACCESS_DENIED",
+                "API_KEY_NOT_FOUND",
+                "API_KEY_REVOKED",
+                "IDEMPOTENCY_KEY_CONFLICT",
+                "STORAGE_UNAVAILABLE",
+                "QUEUE_UNAVAILABLE",
+                "PROCESSING_TIMEOUT",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "ErrorCodeValidationFailed",
+                "ErrorCodeUnsupportedCurrency",
+                "ErrorCodeCurrencyMismatch",
+                "ErrorCodeCustomerNotFound",
+                "ErrorCodeInsufficientFunds",
+                "ErrorCodeAccountFrozen",
+                "ErrorCodeAccountClosed",
+                "ErrorCodeTransactionNotFound",
+                "ErrorCodeNotReversible",
+                "ErrorCodeReversalExceedsOriginal",
+                "ErrorCodeExchangeRateNotFound",
+                "ErrorCodeQuoteNotFound",
+                "ErrorCodeQuoteExpired",
+                "ErrorCodeQuoteUsed",
+                "ErrorCodeConversionNotFound",
+                "ErrorCodeAuthenticationRequired",
+                "ErrorCodeInvalidAPIKey",
+                "ErrorCodeInvalidToken",
+                "ErrorCodeInvalidSignature",
+                "ErrorCodeStaleTimestamp",
+                "ErrorCodeReplayedNonce",
+                "ErrorCodeInsufficientScope",
+                "ErrorCodeCustomerAccessDenied",
+                "ErrorCodeAPIKeyNotFound",
+                "ErrorCodeAPIKeyRevoked",
+                "ErrorCodeIdempotencyConflict",
+                "ErrorCodeStorageUnavailable",
+                "ErrorCodeQueueUnavailable",
+                "ErrorCodeTimeout",
+                "ErrorCodeInternal"
+            ]
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ],
+                    "example": "VALIDATION_FAILED"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Error message"
+                }
+            }
+        },
+        "models.ExchangeRate": {
+            "description": "ExchangeRate is an effective-dated entry of the rate table",
+            "type": "object",
+            "properties": {
+                "base_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-31T18:00:00Z"
+                },
+                "effective_from": {
+                    "type": "string",
+                    "example": "2025-04-01T00:00:00Z"
+                },
+                "quote_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.0842
+                },
+                "rate_id": {
+                    "type": "string",
+                    "example": "EUR/USD@2025-04-01T00:00:00Z"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "rates.json"
+                },
+                "spread": {
+                    "type": "number",
+                    "example": 0.0025
+                }
+            }
+        },
+        "models.FXQuote": {
+            "description": "FXQuote prices a conversion and locks the rate until the quote expires or is executed",
+            "type": "object",
+            "properties": {
+                "conversion_id": {
+                    "type": "string",
+                    "example": "4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "customer_rate": {
                     "type": "number",
-                    "example": 100.5
+                    "example": 1.0814895
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:30Z"
+                },
+                "from_currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "quote_id": {
+                    "type": "string",
+                    "example": "9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.0842
+                },
+                "rate_id": {
+                    "type": "string",
+                    "example": "EUR/USD@2025-04-01T00:00:00Z"
+                },
+                "source_amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "spread": {
+                    "type": "number",
+                    "example": 0.0025
+                },
+                "spread_amount": {
+                    "type": "number",
+                    "example": 0.28
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "executed",
+                        "expired"
+                    ],
+                    "example": "open"
+                },
+                "target_amount": {
+                    "type": "number",
+                    "example": 108.14
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "models.Hold": {
+            "description": "Hold reserves funds so they no longer count towards the available balance until the hold is captured, voided or expires",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 40
+                },
+                "capture_transaction_id": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "captured_amount": {
+                    "type": "number",
+                    "example": 35
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
                 },
                 "customer_id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Card authorization 4821"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-04-13T10:45:00Z"
+                },
+                "hold_id": {
+                    "type": "string",
+                    "example": "3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7"
+                },
+                "resolved_at": {
+                    "type": "string",
+                    "example": "2025-04-07T08:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "captured",
+                        "voided",
+                        "expired"
+                    ],
+                    "example": "active"
                 }
             }
         },
-        "models.Customer": {
-            "description": "Customer represents a financial account that can hold balance and perform transactions",
+        "models.ReconciliationResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "history_continuous": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "history_issue": {
+                    "type": "string",
+                    "example": "transaction sequence has a gap: 7 follows 5"
+                },
+                "in_balance": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "journal_balance": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "last_sequence": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "stored_balance": {
+                    "type": "number",
+                    "example": 100.5
+                }
+            }
+        },
+        "models.ReversalResponse": {
             "type": "object",
             "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25
+                },
                 "balance": {
                     "type": "number",
-                    "example": 1000
+                    "example": 125
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
                 },
                 "customer_id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 },
-                "name": {
+                "original_transaction_id": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "reversal_status": {
+                    "type": "string",
+                    "example": "partially_reversed"
+                },
+                "reversed_amount": {
+                    "type": "number",
+                    "example": 25
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-27T11:03:15Z"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "9a7f6c1e-3d52-4d8b-8f3e-1c2b3a4d5e6f"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.Transaction": {
+            "description": "Transaction represents a credit or debit operation on a customer's account",
             "type": "object",
             "properties": {
-                "error": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "balance_after": {
+                    "type": "number",
+                    "example": 200
+                },
+                "balance_before": {
+                    "type": "number",
+                    "example": 100
+                },
+                "conversion_id": {
                     "type": "string",
-                    "example": "Error message"
+                    "example": "4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "hold_id": {
+                    "type": "string",
+                    "example": "3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7"
+                },
+                "reversal_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reversal_of": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "reversal_reason": {
+                    "type": "string",
+                    "example": "duplicate charge"
+                },
+                "reversal_status": {
+                    "type": "string",
+                    "enum": [
+                        "partially_reversed",
+                        "reversed"
+                    ],
+                    "example": "partially_reversed"
+                },
+                "reversed_amount": {
+                    "type": "number",
+                    "example": 25
+                },
+                "sequence": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "transfer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
+                }
+            }
+        },
+        "models.TransactionStatusRecord": {
+            "description": "TransactionStatusRecord reports the processing state of a submitted transaction",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "error_code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ],
+                    "example": "INSUFFICIENT_FUNDS"
+                },
+                "failure_reason": {
+                    "type": "string",
+                    "example": "insufficient funds"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "completed"
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "credit"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-04-06T10:45:01Z"
                 }
             }
         },
@@ -328,6 +3790,22 @@ const docTemplate = `{
                     "type": "number",
                     "example": 100.5
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "error_code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ],
+                    "example": "INSUFFICIENT_FUNDS"
+                },
+                "failure_reason": {
+                    "type": "string",
+                    "example": "insufficient funds"
+                },
                 "status": {
                     "type": "string",
                     "example": "completed"
@@ -337,6 +3815,83 @@ const docTemplate = `{
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 }
             }
+        },
+        "models.TransferResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 75
+                },
+                "credit_transaction_id": {
+                    "type": "string",
+                    "example": "9a7f6c1e-3d52-4d8b-8f3e-1c2b3a4d5e6f"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "debit_transaction_id": {
+                    "type": "string",
+                    "example": "5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"
+                },
+                "from_customer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "completed"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-04-27T11:03:15Z"
+                },
+                "to_customer_id": {
+                    "type": "string",
+                    "example": "ef48ae68-182f-4f2f-bb62-8a0016a9ca94"
+                },
+                "transfer_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "models.TrialBalanceResponse": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AccountBalance"
+                    }
+                },
+                "balanced": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "total": {
+                    "type": "number",
+                    "example": 0
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT bearer token, sent as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/audit"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/queue"
+	"ledger-service/store"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	defaultDeadLetterLimit = 100
+	maxDeadLetterLimit     = 1000
+)
+
+// AdminHandler exposes operator endpoints for dead-lettered transactions and account states
+type AdminHandler struct {
+	dispatcher  *queue.Dispatcher
+	store       store.LedgerStore
+	deadLetters store.DeadLetterStore
+	audit       store.AuditStore
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(dispatcher *queue.Dispatcher, ledgerStore store.LedgerStore, deadLetterStore store.DeadLetterStore, auditStore store.AuditStore) *AdminHandler {
+	return &AdminHandler{
+		dispatcher:  dispatcher,
+		store:       ledgerStore,
+		deadLetters: deadLetterStore,
+		audit:       auditStore,
+	}
+}
+
+// ListDeadLetters handles listing dead-lettered transactions
+// @Summary List dead letters
+// @Description Lists transactions that could not be posted after all retries, oldest first
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Maximum number of dead letters to return (default 100, max 1000)"
+// @Success 200 {object} models.DeadLetterListResponse "Dead letters retrieved successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid limit"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/dead-letters [get]
+func (h *AdminHandler) ListDeadLetters(c *fiber.Ctx) error {
+	limit := defaultDeadLetterLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxDeadLetterLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit must be between 1 and 1000"})
+		}
+		limit = parsed
+	}
+
+	deadLetters, err := h.deadLetters.ListDeadLetters(c.Context(), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch dead letters"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.DeadLetterListResponse{DeadLetters: deadLetters})
+}
+
+// GetDeadLetter handles inspecting a dead-lettered transaction
+// @Summary Get dead letter
+// @Description Returns a dead-lettered transaction together with its attempt count and last error
+// @Tags admin
+// @Produce json
+// @Param transaction_id path string true "Transaction ID"
+// @Success 200 {object} models.DeadLetter "Dead letter retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/dead-letters/{transaction_id} [get]
+func (h *AdminHandler) GetDeadLetter(c *fiber.Ctx) error {
+	deadLetter, err := h.deadLetters.GetDeadLetter(c.Context(), c.Params("transaction_id"))
+	if err != nil {
+		if errors.Is(err, store.ErrDeadLetterNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Dead letter not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch dead letter"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(deadLetter)
+}
+
+// ReplayDeadLetter handles resubmitting a dead-lettered transaction
+// @Summary Replay dead letter
+// @Description Resubmits a dead-lettered transaction under its original transaction ID and removes it from the dead letters. Poll the returned Location for the outcome.
+// @Tags admin
+// @Produce json
+// @Param transaction_id path string true "Transaction ID"
+// @Success 202 {object} models.TransactionStatusResponse "Transaction resubmitted"
+// @Header 202 {string} Location "URL of the transaction status resource"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Transaction queue unavailable"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/dead-letters/{transaction_id}/replay [post]
+func (h *AdminHandler) ReplayDeadLetter(c *fiber.Ctx) error {
+	deadLetter, err := h.deadLetters.GetDeadLetter(c.Context(), c.Params("transaction_id"))
+	if err != nil {
+		if errors.Is(err, store.ErrDeadLetterNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Dead letter not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch dead letter"})
+	}
+	// The replayed transaction is posted on behalf of whoever replays it
+	ctx := auditContext(c)
+	origin := audit.OriginFrom(ctx)
+	transaction := deadLetter.Transaction
+	transaction.Origin = &origin
+
+	if err := h.store.SaveTransactionStatus(c.Context(), models.PendingStatus(transaction)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to record transaction"})
+	}
+
+	if _, err := h.dispatcher.Submit(transaction); err != nil {
+		h.store.SaveTransactionStatus(c.Context(), models.FailedStatus(transaction, models.ErrorCodeQueueUnavailable, "failed to queue transaction"))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{Error: "Failed to queue transaction"})
+	}
+
+	// The transaction is queued again, so a failure here only leaves a stale
+	// dead letter behind; replaying it twice is harmless as posting is idempotent
+	if err := h.deadLetters.DeleteDeadLetter(c.Context(), transaction.TransactionID); err != nil && !errors.Is(err, store.ErrDeadLetterNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to remove dead letter"})
+	}
+	logAudit(ctx, h.audit, models.AuditDeadLetterReplay, models.AuditTargetDeadLetter, transaction.TransactionID, deadLetter, nil)
+
+	c.Set(fiber.HeaderLocation, "/transactions/"+transaction.TransactionID)
+	return c.Status(fiber.StatusAccepted).JSON(models.TransactionStatusResponse{
+		TransactionID: transaction.TransactionID,
+		Status:        models.TransactionStatusPending,
+	})
+}
+
+// DiscardDeadLetter handles dropping a dead-lettered transaction
+// @Summary Discard dead letter
+// @Description Removes a dead-lettered transaction without posting it. Its status stays failed.
+// @Tags admin
+// @Param transaction_id path string true "Transaction ID"
+// @Success 204 "Dead letter discarded"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/dead-letters/{transaction_id} [delete]
+func (h *AdminHandler) DiscardDeadLetter(c *fiber.Ctx) error {
+	// Fetched first so that the audit entry shows what was discarded
+	deadLetter, err := h.deadLetters.GetDeadLetter(c.Context(), c.Params("transaction_id"))
+	if err == nil {
+		err = h.deadLetters.DeleteDeadLetter(c.Context(), deadLetter.TransactionID)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrDeadLetterNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Dead letter not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to discard dead letter"})
+	}
+	logAudit(auditContext(c), h.audit, models.AuditDeadLetterDiscard, models.AuditTargetDeadLetter, deadLetter.TransactionID, deadLetter, nil)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateAccountStatusRequest represents the request body for changing an account state
+// @Description Request body for freezing, unfreezing or closing a customer account
+type UpdateAccountStatusRequest struct {
+	Status string `json:"status" validate:"required" example:"frozen" enums:"active,frozen,closed" description:"The new account state"`
+	Reason string `json:"reason" validate:"required" example:"fraud investigation" description:"Why the state changes"`
+	Actor  string `json:"actor" validate:"required" example:"ops@example.com" description:"Who makes the change"`
+}
+
+// UpdateAccountStatus handles changing the state of a customer account
+// @Summary Change account status
+// @Description Moves a customer account between active, frozen and closed. Active and frozen accounts may move to any other state;
+// @Description closed is final and requires a zero balance. Frozen accounts reject debits, and credits too unless the service allows them.
+// @Description Every change is recorded in the account's status history.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Param status body UpdateAccountStatusRequest true "Status change"
+// @Success 200 {object} models.Customer "Account status changed"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 409 {object} models.ErrorResponse "Transition not allowed or balance not zero"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/customers/{customer_id}/status [put]
+func (h *AdminHandler) UpdateAccountStatus(c *fiber.Ctx) error {
+	var req UpdateAccountStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Invalid request body"})
+	}
+	if !models.IsValidCustomerStatus(req.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "status must be 'active', 'frozen' or 'closed'"})
+	}
+	if req.Reason == "" || req.Actor == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "reason and actor are required"})
+	}
+
+	customer, err := ledger.ChangeAccountStatus(auditContext(c), h.store, utils.CopyString(c.Params("customer_id")), req.Status, req.Reason, req.Actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrCustomerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
+		case errors.Is(err, models.ErrInvalidStatusTransition):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Cannot change account status: " + err.Error()})
+		case errors.Is(err, models.ErrAccountNotEmpty):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Balance must be zero and no holds active to close the account"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to change account status"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(customer)
+}
+
+// GetAccountStatusHistory handles retrieving the status audit trail of an account
+// @Summary Get account status history
+// @Description Lists every state change of a customer account, oldest first
+// @Tags admin
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Success 200 {array} models.AccountStatusChange "Status history retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/customers/{customer_id}/status-history [get]
+func (h *AdminHandler) GetAccountStatusHistory(c *fiber.Ctx) error {
+	customerID := c.Params("customer_id")
+	if _, err := h.store.GetCustomer(c.Context(), customerID); err != nil {
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch customer"})
+	}
+
+	changes, err := h.store.GetStatusHistory(c.Context(), customerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch status history"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(changes)
+}
+
+// RegisterRoutes registers the admin routes
+func (h *AdminHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/admin/dead-letters", h.ListDeadLetters)
+	app.Get("/admin/dead-letters/:transaction_id", h.GetDeadLetter)
+	app.Post("/admin/dead-letters/:transaction_id/replay", h.ReplayDeadLetter)
+	app.Delete("/admin/dead-letters/:transaction_id", h.DiscardDeadLetter)
+	app.Put("/admin/customers/:customer_id/status", h.UpdateAccountStatus)
+	app.Get("/admin/customers/:customer_id/status-history", h.GetAccountStatusHistory)
+}
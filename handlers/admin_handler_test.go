@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"ledger-service/models"
+	"ledger-service/queue"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewAdminHandler(dispatcher, ledgerStore, ledgerStore, ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100")}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	for _, id := range []string{"dl1", "dl2"} {
+		transaction := models.Transaction{TransactionID: id, CustomerID: "test_customer", Type: "credit", Amount: models.MustParseMoney("10"), Timestamp: time.Now()}
+		deadLetter := models.DeadLetter{TransactionID: id, Transaction: transaction, Attempts: 5, LastError: "storage unavailable", FailedAt: time.Now()}
+		if err := ledgerStore.SaveDeadLetter(context.Background(), deadLetter); err != nil {
+			t.Fatalf("Failed to save dead letter: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		expectedStatus int
+	}{
+		{"list", fiber.MethodGet, "/admin/dead-letters", fiber.StatusOK},
+		{"invalid limit", fiber.MethodGet, "/admin/dead-letters?limit=0", fiber.StatusBadRequest},
+		{"inspect", fiber.MethodGet, "/admin/dead-letters/dl1", fiber.StatusOK},
+		{"inspect missing", fiber.MethodGet, "/admin/dead-letters/missing", fiber.StatusNotFound},
+		{"replay", fiber.MethodPost, "/admin/dead-letters/dl1/replay", fiber.StatusAccepted},
+		{"replay again", fiber.MethodPost, "/admin/dead-letters/dl1/replay", fiber.StatusNotFound},
+		{"discard", fiber.MethodDelete, "/admin/dead-letters/dl2", fiber.StatusNoContent},
+		{"discard again", fiber.MethodDelete, "/admin/dead-letters/dl2", fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.target, nil))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	// The replayed transaction is posted under its original ID
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		record, err := ledgerStore.GetTransactionStatus(context.Background(), "dl1")
+		if err == nil && record.Status == models.TransactionStatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Replayed transaction was not completed: %+v, %v", record, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	remaining, err := ledgerStore.ListDeadLetters(context.Background(), 0)
+	if err != nil || len(remaining) != 0 {
+		t.Errorf("Remaining dead letters = %v (%v), want none", remaining, err)
+	}
+}
+
+func TestAccountStatusEndpoints(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewAdminHandler(dispatcher, ledgerStore, ledgerStore, ledgerStore)
+	transactions := NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+	transactions.RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		requestBody    string
+		expectedStatus int
+	}{
+		{"freeze", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "frozen", "reason": "fraud investigation", "actor": "ops"}`, fiber.StatusOK},
+		{"debit of frozen account", fiber.MethodPost, "/transactions", `{"customer_id": "test_customer", "type": "debit", "amount": 10}`, fiber.StatusConflict},
+		{"credit of frozen account", fiber.MethodPost, "/transactions", `{"customer_id": "test_customer", "type": "credit", "amount": 10}`, fiber.StatusOK},
+		{"unknown status", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "dormant", "reason": "r", "actor": "ops"}`, fiber.StatusBadRequest},
+		{"missing actor", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "active", "reason": "r"}`, fiber.StatusBadRequest},
+		{"close with balance", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "closed", "reason": "r", "actor": "ops"}`, fiber.StatusConflict},
+		{"unfreeze", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "active", "reason": "cleared", "actor": "ops"}`, fiber.StatusOK},
+		{"unfreeze again", fiber.MethodPut, "/admin/customers/test_customer/status", `{"status": "active", "reason": "cleared", "actor": "ops"}`, fiber.StatusConflict},
+		{"missing customer", fiber.MethodPut, "/admin/customers/missing/status", `{"status": "frozen", "reason": "r", "actor": "ops"}`, fiber.StatusNotFound},
+		{"history", fiber.MethodGet, "/admin/customers/test_customer/status-history", "", fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	history, err := ledgerStore.GetStatusHistory(context.Background(), "test_customer")
+	if err != nil || len(history) != 2 || history[0].Actor != "ops" || history[0].Reason != "fraud investigation" {
+		t.Errorf("Status history = %+v (%v), want freeze and unfreeze by ops", history, err)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"ledger-service/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles the management of API keys
+type APIKeyHandler struct {
+	keys  store.APIKeyStore
+	audit store.AuditStore
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(keyStore store.APIKeyStore, auditStore store.AuditStore) *APIKeyHandler {
+	return &APIKeyHandler{keys: keyStore, audit: auditStore}
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" example:"payments backend"`
+	Scopes []string `json:"scopes" example:"customers:read,transactions:post"`
+}
+
+// APIKeySecretResponse represents an API key together with its secret
+type APIKeySecretResponse struct {
+	APIKey models.APIKey `json:"api_key"`
+	// Secret is only ever returned here; store it safely
+	Secret string `json:"secret" example:"lsk_Xy3kQ9aBv7Lm2Qw8Rt5Yu1Io4Pa6Sd9Fg3Hj0Kl2Zx"`
+}
+
+// APIKeyListResponse represents a list of API keys
+type APIKeyListResponse struct {
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+// CreateAPIKey handles creating an API key
+// @Summary Create an API key
+// @Description Creates an API key with the given scopes and returns its secret. The secret is not stored and is
+// @Description shown only in this response. Scopes are customers:read, customers:write, transactions:post and admin,
+// @Description which grants every other scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param api_key body CreateAPIKeyRequest true "Key details"
+// @Success 201 {object} APIKeySecretResponse "API key created successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	}
+	key, secret, err := auth.IssueAPIKey(c.Context(), h.keys, req.Name, req.Scopes)
+	if err != nil {
+		return apiKeyError(c, err, "Failed to create API key")
+	}
+	logAudit(auditContext(c), h.audit, models.AuditAPIKeyCreate, models.AuditTargetAPIKey, key.KeyID, nil, key)
+	c.Location("/admin/api-keys/" + key.KeyID)
+	return c.Status(fiber.StatusCreated).JSON(APIKeySecretResponse{APIKey: key, Secret: secret})
+}
+
+// ListAPIKeys handles listing API keys
+// @Summary List API keys
+// @Description Lists every API key, revoked ones included, oldest first, with when each was last used. Secrets are
+// @Description never listed.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} APIKeyListResponse "API keys retrieved successfully"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.keys.ListAPIKeys(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, "Failed to fetch API keys"))
+	}
+	return c.Status(fiber.StatusOK).JSON(APIKeyListResponse{APIKeys: keys})
+}
+
+// GetAPIKey handles retrieving an API key
+// @Summary Get an API key
+// @Description Retrieves an API key without its secret
+// @Tags admin
+// @Produce json
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} models.APIKey "API key retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys/{key_id} [get]
+func (h *APIKeyHandler) GetAPIKey(c *fiber.Ctx) error {
+	key, err := h.keys.GetAPIKey(c.Context(), c.Params("key_id"))
+	if err != nil {
+		return apiKeyError(c, err, "Failed to fetch API key")
+	}
+	return c.Status(fiber.StatusOK).JSON(key)
+}
+
+// RotateAPIKey handles replacing the secret of an API key
+// @Summary Rotate an API key
+// @Description Replaces the secret of an API key and returns the new one. The old secret stops working at once;
+// @Description the key keeps its ID, name and scopes.
+// @Tags admin
+// @Produce json
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} APIKeySecretResponse "API key rotated successfully"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 409 {object} models.ErrorResponse "API key was revoked"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys/{key_id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *fiber.Ctx) error {
+	key, secret, err := auth.RotateAPIKey(c.Context(), h.keys, c.Params("key_id"))
+	if err != nil {
+		return apiKeyError(c, err, "Failed to rotate API key")
+	}
+	logAudit(auditContext(c), h.audit, models.AuditAPIKeyRotate, models.AuditTargetAPIKey, key.KeyID, nil, key)
+	return c.Status(fiber.StatusOK).JSON(APIKeySecretResponse{APIKey: key, Secret: secret})
+}
+
+// RevokeAPIKey handles revoking an API key
+// @Summary Revoke an API key
+// @Description Revokes an API key for good. The key is kept, so its last use can still be looked up.
+// @Tags admin
+// @Produce json
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} models.APIKey "API key revoked successfully"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 409 {object} models.ErrorResponse "API key was already revoked"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys/{key_id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	key, err := auth.RevokeAPIKey(c.Context(), h.keys, c.Params("key_id"))
+	if err != nil {
+		return apiKeyError(c, err, "Failed to revoke API key")
+	}
+	logAudit(auditContext(c), h.audit, models.AuditAPIKeyRevoke, models.AuditTargetAPIKey, key.KeyID, nil, key)
+	return c.Status(fiber.StatusOK).JSON(key)
+}
+
+// apiKeyError writes the response for an error from managing API keys
+func apiKeyError(c *fiber.Ctx, err error, fallback string) error {
+	var code models.ErrorCode
+	switch {
+	case errors.Is(err, auth.ErrNameRequired), errors.Is(err, auth.ErrScopesRequired), errors.Is(err, auth.ErrInvalidScope):
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	case errors.Is(err, store.ErrAPIKeyNotFound):
+		code = models.ErrorCodeAPIKeyNotFound
+	case errors.Is(err, models.ErrAPIKeyRevoked):
+		code = models.ErrorCodeAPIKeyRevoked
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, fallback))
+	}
+	return c.Status(errorCodeStatus(code)).JSON(errorResponse(code, code.Message()))
+}
+
+// RegisterRoutes registers the API key management routes
+func (h *APIKeyHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/admin/api-keys", h.CreateAPIKey)
+	app.Get("/admin/api-keys", h.ListAPIKeys)
+	app.Get("/admin/api-keys/:key_id", h.GetAPIKey)
+	app.Post("/admin/api-keys/:key_id/rotate", h.RotateAPIKey)
+	app.Delete("/admin/api-keys/:key_id", h.RevokeAPIKey)
+}
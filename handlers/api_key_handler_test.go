@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIKeyEndpoints(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	_, adminSecret, err := auth.IssueAPIKey(context.Background(), ledgerStore, "admin", []string{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("Failed to create admin key: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(NewAuthenticator(ledgerStore).Handle)
+	NewAPIKeyHandler(ledgerStore, ledgerStore).RegisterRoutes(app)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	do := func(method, target, secret, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if secret != "" {
+			req.Header.Set(APIKeyHeader, secret)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if out != nil {
+			json.Unmarshal(raw, out)
+		}
+		return resp.StatusCode
+	}
+
+	var reader APIKeySecretResponse
+	if status := do(fiber.MethodPost, "/admin/api-keys", adminSecret, `{"name": "reporting", "scopes": ["customers:read"]}`, &reader); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d creating key, got %d", fiber.StatusCreated, status)
+	}
+	if reader.Secret == "" || reader.APIKey.Name != "reporting" {
+		t.Fatalf("Created key = %+v, want the key and its secret", reader)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		secret         string
+		requestBody    string
+		expectedStatus int
+		expectedCode   models.ErrorCode
+	}{
+		{"health without key", fiber.MethodGet, "/health", "", "", fiber.StatusOK, ""},
+		{"no key", fiber.MethodGet, "/customers", "", "", fiber.StatusUnauthorized, models.ErrorCodeAuthenticationRequired},
+		{"unknown key", fiber.MethodGet, "/customers", "lsk_unknown", "", fiber.StatusUnauthorized, models.ErrorCodeInvalidAPIKey},
+		{"read with read scope", fiber.MethodGet, "/customers", reader.Secret, "", fiber.StatusOK, ""},
+		{"write with read scope", fiber.MethodPost, "/customers", reader.Secret, `{"name": "Alice"}`, fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"admin route with read scope", fiber.MethodGet, "/admin/api-keys", reader.Secret, "", fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"write with admin scope", fiber.MethodPost, "/customers", adminSecret, `{"name": "Alice"}`, fiber.StatusCreated, ""},
+		{"create without scopes", fiber.MethodPost, "/admin/api-keys", adminSecret, `{"name": "empty"}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"create with unknown scope", fiber.MethodPost, "/admin/api-keys", adminSecret, `{"name": "bad", "scopes": ["root"]}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"get key", fiber.MethodGet, "/admin/api-keys/" + reader.APIKey.KeyID, adminSecret, "", fiber.StatusOK, ""},
+		{"get missing key", fiber.MethodGet, "/admin/api-keys/missing", adminSecret, "", fiber.StatusNotFound, models.ErrorCodeAPIKeyNotFound},
+		{"rotate missing key", fiber.MethodPost, "/admin/api-keys/missing/rotate", adminSecret, "", fiber.StatusNotFound, models.ErrorCodeAPIKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response models.ErrorResponse
+			if status := do(tt.method, tt.target, tt.secret, tt.requestBody, &response); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if tt.expectedCode != "" && response.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, response.Code)
+			}
+		})
+	}
+
+	var rotated APIKeySecretResponse
+	if status := do(fiber.MethodPost, "/admin/api-keys/"+reader.APIKey.KeyID+"/rotate", adminSecret, "", &rotated); status != fiber.StatusOK {
+		t.Fatalf("Expected status %d rotating key, got %d", fiber.StatusOK, status)
+	}
+	if status := do(fiber.MethodGet, "/customers", reader.Secret, "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d with the rotated-out secret, got %d", fiber.StatusUnauthorized, status)
+	}
+	if status := do(fiber.MethodGet, "/customers", rotated.Secret, "", nil); status != fiber.StatusOK {
+		t.Errorf("Expected status %d with the new secret, got %d", fiber.StatusOK, status)
+	}
+
+	if status := do(fiber.MethodDelete, "/admin/api-keys/"+reader.APIKey.KeyID, adminSecret, "", nil); status != fiber.StatusOK {
+		t.Fatalf("Expected status %d revoking key, got %d", fiber.StatusOK, status)
+	}
+	if status := do(fiber.MethodGet, "/customers", rotated.Secret, "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d with a revoked key, got %d", fiber.StatusUnauthorized, status)
+	}
+	if status := do(fiber.MethodDelete, "/admin/api-keys/"+reader.APIKey.KeyID, adminSecret, "", nil); status != fiber.StatusConflict {
+		t.Errorf("Expected status %d revoking twice, got %d", fiber.StatusConflict, status)
+	}
+
+	var list APIKeyListResponse
+	do(fiber.MethodGet, "/admin/api-keys", adminSecret, "", &list)
+	if len(list.APIKeys) != 2 || list.APIKeys[1].RevokedAt == nil || list.APIKeys[1].LastUsedAt == nil || list.APIKeys[0].LastUsedAt == nil {
+		t.Errorf("API keys = %+v, want the admin key and the revoked key, both used", list.APIKeys)
+	}
+	raw, _ := json.Marshal(list)
+	if strings.Contains(string(raw), models.HashAPIKeySecret(adminSecret)) || strings.Contains(string(raw), rotated.Secret) {
+		t.Error("Listed API keys expose a secret or its hash")
+	}
+}
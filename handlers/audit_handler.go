@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// RequestIDLocal is the c.Locals key under which the request ID middleware
+// stores the ID of a request
+const RequestIDLocal = "requestid"
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// AuditHandler handles audit log queries
+type AuditHandler struct {
+	audit store.AuditStore
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditStore store.AuditStore) *AuditHandler {
+	return &AuditHandler{audit: auditStore}
+}
+
+// ListAuditEntries handles querying the audit log
+// @Summary Query the audit log
+// @Description Lists the audit entries of state-changing operations, newest first, with who made each change, from
+// @Description which request and address, and the target before and after it. `from` and `to` take RFC 3339
+// @Description timestamps or YYYY-MM-DD dates; a date as `to` includes that whole day.
+// @Tags admin
+// @Produce json
+// @Param actor query string false "Only entries by this API key ID or token subject"
+// @Param target query string false "Only entries about this target ID"
+// @Param action query string false "Only entries of this action, such as customer.update"
+// @Param from query string false "Only entries at or after this time"
+// @Param to query string false "Only entries before this time"
+// @Param limit query int false "Maximum number of entries to return (default 100, max 1000)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} models.AuditListResponse "Audit entries retrieved successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *AuditHandler) ListAuditEntries(c *fiber.Ctx) error {
+	query := store.AuditQuery{
+		ActorID:  c.Query("actor"),
+		TargetID: c.Query("target"),
+		Action:   c.Query("action"),
+		BeforeID: c.Query("cursor"),
+	}
+	var err error
+	if raw := c.Query("from"); raw != "" {
+		if query.From, err = parsePeriodBound(raw, "from", false); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if query.To, err = parsePeriodBound(raw, "to", true); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+		}
+	}
+	limit := defaultAuditPageSize
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxAuditPageSize {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "limit must be between 1 and 1000"))
+		}
+		limit = parsed
+	}
+	// One entry more than the page tells whether there is a next page
+	query.Limit = limit + 1
+
+	entries, err := h.audit.ListAuditEntries(c.Context(), query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, "Failed to fetch audit entries"))
+	}
+	response := models.AuditListResponse{Entries: entries}
+	if len(entries) > limit {
+		response.Entries = entries[:limit]
+		response.NextCursor = entries[limit-1].AuditID
+	}
+	if response.Entries == nil {
+		response.Entries = []models.AuditEntry{}
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// RegisterRoutes registers the audit routes
+func (h *AuditHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/audit", h.ListAuditEntries)
+}
+
+// auditContext returns the context of the request carrying who made it, so
+// that the changes made with it are audited against them. Requests that did
+// not pass the authenticator are audited as anonymous.
+func auditContext(c *fiber.Ctx) context.Context {
+	principal := principalOf(c)
+	// Principal kinds are named after the matching actor types
+	origin := models.AuditOrigin{
+		ActorType: principal.Kind,
+		ActorID:   principal.ID,
+		SourceIP:  c.IP(),
+	}
+	if origin.ActorType == "" {
+		origin.ActorType = models.ActorAnonymous
+	}
+	// The request ID may come from a header, whose memory fasthttp reuses
+	if requestID, ok := c.Locals(RequestIDLocal).(string); ok {
+		origin.RequestID = utils.CopyString(requestID)
+	}
+	return audit.NewContext(c.Context(), origin)
+}
+
+// logAudit records a change made outside a session. The change is made by
+// then, so failing to record it is logged rather than failing the request.
+func logAudit(ctx context.Context, auditStore store.AuditStore, action, targetType, targetID string, before, after interface{}) {
+	if err := audit.Log(ctx, auditStore, action, targetType, targetID, before, after); err != nil {
+		log.Printf("failed to audit %s of %s %s: %v", action, targetType, targetID, err)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func TestAuditEndpoint(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	adminKey, adminSecret, err := auth.IssueAPIKey(context.Background(), ledgerStore, "admin", []string{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("Failed to create admin key: %v", err)
+	}
+	_, readerSecret, err := auth.IssueAPIKey(context.Background(), ledgerStore, "reader", []string{models.ScopeCustomersRead})
+	if err != nil {
+		t.Fatalf("Failed to create reader key: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(requestid.New(requestid.Config{ContextKey: RequestIDLocal}))
+	app.Use(NewAuthenticator(ledgerStore).Handle)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+	NewAuditHandler(ledgerStore).RegisterRoutes(app)
+
+	do := func(method, target, secret, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set(APIKeyHeader, secret)
+		req.Header.Set(fiber.HeaderXRequestID, "req-"+method)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	var customer models.Customer
+	if status := do(fiber.MethodPost, "/customers", adminSecret, `{"name": "Alice", "initial_balance": 100}`, &customer); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d creating a customer, got %d", fiber.StatusCreated, status)
+	}
+	if status := do(fiber.MethodPut, "/customers/"+customer.CustomerID, adminSecret, `{"name": "Alice Smith", "version": 0}`, nil); status != fiber.StatusOK {
+		t.Fatalf("Expected status %d updating the customer, got %d", fiber.StatusOK, status)
+	}
+
+	var page models.AuditListResponse
+	if status := do(fiber.MethodGet, "/audit?actor="+adminKey.KeyID+"&target="+customer.CustomerID, adminSecret, "", &page); status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	if len(page.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", page.Entries)
+	}
+	update, create := page.Entries[0], page.Entries[1]
+	if create.Action != models.AuditCustomerCreate || update.Action != models.AuditCustomerUpdate {
+		t.Errorf("Expected %s then %s, got %s then %s", models.AuditCustomerCreate, models.AuditCustomerUpdate, create.Action, update.Action)
+	}
+	if update.ActorType != models.ActorAPIKey || update.RequestID != "req-PUT" || update.SourceIP == "" {
+		t.Errorf("Expected the update attributed to the admin key and its request, got %+v", update.AuditOrigin)
+	}
+	var before, after models.Customer
+	json.Unmarshal(update.Before, &before)
+	json.Unmarshal(update.After, &after)
+	if before.Name != "Alice" || after.Name != "Alice Smith" {
+		t.Errorf("Expected the name to change from Alice to Alice Smith, got %q to %q", before.Name, after.Name)
+	}
+
+	var first, second models.AuditListResponse
+	do(fiber.MethodGet, "/audit?target="+customer.CustomerID+"&limit=1", adminSecret, "", &first)
+	if len(first.Entries) != 1 || first.NextCursor == "" {
+		t.Fatalf("Expected one entry and a next cursor, got %+v", first)
+	}
+	do(fiber.MethodGet, "/audit?target="+customer.CustomerID+"&limit=1&cursor="+first.NextCursor, adminSecret, "", &second)
+	if len(second.Entries) != 1 || second.Entries[0].AuditID != create.AuditID || second.NextCursor != "" {
+		t.Errorf("Expected the create entry as the last page, got %+v", second)
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		secret         string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"by action", "/audit?action=customer.create", adminSecret, fiber.StatusOK, 1},
+		{"by other actor", "/audit?actor=someone-else", adminSecret, fiber.StatusOK, 0},
+		{"up to a day before", "/audit?to=2000-01-01", adminSecret, fiber.StatusOK, 0},
+		{"from a day before", "/audit?from=2000-01-01", adminSecret, fiber.StatusOK, 2},
+		{"bad from", "/audit?from=yesterday", adminSecret, fiber.StatusBadRequest, 0},
+		{"bad limit", "/audit?limit=0", adminSecret, fiber.StatusBadRequest, 0},
+		{"without admin scope", "/audit", readerSecret, fiber.StatusForbidden, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response models.AuditListResponse
+			if status := do(fiber.MethodGet, tt.target, tt.secret, "", &response); status != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if len(response.Entries) != tt.expectedCount {
+				t.Errorf("Expected %d entries, got %d", tt.expectedCount, len(response.Entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"ledger-service/store"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader is the request header that carries an API key secret
+const APIKeyHeader = "X-API-Key"
+
+// bearerScheme is the Authorization scheme of JWT bearer tokens
+const bearerScheme = "Bearer"
+
+// principalLocal is the c.Locals key of the principal a request was authenticated as
+const principalLocal = "principal"
+
+// Authenticator is the middleware that lets a request through only with an
+// API key or bearer token granting the scope its route needs
+type Authenticator struct {
+	keys   store.APIKeyStore
+	tokens *auth.TokenVerifier
+}
+
+// NewAuthenticator creates a new authenticator checking keys against keyStore
+func NewAuthenticator(keyStore store.APIKeyStore) *Authenticator {
+	return &Authenticator{keys: keyStore}
+}
+
+// SetTokenVerifier accepts JWT bearer tokens verified by verifier. Without
+// one every bearer token is rejected.
+func (a *Authenticator) SetTokenVerifier(verifier *auth.TokenVerifier) {
+	a.tokens = verifier
+}
+
+// Handle authenticates the request and passes it on. Requests without
+// credentials or with an unknown or revoked key or an invalid token are
+// rejected with 401, those lacking the scope of the route with 403, and
+// those of a customer's token for another customer's data with 403.
+func (a *Authenticator) Handle(c *fiber.Ctx) error {
+	if _, public := auth.RequiredScope(c.Method(), c.Path()); public {
+		return c.Next()
+	}
+
+	var principal auth.Principal
+	if token, ok := bearerToken(c.Get(fiber.HeaderAuthorization)); ok {
+		if a.tokens == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeInvalidToken, models.ErrorCodeInvalidToken.Message()))
+		}
+		claims, err := a.tokens.Verify(token)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeInvalidToken, models.ErrorCodeInvalidToken.Message()))
+		}
+		principal = claims.Principal()
+	} else {
+		secret := c.Get(APIKeyHeader)
+		if secret == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeAuthenticationRequired, models.ErrorCodeAuthenticationRequired.Message()))
+		}
+		key, err := auth.Authenticate(c.Context(), a.keys, secret)
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeInvalidAPIKey, models.ErrorCodeInvalidAPIKey.Message()))
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, "Failed to authenticate request"))
+		}
+		principal = auth.APIKeyPrincipal(key)
+	}
+
+	err := principal.Authorize(c.Method(), c.Path())
+	if errors.Is(err, auth.ErrCustomerAccessDenied) {
+		return customerAccessDenied(c)
+	}
+	if errors.Is(err, auth.ErrOperatorOnly) {
+		return operatorOnly(c)
+	}
+	if err != nil {
+		scope, _ := auth.RequiredScope(c.Method(), c.Path())
+		return c.Status(fiber.StatusForbidden).JSON(errorResponse(models.ErrorCodeInsufficientScope, "The credentials do not grant the "+scope+" scope"))
+	}
+
+	c.Locals(principalLocal, principal)
+	return c.Next()
+}
+
+// bearerToken returns the token of an Authorization header using the Bearer scheme
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, bearerScheme) {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// principalOf returns the principal a request was authenticated as. Requests
+// that did not pass the authenticator, as when authentication is disabled,
+// get the zero principal, which is not restricted to a customer.
+func principalOf(c *fiber.Ctx) auth.Principal {
+	principal, _ := c.Locals(principalLocal).(auth.Principal)
+	return principal
+}
+
+// canAccessCustomer reports whether the request may see and act on the data of customerID
+func canAccessCustomer(c *fiber.Ctx, customerID string) bool {
+	return principalOf(c).CanAccessCustomer(customerID)
+}
+
+// operatorOnly rejects a request of a customer's token that only API keys
+// and operator tokens may make
+func operatorOnly(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(errorResponse(models.ErrorCodeInsufficientScope, "Only API keys and operator tokens may do this"))
+}
+
+// customerAccessDenied rejects a request for another customer's data
+func customerAccessDenied(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(errorResponse(models.ErrorCodeCustomerAccessDenied, models.ErrorCodeCustomerAccessDenied.Message()))
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"ledger-service/auth"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/queue"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// hs256Token signs claims into an HS256 bearer token
+func hs256Token(t *testing.T, secret []byte, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Failed to encode token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signingInput := encode(map[string]string{"alg": auth.AlgHS256, "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestBearerTokenIsolation(t *testing.T) {
+	ctx := context.Background()
+	ledgerStore := setupTestStore(t)
+	for _, id := range []string{"alice", "bob"} {
+		customer := models.Customer{CustomerID: id, Name: id, Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+		if err := ledgerStore.CreateCustomer(ctx, customer); err != nil {
+			t.Fatalf("Failed to create test customer: %v", err)
+		}
+	}
+	holds := make(map[string]models.Hold)
+	for _, id := range []string{"alice", "bob"} {
+		hold, err := ledger.PlaceHold(ctx, ledgerStore, models.Hold{
+			HoldID:     models.GenerateHoldID(),
+			CustomerID: id,
+			Amount:     models.MustParseMoney("10"),
+			Currency:   models.DefaultCurrency,
+			CreatedAt:  time.Now(),
+			ExpiresAt:  time.Now().Add(time.Hour),
+		}, ledger.DefaultPolicy)
+		if err != nil {
+			t.Fatalf("Failed to place test hold: %v", err)
+		}
+		holds[id] = hold
+	}
+	aliceHold, bobHold := holds["alice"], holds["bob"]
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	verifier, err := auth.NewTokenVerifier(auth.TokenConfig{HMACSecret: secret, Audience: "ledger"})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	authenticator := NewAuthenticator(ledgerStore)
+	authenticator.SetTokenVerifier(verifier)
+
+	app := fiber.New()
+	app.Use(authenticator.Handle)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+	NewHoldHandler(ledgerStore, ledger.DefaultPolicy).RegisterRoutes(app)
+	NewTransactionHandler(dispatcher, ledgerStore, ledgerStore).RegisterRoutes(app)
+	NewReversalHandler(ledgerStore, ledger.DefaultPolicy).RegisterRoutes(app)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	aliceToken := hs256Token(t, secret, map[string]interface{}{"sub": "alice", "aud": "ledger", "exp": exp, "scope": "customers:read transactions:post"})
+	operatorToken := hs256Token(t, secret, map[string]interface{}{"sub": "support", "aud": "ledger", "exp": exp, "role": auth.OperatorRole})
+	postingOperatorToken := hs256Token(t, secret, map[string]interface{}{"sub": "support", "aud": "ledger", "exp": exp, "role": auth.OperatorRole, "scope": "customers:read transactions:post"})
+	expiredToken := hs256Token(t, secret, map[string]interface{}{"sub": "alice", "aud": "ledger", "exp": time.Now().Add(-time.Hour).Unix()})
+	otherAudienceToken := hs256Token(t, secret, map[string]interface{}{"sub": "alice", "aud": "billing", "exp": exp})
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		token          string
+		requestBody    string
+		expectedStatus int
+		expectedCode   models.ErrorCode
+	}{
+		{"own balance", fiber.MethodGet, "/customers/alice/balance", aliceToken, "", fiber.StatusOK, ""},
+		{"other customer's balance", fiber.MethodGet, "/customers/bob/balance", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeCustomerAccessDenied},
+		{"other customer's holds", fiber.MethodGet, "/customers/bob/holds", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeCustomerAccessDenied},
+		{"list customers", fiber.MethodGet, "/customers", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeCustomerAccessDenied},
+		{"close own account", fiber.MethodDelete, "/customers/alice", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"other customer's hold", fiber.MethodGet, "/holds/" + bobHold.HoldID, aliceToken, "", fiber.StatusNotFound, ""},
+		{"void own hold", fiber.MethodPost, "/holds/" + aliceHold.HoldID + "/void", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"capture own hold", fiber.MethodPost, "/holds/" + aliceHold.HoldID + "/capture", aliceToken, "", fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"credit own account", fiber.MethodPost, "/transactions", aliceToken, `{"customer_id": "alice", "type": "credit", "amount": 5}`, fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"debit own account", fiber.MethodPost, "/transactions", aliceToken, `{"customer_id": "alice", "type": "debit", "amount": 5}`, fiber.StatusOK, ""},
+		{"reverse own transaction", fiber.MethodPost, "/transactions/t1/reverse", aliceToken, `{"reason": "undo"}`, fiber.StatusForbidden, models.ErrorCodeInsufficientScope},
+		{"operator voids a customer's hold", fiber.MethodPost, "/holds/" + aliceHold.HoldID + "/void", postingOperatorToken, "", fiber.StatusOK, ""},
+		{"operator credits a customer", fiber.MethodPost, "/transactions", postingOperatorToken, `{"customer_id": "alice", "type": "credit", "amount": 5}`, fiber.StatusOK, ""},
+		{"hold on other customer", fiber.MethodPost, "/holds", aliceToken, `{"customer_id": "bob", "amount": 5}`, fiber.StatusForbidden, models.ErrorCodeCustomerAccessDenied},
+		{"hold on own account", fiber.MethodPost, "/holds", aliceToken, `{"customer_id": "alice", "amount": 5}`, fiber.StatusCreated, ""},
+		{"operator reads any balance", fiber.MethodGet, "/customers/bob/balance", operatorToken, "", fiber.StatusOK, ""},
+		{"operator reads any hold", fiber.MethodGet, "/holds/" + bobHold.HoldID, operatorToken, "", fiber.StatusOK, ""},
+		{"operator lists customers", fiber.MethodGet, "/customers", operatorToken, "", fiber.StatusOK, ""},
+		{"expired token", fiber.MethodGet, "/customers/alice/balance", expiredToken, "", fiber.StatusUnauthorized, models.ErrorCodeInvalidToken},
+		{"token for another audience", fiber.MethodGet, "/customers/alice/balance", otherAudienceToken, "", fiber.StatusUnauthorized, models.ErrorCodeInvalidToken},
+		{"malformed token", fiber.MethodGet, "/customers/alice/balance", "not-a-token", "", fiber.StatusUnauthorized, models.ErrorCodeInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.requestBody))
+			if tt.requestBody != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			var response models.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&response)
+			if tt.expectedCode != "" && response.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, response.Code)
+			}
+		})
+	}
+
+	// Without a verifier every bearer token is rejected
+	app = fiber.New()
+	app.Use(NewAuthenticator(ledgerStore).Handle)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+	req := httptest.NewRequest(fiber.MethodGet, "/customers/alice/balance", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+aliceToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d without a verifier, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -2,40 +2,50 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"ledger-service/ledger"
 	"ledger-service/models"
+	"ledger-service/store"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	defaultCustomerPageSize = 50
+	maxCustomerPageSize     = 200
+
+	// customerAPIActor is recorded as the actor of status changes made through the customer API
+	customerAPIActor = "customer-api"
 )
 
 // CustomerHandler handles customer-related HTTP requests
 type CustomerHandler struct {
-	customersCollection    *mongo.Collection
-	transactionsCollection *mongo.Collection
-	mu                    sync.RWMutex
+	store store.LedgerStore
+	mu    sync.RWMutex
 }
 
 // NewCustomerHandler creates a new CustomerHandler
-func NewCustomerHandler(customersCollection, transactionsCollection *mongo.Collection) *CustomerHandler {
+func NewCustomerHandler(ledgerStore store.LedgerStore) *CustomerHandler {
 	return &CustomerHandler{
-		customersCollection:    customersCollection,
-		transactionsCollection: transactionsCollection,
+		store: ledgerStore,
 	}
 }
 
 // CreateCustomerRequest represents the request body for creating a customer
 // @Description Request body for creating a new customer
 type CreateCustomerRequest struct {
-	Name    string   `json:"name" validate:"required" example:"John Doe" description:"The name of the customer"`
-	Balance *float64 `json:"balance,omitempty" example:"100.50" description:"Initial balance (optional, defaults to 0)"`
+	Name           string        `json:"name" validate:"required" example:"John Doe" description:"The name of the customer"`
+	Balance        *models.Money `json:"balance,omitempty" swaggertype:"number" example:"100.50" description:"Initial balance (optional, defaults to 0)"`
+	OverdraftLimit *models.Money `json:"overdraft_limit,omitempty" swaggertype:"number" example:"500.00" description:"How far the available balance may go below zero (optional, defaults to 0)"`
 }
 
 // CreateCustomer handles the creation of a new customer
 // @Summary Create a new customer
-// @Description Creates a new customer with an optional initial balance (defaults to 0)
+// @Description Creates a new customer with an optional initial balance and overdraft limit (both default to 0)
 // @Tags customers
 // @Accept json
 // @Produce json
@@ -43,6 +53,8 @@ type CreateCustomerRequest struct {
 // @Success 201 {object} models.Customer "Customer created successfully"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /customers [post]
 func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 	var req CreateCustomerRequest
@@ -52,19 +64,43 @@ func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 		})
 	}
 
+	currency, err := models.LookupCurrency(models.DefaultCurrency)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Default currency is not configured",
+		})
+	}
+
 	// Set default balance to 0 if not provided
-	initialBalance := 0.0
+	initialBalance := models.NewMoneyFromMinor(0, currency)
 	if req.Balance != nil {
-		initialBalance = *req.Balance
+		initialBalance, err = req.Balance.InCurrency(currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Balance has more decimal places than " + currency.Code + " allows",
+			})
+		}
+	}
+
+	overdraftLimit := models.NewMoneyFromMinor(0, currency)
+	if req.OverdraftLimit != nil {
+		overdraftLimit, err = validOverdraftLimit(*req.OverdraftLimit, currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
 	}
 
 	customer := models.Customer{
-		CustomerID: models.GenerateCustomerID(),
-		Name:      req.Name,
-		Balance:   initialBalance,
+		CustomerID:     models.GenerateCustomerID(),
+		Name:           req.Name,
+		Balance:        initialBalance,
+		OverdraftLimit: overdraftLimit,
+		Status:         models.CustomerStatusActive,
 	}
 
-	_, err := h.customersCollection.InsertOne(context.Background(), customer)
+	err = ledger.OpenAccount(auditContext(c), h.store, customer)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Failed to create customer",
@@ -74,17 +110,236 @@ func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(customer)
 }
 
+// ListCustomers handles listing customers
+// @Summary List customers
+// @Description Lists customers ordered by ID, optionally filtered by name. Closed customers are included.
+// @Tags customers
+// @Produce json
+// @Param name query string false "Case-insensitive substring of the customer name"
+// @Param limit query int false "Maximum number of customers to return (default 50, max 200)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} models.CustomerListResponse "Customers retrieved successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers [get]
+func (h *CustomerHandler) ListCustomers(c *fiber.Ctx) error {
+	limit := defaultCustomerPageSize
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxCustomerPageSize {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "limit must be between 1 and 200",
+			})
+		}
+		limit = parsed
+	}
+
+	// Fetch one extra customer to learn whether another page follows
+	customers, err := h.store.ListCustomers(c.Context(), store.CustomerQuery{
+		NameContains: c.Query("name"),
+		AfterID:      c.Query("cursor"),
+		Limit:        limit + 1,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch customers",
+		})
+	}
+
+	response := models.CustomerListResponse{Customers: customers}
+	if len(customers) > limit {
+		response.Customers = customers[:limit]
+		response.NextCursor = customers[limit-1].CustomerID
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetCustomer handles retrieving a customer
+// @Summary Get a customer
+// @Description Retrieves a customer, including its account state and version
+// @Tags customers
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Success 200 {object} models.Customer "Customer retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id} [get]
+func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
+	customer, err := h.store.GetCustomer(c.Context(), c.Params("customer_id"))
+	if err != nil {
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch customer",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(customer)
+}
+
+// UpdateCustomerRequest represents the request body for updating a customer
+// @Description Request body for updating a customer. Omitted fields are left unchanged. The balance can only change through transactions.
+type UpdateCustomerRequest struct {
+	Name           string        `json:"name,omitempty" example:"Jane Doe" description:"The new name of the customer"`
+	OverdraftLimit *models.Money `json:"overdraft_limit,omitempty" swaggertype:"number" example:"500.00" description:"The new overdraft limit; 0 disables overdrafts"`
+	Version        *int64        `json:"version" validate:"required" example:"3" description:"The version the update is based on, as returned by GET /customers/{customer_id}"`
+}
+
+// UpdateCustomer handles updating a customer
+// @Summary Update a customer
+// @Description Renames a customer or changes its overdraft limit. The update only applies if the customer is still at the given version;
+// @Description otherwise it fails with 409 and the client should fetch the customer again.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Param customer body UpdateCustomerRequest true "Customer changes"
+// @Success 200 {object} models.Customer "Customer updated successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 409 {object} models.ErrorResponse "Customer was modified since the given version"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
+	var req UpdateCustomerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+	if req.Version == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "version is required",
+		})
+	}
+	if req.Name == "" && req.OverdraftLimit == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "name or overdraft_limit is required",
+		})
+	}
+
+	var changes ledger.CustomerChanges
+	if req.Name != "" {
+		changes.Name = &req.Name
+	}
+	if req.OverdraftLimit != nil {
+		currency, err := models.LookupCurrency(models.DefaultCurrency)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Default currency is not configured",
+			})
+		}
+		limit, err := validOverdraftLimit(*req.OverdraftLimit, currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		changes.OverdraftLimit = &limit
+	}
+
+	customer, err := ledger.UpdateCustomer(auditContext(c), h.store, utils.CopyString(c.Params("customer_id")), *req.Version, changes)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrCustomerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		case errors.Is(err, store.ErrVersionConflict):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error: "Customer was modified since the given version",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to update customer",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(customer)
+}
+
+// validOverdraftLimit checks that limit is not negative and fits currency,
+// returning it in that currency
+func validOverdraftLimit(limit models.Money, currency models.Currency) (models.Money, error) {
+	if limit.IsNegative() {
+		return models.Money{}, ledger.ErrInvalidOverdraftLimit
+	}
+	limit, err := limit.InCurrency(currency)
+	if err != nil {
+		return models.Money{}, errors.New("overdraft_limit has more decimal places than " + currency.Code + " allows")
+	}
+	return limit, nil
+}
+
+// CloseCustomer handles closing a customer account
+// @Summary Close a customer account
+// @Description Soft-closes a customer account. The balance must be zero and no holds may be active. The customer and its transaction history are kept for audit,
+// @Description but the account accepts no further transactions.
+// @Tags customers
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Param reason query string false "Why the account is closed, recorded in its status history"
+// @Success 200 {object} models.Customer "Customer account closed"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 409 {object} models.ErrorResponse "Balance is not zero or the account is already closed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id} [delete]
+func (h *CustomerHandler) CloseCustomer(c *fiber.Ctx) error {
+	reason := c.Query("reason", "closed through the customer API")
+	customer, err := ledger.ChangeAccountStatus(auditContext(c), h.store, utils.CopyString(c.Params("customer_id")), models.CustomerStatusClosed, reason, customerAPIActor)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrCustomerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		case errors.Is(err, models.ErrAccountNotEmpty):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error: "Balance must be zero and no holds active to close the account",
+			})
+		case errors.Is(err, models.ErrInvalidStatusTransition):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error: "Account is already closed",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to close customer account",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(customer)
+}
+
 // GetBalance handles retrieving a customer's balance
 // @Summary Get customer balance
-// @Description Retrieves the current balance of a customer
+// @Description Retrieves the current ledger balance of a customer and its available balance, which excludes
+// @Description funds reserved by active holds, with its overdraft limit and the part of it still available
+// @Description as credit. With as_of it returns the ledger balance at that moment
+// @Description together with the last transaction included in it. Without a currency the balance in USD is
+// @Description returned together with a list of the balances in every currency the customer holds.
 // @Tags customers
 // @Accept json
 // @Produce json
 // @Param customer_id path string true "Customer ID"
+// @Param currency query string false "ISO 4217 code of the currency to report"
+// @Param as_of query string false "RFC 3339 timestamp, or a YYYY-MM-DD date meaning the end of that day in UTC"
 // @Success 200 {object} models.BalanceResponse "Customer balance retrieved successfully"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 404 {object} models.ErrorResponse "Customer not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /customers/{customer_id}/balance [get]
 func (h *CustomerHandler) GetBalance(c *fiber.Ctx) error {
 	customerID := c.Params("customer_id")
@@ -94,39 +349,169 @@ func (h *CustomerHandler) GetBalance(c *fiber.Ctx) error {
 		})
 	}
 
-	var customer models.Customer
-	err := h.customersCollection.FindOne(context.Background(), bson.M{"_id": customerID}).Decode(&customer)
+	currency, err := requestCurrency(c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err := parseAsOf(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return h.getBalanceAsOf(c, customerID, currency.Code, asOf)
+	}
+
+	customer, err := h.store.GetCustomer(context.Background(), customerID)
+	if err != nil {
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to fetch customer",
+		})
+	}
+
+	balance := customer.BalanceIn(currency.Code)
+	available := balance.Available()
+	response := models.BalanceResponse{
+		CustomerID:       customer.CustomerID,
+		Currency:         currency.Code,
+		Balance:          balance.Balance,
+		LedgerBalance:    balance.Balance,
+		AvailableBalance: &available,
+	}
+	if currency.Code == models.DefaultCurrency {
+		overdraftLimit := customer.OverdraftLimit
+		availableCredit := customer.AvailableCredit()
+		response.OverdraftLimit = &overdraftLimit
+		response.AvailableCredit = &availableCredit
+	}
+	if c.Query("currency") == "" {
+		for _, code := range customer.Currencies() {
+			balance := customer.BalanceIn(code)
+			response.Balances = append(response.Balances, models.CurrencyBalanceResponse{
+				Currency:         code,
+				LedgerBalance:    balance.Balance,
+				AvailableBalance: balance.Available(),
+			})
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// getBalanceAsOf answers a balance query in currency for a moment in the past
+// from the customer's transaction history
+func (h *CustomerHandler) getBalanceAsOf(c *fiber.Ctx, customerID, currency string, asOf time.Time) error {
+	balance, err := ledger.BalanceAsOf(c.Context(), h.store, customerID, currency, asOf)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
-			Error: "Customer not found",
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to compute balance",
 		})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(models.BalanceResponse{
-		CustomerID: customer.CustomerID,
-		Balance:    customer.Balance,
+		CustomerID:        customerID,
+		Currency:          currency,
+		Balance:           balance.Balance,
+		LedgerBalance:     balance.Balance,
+		AsOf:              asOf.Format(time.RFC3339Nano),
+		LastTransactionID: balance.LastTransactionID,
 	})
 }
 
+// parseAsOf reads an as_of parameter. A bare date stands for the last moment
+// of that day in UTC.
+func parseAsOf(raw string) (time.Time, error) {
+	if asOf, err := time.Parse(time.RFC3339, raw); err == nil {
+		return asOf, nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, errors.New("as_of must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
 // TransactionHistoryResponse represents a transaction in the history
 type TransactionHistoryResponse struct {
-	TransactionID string  `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Type         string  `json:"type" example:"credit"`
-	Amount       float64 `json:"amount" example:"100.00"`
-	Timestamp    string  `json:"timestamp" example:"2025-04-27T11:03:15Z"`
+	TransactionID  string        `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Type           string        `json:"type" example:"credit"`
+	Amount         models.Money  `json:"amount" swaggertype:"number" example:"100.00"`
+	Currency       string        `json:"currency" example:"USD"`
+	Timestamp      string        `json:"timestamp" example:"2025-04-27T11:03:15Z"`
+	TransferID     string        `json:"transfer_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Sequence       int64         `json:"sequence,omitempty" example:"42"`
+	BalanceBefore  *models.Money `json:"balance_before,omitempty" swaggertype:"number" example:"150.00"`
+	RunningBalance *models.Money `json:"running_balance,omitempty" swaggertype:"number" example:"250.00"`
+	HoldID         string        `json:"hold_id,omitempty" example:"3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7"`
+	ConversionID   string        `json:"conversion_id,omitempty" example:"4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a"`
+	ReversalOf     string        `json:"reversal_of,omitempty" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"`
+	ReversedAmount *models.Money `json:"reversed_amount,omitempty" swaggertype:"number" example:"25.00"`
+	ReversalStatus string        `json:"reversal_status,omitempty" example:"partially_reversed"`
+}
+
+// newTransactionHistoryResponse converts a posted transaction to its history
+// form, which leaves out the customer ID
+func newTransactionHistoryResponse(t models.Transaction) TransactionHistoryResponse {
+	return TransactionHistoryResponse{
+		TransactionID:  t.TransactionID,
+		Type:           t.Type,
+		Amount:         t.Amount,
+		Currency:       t.CurrencyCode(),
+		Timestamp:      t.Timestamp.Format(time.RFC3339),
+		TransferID:     t.TransferID,
+		Sequence:       t.Sequence,
+		BalanceBefore:  t.BalanceBefore,
+		RunningBalance: t.BalanceAfter,
+		HoldID:         t.HoldID,
+		ConversionID:   t.ConversionID,
+		ReversalOf:     t.ReversalOf,
+		ReversedAmount: t.ReversedAmount,
+		ReversalStatus: t.ReversalStatus,
+	}
+}
+
+// TransactionHistoryPage represents a page of a customer's transaction history
+type TransactionHistoryPage struct {
+	Transactions []TransactionHistoryResponse `json:"transactions"`
+	Next         string                       `json:"next,omitempty" example:"eyJ0IjoiMjAyNS0wNC0yN1QxMTowMzoxNVoiLCJpZCI6IjEyMyJ9"`
 }
 
 // GetTransactionHistory handles retrieving a customer's transaction history
 // @Summary Get transaction history
-// @Description Retrieves the transaction history for a customer
+// @Description Retrieves a page of a customer's transactions, ordered by timestamp, with the running balance after each one.
+// @Description Pass the returned next token as cursor, with the same filters, to fetch the following page.
 // @Tags customers
 // @Accept json
 // @Produce json
 // @Param customer_id path string true "Customer ID"
-// @Success 200 {array} TransactionHistoryResponse "Transaction history retrieved successfully"
+// @Param type query string false "Only credits or only debits" Enums(credit, debit)
+// @Param currency query string false "Only transactions in this ISO 4217 currency"
+// @Param min_amount query number false "Minimum amount, inclusive"
+// @Param max_amount query number false "Maximum amount, inclusive"
+// @Param from query string false "Earliest timestamp, inclusive (RFC 3339)"
+// @Param to query string false "Latest timestamp, exclusive (RFC 3339)"
+// @Param sort query string false "Timestamp order (default asc)" Enums(asc, desc)
+// @Param limit query int false "Maximum number of transactions to return (default 50, max 500)"
+// @Param cursor query string false "next token of the previous page"
+// @Success 200 {object} TransactionHistoryPage "Transaction history retrieved successfully"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 404 {object} models.ErrorResponse "Customer not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /customers/{customer_id}/transactions [get]
 func (h *CustomerHandler) GetTransactionHistory(c *fiber.Ctx) error {
 	customerID := c.Params("customer_id")
@@ -136,46 +521,59 @@ func (h *CustomerHandler) GetTransactionHistory(c *fiber.Ctx) error {
 		})
 	}
 
-	var customer models.Customer
-	err := h.customersCollection.FindOne(context.Background(), bson.M{"_id": customerID}).Decode(&customer)
+	query, err := parseTransactionQuery(c, customerID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
-			Error: "Customer not found",
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: err.Error(),
 		})
 	}
 
-	cursor, err := h.transactionsCollection.Find(context.Background(), bson.M{"customer_id": customerID})
+	customer, err := h.store.GetCustomer(context.Background(), customerID)
 	if err != nil {
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error: "Failed to fetch transactions",
+			Error: "Failed to fetch customer",
 		})
 	}
-	defer cursor.Close(context.Background())
 
-	var transactions []models.Transaction
-	if err := cursor.All(context.Background(), &transactions); err != nil {
+	// Fetch one extra transaction to learn whether another page follows
+	limit := query.Limit
+	query.CustomerID = customer.CustomerID
+	query.Limit = limit + 1
+	transactions, err := h.store.QueryTransactions(context.Background(), query)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error: "Failed to decode transactions",
+			Error: "Failed to fetch transactions",
 		})
 	}
 
+	page := TransactionHistoryPage{}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.Next = encodeTransactionCursor(transactions[limit-1])
+	}
+
 	// Convert to response format without customer_id
-	response := make([]TransactionHistoryResponse, len(transactions))
+	page.Transactions = make([]TransactionHistoryResponse, len(transactions))
 	for i, t := range transactions {
-		response[i] = TransactionHistoryResponse{
-			TransactionID: t.TransactionID,
-			Type:         t.Type,
-			Amount:       t.Amount,
-			Timestamp:    t.Timestamp.Format(time.RFC3339),
-		}
+		page.Transactions[i] = newTransactionHistoryResponse(t)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(response)
+	return c.Status(fiber.StatusOK).JSON(page)
 }
 
 // RegisterRoutes registers the customer routes
 func (h *CustomerHandler) RegisterRoutes(app *fiber.App) {
 	app.Post("/customers", h.CreateCustomer)
+	app.Get("/customers", h.ListCustomers)
+	app.Get("/customers/:customer_id", h.GetCustomer)
+	app.Put("/customers/:customer_id", h.UpdateCustomer)
+	app.Delete("/customers/:customer_id", h.CloseCustomer)
 	app.Get("/customers/:customer_id/balance", h.GetBalance)
 	app.Get("/customers/:customer_id/transactions", h.GetTransactionHistory)
-} 
\ No newline at end of file
+	app.Get("/customers/:customer_id/statements", h.GetStatement)
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestListCustomers(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	for i, name := range []string{"Alice Smith", "Bob Jones", "Carol Smith"} {
+		customer := models.Customer{CustomerID: fmt.Sprintf("c%d", i+1), Name: name, Status: models.CustomerStatusActive}
+		if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+			t.Fatalf("Failed to create test customer: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	list := func(target string) (int, models.CustomerListResponse) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var page models.CustomerListResponse
+		json.NewDecoder(resp.Body).Decode(&page)
+		return resp.StatusCode, page
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		expectedStatus int
+		expectedIDs    []string
+		expectedCursor string
+	}{
+		{"first page", "/customers?limit=2", fiber.StatusOK, []string{"c1", "c2"}, "c2"},
+		{"next page", "/customers?limit=2&cursor=c2", fiber.StatusOK, []string{"c3"}, ""},
+		{"name search", "/customers?name=smith", fiber.StatusOK, []string{"c1", "c3"}, ""},
+		{"invalid limit", "/customers?limit=500", fiber.StatusBadRequest, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, page := list(tt.target)
+			if status != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if status != fiber.StatusOK {
+				return
+			}
+			ids := []string{}
+			for _, customer := range page.Customers {
+				ids = append(ids, customer.CustomerID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expectedIDs, ",") || page.NextCursor != tt.expectedCursor {
+				t.Errorf("Got customers %v with cursor %q, want %v with cursor %q", ids, page.NextCursor, tt.expectedIDs, tt.expectedCursor)
+			}
+		})
+	}
+}
+
+func TestCustomerLifecycle(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	for id, balance := range map[string]string{"empty": "0", "funded": "10"} {
+		customer := models.Customer{CustomerID: id, Name: id, Balance: models.MustParseMoney(balance), Status: models.CustomerStatusActive}
+		if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+			t.Fatalf("Failed to create test customer: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		requestBody    string
+		expectedStatus int
+	}{
+		{"get", fiber.MethodGet, "/customers/empty", "", fiber.StatusOK},
+		{"get missing", fiber.MethodGet, "/customers/missing", "", fiber.StatusNotFound},
+		{"update", fiber.MethodPut, "/customers/empty", `{"name": "Renamed", "version": 0}`, fiber.StatusOK},
+		{"update with stale version", fiber.MethodPut, "/customers/empty", `{"name": "Again", "version": 0}`, fiber.StatusConflict},
+		{"update without version", fiber.MethodPut, "/customers/empty", `{"name": "Again"}`, fiber.StatusBadRequest},
+		{"update without changes", fiber.MethodPut, "/customers/empty", `{"version": 1}`, fiber.StatusBadRequest},
+		{"close with balance", fiber.MethodDelete, "/customers/funded", "", fiber.StatusConflict},
+		{"close", fiber.MethodDelete, "/customers/empty", "", fiber.StatusOK},
+		{"close twice", fiber.MethodDelete, "/customers/empty", "", fiber.StatusConflict},
+		{"history of closed account", fiber.MethodGet, "/customers/empty/transactions", "", fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	closed, err := ledgerStore.GetCustomer(context.Background(), "empty")
+	if err != nil || closed.Name != "Renamed" || !closed.IsClosed() {
+		t.Errorf("Customer after lifecycle = %+v (%v), want renamed and closed", closed, err)
+	}
+}
+
+func TestOverdraftLimit(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("10"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+	}{
+		{"negative limit", `{"overdraft_limit": -5, "version": 0}`, fiber.StatusBadRequest},
+		{"limit with too many decimals", `{"overdraft_limit": 5.001, "version": 0}`, fiber.StatusBadRequest},
+		{"set limit", `{"overdraft_limit": 50, "version": 0}`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPut, "/customers/test_customer", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+		_, err := ledger.ApplyTransaction(tx, models.Transaction{
+			TransactionID: "t1",
+			CustomerID:    "test_customer",
+			Type:          "debit",
+			Amount:        models.MustParseMoney("30"),
+			Timestamp:     time.Now(),
+		}, ledger.DefaultPolicy)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Debit into the overdraft failed: %v", err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/test_customer/balance", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var balance models.BalanceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&balance); err != nil {
+		t.Fatalf("Failed to decode balance: %v", err)
+	}
+	if !balance.LedgerBalance.Equal(models.MustParseMoney("-20")) || balance.OverdraftLimit == nil || !balance.OverdraftLimit.Equal(models.MustParseMoney("50")) ||
+		balance.AvailableCredit == nil || !balance.AvailableCredit.Equal(models.MustParseMoney("30")) {
+		t.Errorf("Balance = %+v, want -20 with an overdraft limit of 50 and 30 available credit", balance)
+	}
+}
+
+func TestGetTransactionHistoryPagination(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	base := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i, posting := range []struct{ transactionType, amount string }{{"credit", "50"}, {"debit", "30"}, {"credit", "5"}} {
+		transaction := models.Transaction{
+			TransactionID: fmt.Sprintf("t%d", i+1),
+			CustomerID:    "test_customer",
+			Type:          posting.transactionType,
+			Amount:        models.MustParseMoney(posting.amount),
+			Timestamp:     base.Add(time.Duration(i) * time.Minute),
+		}
+		err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+			_, err := ledger.ApplyTransaction(tx, transaction, ledger.DefaultPolicy)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to post transaction: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	get := func(target string) (int, TransactionHistoryPage) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var page TransactionHistoryPage
+		json.NewDecoder(resp.Body).Decode(&page)
+		return resp.StatusCode, page
+	}
+
+	// Walk every page in descending order
+	var ids, balances []string
+	target := "/customers/test_customer/transactions?sort=desc&limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("Pagination did not terminate")
+		}
+		status, page := get(target)
+		if status != fiber.StatusOK {
+			t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+		}
+		for _, entry := range page.Transactions {
+			ids = append(ids, entry.TransactionID)
+			if entry.RunningBalance != nil {
+				balances = append(balances, entry.RunningBalance.String())
+			}
+		}
+		target = ""
+		if page.Next != "" {
+			target = "/customers/test_customer/transactions?sort=desc&limit=2&cursor=" + page.Next
+		}
+	}
+	if got := strings.Join(ids, ","); got != "t3,t2,t1" {
+		t.Errorf("Transactions = %s, want t3,t2,t1", got)
+	}
+	if got := strings.Join(balances, ","); got != "125,120,150" {
+		t.Errorf("Running balances = %s, want 125,120,150", got)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    string
+	}{
+		{"type filter", "?type=credit", fiber.StatusOK, "t1,t3"},
+		{"amount range", "?min_amount=10&max_amount=40", fiber.StatusOK, "t2"},
+		{"time range", "?from=2025-04-01T00:01:00Z&to=2025-04-01T00:02:00Z", fiber.StatusOK, "t2"},
+		{"invalid type", "?type=refund", fiber.StatusBadRequest, ""},
+		{"invalid cursor", "?cursor=not-a-cursor", fiber.StatusBadRequest, ""},
+		{"invalid timestamp", "?from=yesterday", fiber.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, page := get("/customers/test_customer/transactions" + tt.query)
+			if status != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			ids := []string{}
+			for _, entry := range page.Transactions {
+				ids = append(ids, entry.TransactionID)
+			}
+			if status == fiber.StatusOK && strings.Join(ids, ",") != tt.expectedIDs {
+				t.Errorf("Transactions = %v, want %s", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	base := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	for i, posting := range []struct{ transactionType, amount string }{{"credit", "50"}, {"debit", "30"}} {
+		transaction := models.Transaction{
+			TransactionID: fmt.Sprintf("t%d", i+1),
+			CustomerID:    "test_customer",
+			Type:          posting.transactionType,
+			Amount:        models.MustParseMoney(posting.amount),
+			Timestamp:     base.AddDate(0, 0, i),
+		}
+		err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+			_, err := ledger.ApplyTransaction(tx, transaction, ledger.DefaultPolicy)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to post transaction: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBal    string
+		expectedLastID string
+	}{
+		{"current balance", "", fiber.StatusOK, "120", ""},
+		{"before any transaction", "?as_of=2025-03-31", fiber.StatusOK, "100", ""},
+		{"end of day", "?as_of=2025-04-01", fiber.StatusOK, "150", "t1"},
+		{"timestamp", "?as_of=2025-04-02T11:59:59Z", fiber.StatusOK, "150", "t1"},
+		{"timestamp of a transaction", "?as_of=2025-04-02T12:00:00Z", fiber.StatusOK, "120", "t2"},
+		{"invalid as_of", "?as_of=yesterday", fiber.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/test_customer/balance"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != fiber.StatusOK {
+				return
+			}
+			var balance models.BalanceResponse
+			json.NewDecoder(resp.Body).Decode(&balance)
+			if !balance.Balance.Equal(models.MustParseMoney(tt.expectedBal)) || balance.LastTransactionID != tt.expectedLastID {
+				t.Errorf("Balance = %s after %q, want %s after %q", balance.Balance, balance.LastTransactionID, tt.expectedBal, tt.expectedLastID)
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/missing/balance?as_of=2025-04-01", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for missing customer, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestMultiCurrencyBalance(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	for i, posting := range []struct{ transactionType, amount, currency string }{{"credit", "80", "EUR"}, {"debit", "30", "USD"}, {"debit", "5", "EUR"}} {
+		transaction := models.Transaction{
+			TransactionID: fmt.Sprintf("t%d", i+1),
+			CustomerID:    "test_customer",
+			Type:          posting.transactionType,
+			Amount:        models.MustParseMoney(posting.amount),
+			Currency:      posting.currency,
+			Timestamp:     time.Now(),
+		}
+		err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+			_, err := ledger.ApplyTransaction(tx, transaction, ledger.DefaultPolicy)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to post transaction: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	getBalance := func(query string) (int, models.BalanceResponse) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/test_customer/balance"+query, nil))
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var balance models.BalanceResponse
+		json.NewDecoder(resp.Body).Decode(&balance)
+		return resp.StatusCode, balance
+	}
+
+	status, balance := getBalance("")
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	if balance.Currency != "USD" || !balance.LedgerBalance.Equal(models.MustParseMoney("70")) {
+		t.Errorf("Balance = %s %s, want USD 70", balance.Currency, balance.LedgerBalance)
+	}
+	if len(balance.Balances) != 2 || balance.Balances[1].Currency != "EUR" || !balance.Balances[1].LedgerBalance.Equal(models.MustParseMoney("75")) {
+		t.Errorf("Balances = %+v, want USD 70 and EUR 75", balance.Balances)
+	}
+
+	status, balance = getBalance("?currency=eur")
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	if balance.Currency != "EUR" || !balance.LedgerBalance.Equal(models.MustParseMoney("75")) || balance.OverdraftLimit != nil || len(balance.Balances) != 0 {
+		t.Errorf("EUR balance = %+v, want EUR 75 without overdraft or other currencies", balance)
+	}
+
+	if status, _ := getBalance("?currency=XYZ"); status != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for an unsupported currency, got %d", fiber.StatusBadRequest, status)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/test_customer/transactions?currency=EUR", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var page TransactionHistoryPage
+	json.NewDecoder(resp.Body).Decode(&page)
+	var ids []string
+	for _, entry := range page.Transactions {
+		if entry.Currency != "EUR" {
+			t.Errorf("Transaction %s has currency %q, want EUR", entry.TransactionID, entry.Currency)
+		}
+		ids = append(ids, entry.TransactionID)
+	}
+	if got := strings.Join(ids, ","); got != "t1,t3" {
+		t.Errorf("EUR transactions = %s, want t1,t3", got)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"ledger-service/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorCodeStatus maps an error code to the HTTP status it is reported with
+func errorCodeStatus(code models.ErrorCode) int {
+	switch code {
+	case models.ErrorCodeValidationFailed, models.ErrorCodeUnsupportedCurrency:
+		return fiber.StatusBadRequest
+	case models.ErrorCodeCustomerNotFound, models.ErrorCodeTransactionNotFound, models.ErrorCodeQuoteNotFound, models.ErrorCodeConversionNotFound,
+		models.ErrorCodeAPIKeyNotFound:
+		return fiber.StatusNotFound
+	case models.ErrorCodeAuthenticationRequired, models.ErrorCodeInvalidAPIKey, models.ErrorCodeInvalidToken,
+		models.ErrorCodeInvalidSignature, models.ErrorCodeStaleTimestamp, models.ErrorCodeReplayedNonce:
+		return fiber.StatusUnauthorized
+	case models.ErrorCodeInsufficientScope, models.ErrorCodeCustomerAccessDenied:
+		return fiber.StatusForbidden
+	case models.ErrorCodeAccountFrozen, models.ErrorCodeAccountClosed, models.ErrorCodeIdempotencyConflict, models.ErrorCodeNotReversible,
+		models.ErrorCodeQuoteExpired, models.ErrorCodeQuoteUsed, models.ErrorCodeAPIKeyRevoked:
+		return fiber.StatusConflict
+	case models.ErrorCodeInsufficientFunds, models.ErrorCodeReversalExceedsOriginal, models.ErrorCodeCurrencyMismatch,
+		models.ErrorCodeExchangeRateNotFound:
+		return fiber.StatusUnprocessableEntity
+	case models.ErrorCodeStorageUnavailable, models.ErrorCodeQueueUnavailable:
+		return fiber.StatusServiceUnavailable
+	case models.ErrorCodeTimeout:
+		return fiber.StatusRequestTimeout
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// transactionStatusCode returns the HTTP status of a transaction outcome
+func transactionStatusCode(status models.TransactionStatusResponse) int {
+	if status.Status == models.TransactionStatusFailed {
+		return errorCodeStatus(status.ErrorCode)
+	}
+	return fiber.StatusOK
+}
+
+// errorResponse builds the body of an error reported with code
+func errorResponse(code models.ErrorCode, message string) models.ErrorResponse {
+	return models.ErrorResponse{Error: message, Code: code}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FXHandler handles the exchange rate table, conversion quotes and conversions
+type FXHandler struct {
+	store    store.LedgerStore
+	fx       store.FXStore
+	audit    store.AuditStore
+	policy   ledger.Policy
+	quoteTTL time.Duration
+}
+
+// NewFXHandler creates a new FX handler whose quotes lock their rate for
+// quoteTTL and whose conversions post with policy
+func NewFXHandler(ledgerStore store.LedgerStore, fxStore store.FXStore, auditStore store.AuditStore, policy ledger.Policy, quoteTTL time.Duration) *FXHandler {
+	return &FXHandler{
+		store:    ledgerStore,
+		fx:       fxStore,
+		audit:    auditStore,
+		policy:   policy,
+		quoteTTL: quoteTTL,
+	}
+}
+
+// CreateExchangeRateRequest represents the request body for adding a rate to the rate table
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string       `json:"base_currency" example:"EUR"`
+	QuoteCurrency string       `json:"quote_currency" example:"USD"`
+	Rate          models.Money `json:"rate" swaggertype:"number" example:"1.0842"`
+	Spread        models.Money `json:"spread" swaggertype:"number" example:"0.0025"`
+	// EffectiveFrom defaults to now
+	EffectiveFrom *time.Time `json:"effective_from,omitempty" example:"2025-04-01T00:00:00Z"`
+	Source        string     `json:"source,omitempty" example:"treasury desk"`
+}
+
+// ExchangeRateListResponse represents entries of the rate table
+type ExchangeRateListResponse struct {
+	Rates []models.ExchangeRate `json:"rates"`
+}
+
+// CreateQuoteRequest represents the request body for quoting a conversion
+type CreateQuoteRequest struct {
+	CustomerID   string       `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	FromCurrency string       `json:"from_currency" example:"EUR"`
+	ToCurrency   string       `json:"to_currency" example:"USD"`
+	Amount       models.Money `json:"amount" swaggertype:"number" example:"100"`
+}
+
+// CreateConversionRequest represents the request body for converting
+// between two currency balances. Either QuoteID or the other fields are sent.
+type CreateConversionRequest struct {
+	// QuoteID executes a quote at its locked rate
+	QuoteID      string        `json:"quote_id,omitempty" example:"9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"`
+	CustomerID   string        `json:"customer_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	FromCurrency string        `json:"from_currency,omitempty" example:"EUR"`
+	ToCurrency   string        `json:"to_currency,omitempty" example:"USD"`
+	Amount       *models.Money `json:"amount,omitempty" swaggertype:"number" example:"100"`
+}
+
+// ConversionResponse represents the result of a conversion
+type ConversionResponse struct {
+	Conversion  models.Conversion `json:"conversion"`
+	FromBalance models.Money      `json:"from_balance" swaggertype:"number" example:"150.00"`
+	ToBalance   models.Money      `json:"to_balance" swaggertype:"number" example:"358.14"`
+}
+
+// ConversionListResponse represents the conversions of a customer
+type ConversionListResponse struct {
+	Conversions []models.Conversion `json:"conversions"`
+}
+
+// ListExchangeRates handles listing the rate table
+// @Summary List exchange rates
+// @Description Lists the entries of the rate table by currency pair and effective time. A rate applies from its
+// @Description effective time until the next rate of the same pair takes effect.
+// @Tags fx
+// @Produce json
+// @Param base query string false "Only rates selling this currency"
+// @Param quote query string false "Only rates buying this currency"
+// @Success 200 {object} ExchangeRateListResponse "Rates retrieved successfully"
+// @Failure 400 {object} models.ErrorResponse "Unsupported currency"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /fx/rates [get]
+func (h *FXHandler) ListExchangeRates(c *fiber.Ctx) error {
+	pair := []string{c.Query("base"), c.Query("quote")}
+	for i, code := range pair {
+		if code == "" {
+			continue
+		}
+		currency, err := models.LookupCurrency(code)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeUnsupportedCurrency, err.Error()))
+		}
+		pair[i] = currency.Code
+	}
+
+	rates, err := h.fx.ListExchangeRates(c.Context(), pair[0], pair[1])
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, "Failed to fetch exchange rates"))
+	}
+	return c.Status(fiber.StatusOK).JSON(ExchangeRateListResponse{Rates: rates})
+}
+
+// CreateExchangeRate handles adding a rate to the rate table
+// @Summary Add an exchange rate
+// @Description Adds the rate of a currency pair taking effect at effective_from, now unless given. A rate for the
+// @Description same pair and effective time is replaced. The spread is the fraction of the rate kept by the service.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param rate body CreateExchangeRateRequest true "Rate details"
+// @Success 201 {object} models.ExchangeRate "Rate added successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid rate or unsupported currency"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/fx/rates [post]
+func (h *FXHandler) CreateExchangeRate(c *fiber.Ctx) error {
+	var req CreateExchangeRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	}
+
+	effectiveFrom := models.GenerateTimestamp()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	ctx := auditContext(c)
+	rate, err := ledger.AddExchangeRate(ctx, h.fx, models.ExchangeRate{
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Rate:          req.Rate,
+		Spread:        req.Spread,
+		EffectiveFrom: effectiveFrom,
+		Source:        req.Source,
+	})
+	if err != nil {
+		return fxError(c, err, "Failed to add exchange rate")
+	}
+	logAudit(ctx, h.audit, models.AuditExchangeRateCreate, models.AuditTargetExchangeRate, rate.RateID, nil, rate)
+	return c.Status(fiber.StatusCreated).JSON(rate)
+}
+
+// CreateQuote handles quoting a conversion
+// @Summary Quote a conversion
+// @Description Prices converting an amount of a customer's balance in one currency into another at the rate in
+// @Description effect, less its spread, and locks that price until the quote expires. The converted amount is
+// @Description truncated to the minor units of the target currency. Funds are checked when the quote is executed.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param quote body CreateQuoteRequest true "Conversion to quote"
+// @Success 201 {object} models.FXQuote "Quote created successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unsupported currency"
+// @Failure 403 {object} models.ErrorResponse "Token of another customer"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 422 {object} models.ErrorResponse "No exchange rate is in effect for the currency pair"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /fx/quotes [post]
+func (h *FXHandler) CreateQuote(c *fiber.Ctx) error {
+	var req CreateQuoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	}
+	if err := validateQuoteRequest(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	}
+	if !canAccessCustomer(c, req.CustomerID) {
+		return customerAccessDenied(c)
+	}
+	ctx := auditContext(c)
+	quote, err := ledger.QuoteConversion(ctx, h.store, h.fx, req.CustomerID, req.FromCurrency, req.ToCurrency, req.Amount, h.quoteTTL)
+	if err != nil {
+		return fxError(c, err, "Failed to quote conversion")
+	}
+	logAudit(ctx, h.audit, models.AuditQuoteCreate, models.AuditTargetQuote, quote.QuoteID, nil, quote)
+	c.Location("/fx/quotes/" + quote.QuoteID)
+	return c.Status(fiber.StatusCreated).JSON(quote)
+}
+
+// GetQuote handles retrieving a quote
+// @Summary Get a quote
+// @Description Retrieves a quote and its state; open quotes past their expiry time are reported as expired
+// @Tags fx
+// @Produce json
+// @Param quote_id path string true "Quote ID"
+// @Success 200 {object} models.FXQuote "Quote retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Quote not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /fx/quotes/{quote_id} [get]
+func (h *FXHandler) GetQuote(c *fiber.Ctx) error {
+	quote, err := h.fx.GetQuote(c.Context(), c.Params("quote_id"))
+	if err == nil && !canAccessCustomer(c, quote.CustomerID) {
+		err = store.ErrQuoteNotFound
+	}
+	if err != nil {
+		return fxError(c, err, "Failed to fetch quote")
+	}
+	quote.Status = quote.StatusAt(models.GenerateTimestamp())
+	return c.Status(fiber.StatusOK).JSON(quote)
+}
+
+// CreateConversion handles converting between two currency balances of a customer
+// @Summary Convert between currencies
+// @Description Debits one currency balance of a customer and credits another atomically. Send quote_id to execute
+// @Description a quote at its locked rate, or customer_id, from_currency, to_currency and amount to convert at
+// @Description the rate in effect now. The conversion records the rate and spread it was executed at.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param conversion body CreateConversionRequest true "Quote to execute or conversion details"
+// @Success 201 {object} ConversionResponse "Conversion executed successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unsupported currency"
+// @Failure 403 {object} models.ErrorResponse "Token of another customer"
+// @Failure 404 {object} models.ErrorResponse "Customer or quote not found"
+// @Failure 409 {object} models.ErrorResponse "Quote expired or already executed, or the account is frozen or closed"
+// @Failure 422 {object} models.ErrorResponse "No exchange rate is in effect, or insufficient funds"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /conversions [post]
+func (h *FXHandler) CreateConversion(c *fiber.Ctx) error {
+	var req CreateConversionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+	}
+
+	ctx := auditContext(c)
+	quoteID := req.QuoteID
+	if quoteID != "" {
+		if req.CustomerID != "" || req.FromCurrency != "" || req.ToCurrency != "" || req.Amount != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "send either quote_id or the conversion details"))
+		}
+		if principalOf(c).IsRestricted() {
+			quote, err := h.fx.GetQuote(c.Context(), quoteID)
+			if err == nil && !canAccessCustomer(c, quote.CustomerID) {
+				err = store.ErrQuoteNotFound
+			}
+			if err != nil {
+				return fxError(c, err, "Failed to fetch quote")
+			}
+		}
+	} else {
+		if req.Amount == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "amount is required"))
+		}
+		quoteReq := CreateQuoteRequest{
+			CustomerID:   req.CustomerID,
+			FromCurrency: req.FromCurrency,
+			ToCurrency:   req.ToCurrency,
+			Amount:       *req.Amount,
+		}
+		if err := validateQuoteRequest(quoteReq); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+		}
+		if !canAccessCustomer(c, quoteReq.CustomerID) {
+			return customerAccessDenied(c)
+		}
+		quote, err := ledger.QuoteConversion(ctx, h.store, h.fx, quoteReq.CustomerID, quoteReq.FromCurrency, quoteReq.ToCurrency, quoteReq.Amount, h.quoteTTL)
+		if err != nil {
+			return fxError(c, err, "Failed to convert")
+		}
+		logAudit(ctx, h.audit, models.AuditQuoteCreate, models.AuditTargetQuote, quote.QuoteID, nil, quote)
+		quoteID = quote.QuoteID
+	}
+
+	conversion, fromBalance, toBalance, err := ledger.ExecuteConversion(ctx, h.store, h.fx, quoteID, h.policy)
+	if err != nil {
+		return fxError(c, err, "Failed to convert")
+	}
+	c.Location("/conversions/" + conversion.ConversionID)
+	return c.Status(fiber.StatusCreated).JSON(ConversionResponse{
+		Conversion:  conversion,
+		FromBalance: fromBalance,
+		ToBalance:   toBalance,
+	})
+}
+
+// GetConversion handles retrieving a conversion
+// @Summary Get a conversion
+// @Description Retrieves a conversion with the quote, rate and spread it was executed at and the IDs of its two legs
+// @Tags fx
+// @Produce json
+// @Param conversion_id path string true "Conversion ID"
+// @Success 200 {object} models.Conversion "Conversion retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Conversion not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /conversions/{conversion_id} [get]
+func (h *FXHandler) GetConversion(c *fiber.Ctx) error {
+	conversion, err := h.fx.GetConversion(c.Context(), c.Params("conversion_id"))
+	if err == nil && !canAccessCustomer(c, conversion.CustomerID) {
+		err = store.ErrConversionNotFound
+	}
+	if err != nil {
+		return fxError(c, err, "Failed to fetch conversion")
+	}
+	return c.Status(fiber.StatusOK).JSON(conversion)
+}
+
+// ListCustomerConversions handles listing the conversions of a customer
+// @Summary List customer conversions
+// @Description Lists the conversions of a customer, oldest first
+// @Tags fx
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Success 200 {object} ConversionListResponse "Conversions retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id}/conversions [get]
+func (h *FXHandler) ListCustomerConversions(c *fiber.Ctx) error {
+	customerID := c.Params("customer_id")
+	if _, err := h.store.GetCustomer(c.Context(), customerID); err != nil {
+		return fxError(c, err, "Failed to fetch customer")
+	}
+	conversions, err := h.fx.ListConversions(c.Context(), customerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(models.ErrorCodeInternal, "Failed to fetch conversions"))
+	}
+	return c.Status(fiber.StatusOK).JSON(ConversionListResponse{Conversions: conversions})
+}
+
+// validateQuoteRequest checks that a quote request names a customer and
+// both currencies; the amount and currencies are checked by the ledger
+func validateQuoteRequest(req CreateQuoteRequest) error {
+	if req.CustomerID == "" {
+		return errors.New("customer_id is required")
+	}
+	if req.FromCurrency == "" || req.ToCurrency == "" {
+		return errors.New("from_currency and to_currency are required")
+	}
+	return nil
+}
+
+// fxErrorCode maps an error from an FX operation to its error code
+func fxErrorCode(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, models.ErrUnsupportedCurrency):
+		return models.ErrorCodeUnsupportedCurrency
+	case errors.Is(err, store.ErrCustomerNotFound):
+		return models.ErrorCodeCustomerNotFound
+	case errors.Is(err, store.ErrQuoteNotFound):
+		return models.ErrorCodeQuoteNotFound
+	case errors.Is(err, store.ErrConversionNotFound):
+		return models.ErrorCodeConversionNotFound
+	case errors.Is(err, store.ErrExchangeRateNotFound):
+		return models.ErrorCodeExchangeRateNotFound
+	case errors.Is(err, models.ErrQuoteExpired):
+		return models.ErrorCodeQuoteExpired
+	case errors.Is(err, models.ErrQuoteUsed):
+		return models.ErrorCodeQuoteUsed
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return models.ErrorCodeInsufficientFunds
+	case errors.Is(err, models.ErrAccountClosed):
+		return models.ErrorCodeAccountClosed
+	case errors.Is(err, models.ErrAccountFrozen):
+		return models.ErrorCodeAccountFrozen
+	case errors.Is(err, ledger.ErrInvalidAmount), errors.Is(err, ledger.ErrSameCurrency), errors.Is(err, ledger.ErrAmountTooSmall),
+		errors.Is(err, models.ErrInvalidExchangeRate), errors.Is(err, models.ErrExcessPrecision), errors.Is(err, models.ErrMoneyOverflow):
+		return models.ErrorCodeValidationFailed
+	}
+	return models.ErrorCodeInternal
+}
+
+// fxError writes the response for an error from an FX operation. Invalid
+// requests are reported with the error itself, so the caller sees what to fix.
+func fxError(c *fiber.Ctx, err error, fallback string) error {
+	switch code := fxErrorCode(err); code {
+	case models.ErrorCodeValidationFailed, models.ErrorCodeUnsupportedCurrency:
+		return c.Status(errorCodeStatus(code)).JSON(errorResponse(code, err.Error()))
+	case models.ErrorCodeInternal:
+		return c.Status(fiber.StatusInternalServerError).JSON(errorResponse(code, fallback))
+	default:
+		return c.Status(errorCodeStatus(code)).JSON(errorResponse(code, code.Message()))
+	}
+}
+
+// RegisterRoutes registers the FX routes
+func (h *FXHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/fx/rates", h.ListExchangeRates)
+	app.Post("/admin/fx/rates", h.CreateExchangeRate)
+	app.Post("/fx/quotes", h.CreateQuote)
+	app.Get("/fx/quotes/:quote_id", h.GetQuote)
+	app.Post("/conversions", h.CreateConversion)
+	app.Get("/conversions/:conversion_id", h.GetConversion)
+	app.Get("/customers/:customer_id/conversions", h.ListCustomerConversions)
+}
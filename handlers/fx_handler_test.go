@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFXEndpoints(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	NewFXHandler(ledgerStore, ledgerStore, ledgerStore, ledger.DefaultPolicy, time.Minute).RegisterRoutes(app)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+
+	do := func(method, target, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if out != nil {
+			json.Unmarshal(raw, out)
+		}
+		return resp.StatusCode
+	}
+
+	if status := do(fiber.MethodPost, "/admin/fx/rates", `{"base_currency": "usd", "quote_currency": "eur", "rate": 0.9, "spread": 0.01, "effective_from": "2025-01-01T00:00:00Z"}`, nil); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d adding rate, got %d", fiber.StatusCreated, status)
+	}
+
+	var quote models.FXQuote
+	if status := do(fiber.MethodPost, "/fx/quotes", `{"customer_id": "test_customer", "from_currency": "USD", "to_currency": "EUR", "amount": 50}`, &quote); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d quoting, got %d", fiber.StatusCreated, status)
+	}
+	if quote.Status != models.QuoteStatusOpen || !quote.TargetAmount.Equal(models.MustParseMoney("44.55")) {
+		t.Errorf("Quote = %+v, want an open quote for 44.55 EUR", quote)
+	}
+
+	var converted ConversionResponse
+	if status := do(fiber.MethodPost, "/conversions", `{"quote_id": "`+quote.QuoteID+`"}`, &converted); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d converting, got %d", fiber.StatusCreated, status)
+	}
+	if !converted.FromBalance.Equal(models.MustParseMoney("50")) || !converted.ToBalance.Equal(models.MustParseMoney("44.55")) || converted.Conversion.QuoteID != quote.QuoteID {
+		t.Errorf("Conversion = %+v, want 50 USD and 44.55 EUR left", converted)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		requestBody    string
+		expectedStatus int
+		expectedCode   models.ErrorCode
+	}{
+		{"list rates for pair", fiber.MethodGet, "/fx/rates?base=USD&quote=EUR", "", fiber.StatusOK, ""},
+		{"rate with negative spread", fiber.MethodPost, "/admin/fx/rates", `{"base_currency": "USD", "quote_currency": "EUR", "rate": 0.9, "spread": -0.1}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"rate for unsupported currency", fiber.MethodPost, "/admin/fx/rates", `{"base_currency": "USD", "quote_currency": "XYZ", "rate": 1}`, fiber.StatusBadRequest, models.ErrorCodeUnsupportedCurrency},
+		{"quote without rate", fiber.MethodPost, "/fx/quotes", `{"customer_id": "test_customer", "from_currency": "EUR", "to_currency": "GBP", "amount": 1}`, fiber.StatusUnprocessableEntity, models.ErrorCodeExchangeRateNotFound},
+		{"quote same currency", fiber.MethodPost, "/fx/quotes", `{"customer_id": "test_customer", "from_currency": "USD", "to_currency": "USD", "amount": 1}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"quote without customer", fiber.MethodPost, "/fx/quotes", `{"from_currency": "USD", "to_currency": "EUR", "amount": 1}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"quote for missing customer", fiber.MethodPost, "/fx/quotes", `{"customer_id": "missing", "from_currency": "USD", "to_currency": "EUR", "amount": 1}`, fiber.StatusNotFound, models.ErrorCodeCustomerNotFound},
+		{"get executed quote", fiber.MethodGet, "/fx/quotes/" + quote.QuoteID, "", fiber.StatusOK, ""},
+		{"get missing quote", fiber.MethodGet, "/fx/quotes/missing", "", fiber.StatusNotFound, models.ErrorCodeQuoteNotFound},
+		{"execute quote twice", fiber.MethodPost, "/conversions", `{"quote_id": "` + quote.QuoteID + `"}`, fiber.StatusConflict, models.ErrorCodeQuoteUsed},
+		{"execute missing quote", fiber.MethodPost, "/conversions", `{"quote_id": "missing"}`, fiber.StatusNotFound, models.ErrorCodeQuoteNotFound},
+		{"quote and details", fiber.MethodPost, "/conversions", `{"quote_id": "` + quote.QuoteID + `", "amount": 1}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed},
+		{"convert more than balance", fiber.MethodPost, "/conversions", `{"customer_id": "test_customer", "from_currency": "USD", "to_currency": "EUR", "amount": 51}`, fiber.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds},
+		{"convert without quote", fiber.MethodPost, "/conversions", `{"customer_id": "test_customer", "from_currency": "USD", "to_currency": "EUR", "amount": 10}`, fiber.StatusCreated, ""},
+		{"get conversion", fiber.MethodGet, "/conversions/" + converted.Conversion.ConversionID, "", fiber.StatusOK, ""},
+		{"get missing conversion", fiber.MethodGet, "/conversions/missing", "", fiber.StatusNotFound, models.ErrorCodeConversionNotFound},
+		{"list for missing customer", fiber.MethodGet, "/customers/missing/conversions", "", fiber.StatusNotFound, models.ErrorCodeCustomerNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response models.ErrorResponse
+			if status := do(tt.method, tt.target, tt.requestBody, &response); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if tt.expectedCode != "" && response.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, response.Code)
+			}
+		})
+	}
+
+	var executed models.FXQuote
+	do(fiber.MethodGet, "/fx/quotes/"+quote.QuoteID, "", &executed)
+	if executed.Status != models.QuoteStatusExecuted || executed.ConversionID != converted.Conversion.ConversionID {
+		t.Errorf("Executed quote = %+v, want executed by %s", executed, converted.Conversion.ConversionID)
+	}
+
+	var list ConversionListResponse
+	do(fiber.MethodGet, "/customers/test_customer/conversions", "", &list)
+	if len(list.Conversions) != 2 || list.Conversions[0].ConversionID != converted.Conversion.ConversionID {
+		t.Errorf("Conversions = %+v, want the quoted conversion and then the direct one", list.Conversions)
+	}
+
+	var balance models.BalanceResponse
+	do(fiber.MethodGet, "/customers/test_customer/balance?currency=EUR", "", &balance)
+	if !balance.Balance.Equal(models.MustParseMoney("53.46")) {
+		t.Errorf("EUR balance = %s, want 53.46", balance.Balance)
+	}
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HoldHandler handles placing, capturing and voiding holds
+type HoldHandler struct {
+	store  store.LedgerStore
+	policy ledger.Policy
+}
+
+// NewHoldHandler creates a new hold handler that captures with policy
+func NewHoldHandler(ledgerStore store.LedgerStore, policy ledger.Policy) *HoldHandler {
+	return &HoldHandler{
+		store:  ledgerStore,
+		policy: policy,
+	}
+}
+
+// CreateHoldRequest represents the request body for placing a hold
+type CreateHoldRequest struct {
+	CustomerID  string       `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Amount      models.Money `json:"amount" swaggertype:"number" example:"40"`
+	Currency    string       `json:"currency,omitempty" example:"USD"`
+	Description string       `json:"description" example:"Card authorization 4821"`
+	// ExpiresAt defaults to seven days from now
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-04-13T10:45:00Z"`
+}
+
+// CaptureHoldRequest represents the request body for capturing a hold
+type CaptureHoldRequest struct {
+	// Amount defaults to the whole hold; anything less releases the rest
+	Amount *models.Money `json:"amount,omitempty" swaggertype:"number" example:"35"`
+	// Currency, if given, must be the currency of the hold
+	Currency string `json:"currency,omitempty" example:"USD"`
+}
+
+// CaptureHoldResponse represents the result of capturing a hold
+type CaptureHoldResponse struct {
+	Hold          models.Hold  `json:"hold"`
+	TransactionID string       `json:"transaction_id" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"`
+	Balance       models.Money `json:"balance" swaggertype:"number" example:"65.00"`
+}
+
+// HoldListResponse represents the holds of a customer
+type HoldListResponse struct {
+	Holds []models.Hold `json:"holds"`
+}
+
+// CreateHold handles placing a hold
+// @Summary Place a hold
+// @Description Reserves funds of a customer. Held funds stay in the ledger balance but no longer count towards
+// @Description the available balance until the hold is captured, voided or expires. The currency defaults to USD.
+// @Tags holds
+// @Accept json
+// @Produce json
+// @Param hold body CreateHoldRequest true "Hold details"
+// @Success 201 {object} models.Hold "Hold placed successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Token of another customer"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 409 {object} models.ErrorResponse "Account is frozen or closed"
+// @Failure 422 {object} models.ErrorResponse "Insufficient funds"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /holds [post]
+func (h *HoldHandler) CreateHold(c *fiber.Ctx) error {
+	var req CreateHoldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if req.CustomerID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "customer_id is required"})
+	}
+	if !canAccessCustomer(c, req.CustomerID) {
+		return customerAccessDenied(c)
+	}
+	currency, err := requestCurrency(req.Currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	amount, err := validAmount(req.Amount, currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	now := models.GenerateTimestamp()
+	expiresAt := now.Add(ledger.DefaultHoldTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "expires_at must be in the future"})
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	hold, err := ledger.PlaceHold(auditContext(c), h.store, models.Hold{
+		HoldID:      models.GenerateHoldID(),
+		CustomerID:  req.CustomerID,
+		Amount:      amount,
+		Currency:    currency.Code,
+		Description: req.Description,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}, h.policy)
+	if err != nil {
+		return holdError(c, err, "Failed to place hold")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(hold)
+}
+
+// GetHold handles retrieving a hold
+// @Summary Get a hold
+// @Description Retrieves a hold and its current state
+// @Tags holds
+// @Produce json
+// @Param hold_id path string true "Hold ID"
+// @Success 200 {object} models.Hold "Hold retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Hold not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /holds/{hold_id} [get]
+func (h *HoldHandler) GetHold(c *fiber.Ctx) error {
+	hold, err := h.store.GetHold(c.Context(), c.Params("hold_id"))
+	if err == nil && !canAccessCustomer(c, hold.CustomerID) {
+		err = store.ErrHoldNotFound
+	}
+	if err != nil {
+		return holdError(c, err, "Failed to fetch hold")
+	}
+	return c.Status(fiber.StatusOK).JSON(hold)
+}
+
+// CaptureHold handles capturing a hold
+// @Summary Capture a hold
+// @Description Releases an active hold and debits the captured amount, all of the hold unless a smaller amount
+// @Description is given, in the currency of the hold. The debit appears in the customer's transaction history with the hold ID.
+// @Tags holds
+// @Accept json
+// @Produce json
+// @Param hold_id path string true "Hold ID"
+// @Param capture body CaptureHoldRequest false "Amount to capture"
+// @Success 200 {object} CaptureHoldResponse "Hold captured successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or amount exceeds the hold"
+// @Failure 403 {object} models.ErrorResponse "Token of a customer rather than an operator"
+// @Failure 404 {object} models.ErrorResponse "Hold not found"
+// @Failure 409 {object} models.ErrorResponse "Hold is no longer active, has expired, or the account is frozen or closed"
+// @Failure 422 {object} models.ErrorResponse "Currency does not match the hold"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /holds/{hold_id}/capture [post]
+func (h *HoldHandler) CaptureHold(c *fiber.Ctx) error {
+	var req CaptureHoldRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
+	if req.Currency != "" {
+		currency, err := requestCurrency(req.Currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		req.Currency = currency.Code
+	}
+	if err := h.checkHoldAccess(c, c.Params("hold_id")); err != nil {
+		return holdError(c, err, "Failed to fetch hold")
+	}
+
+	// The amount is checked against the currency of the hold when it is captured
+	hold, debit, balance, err := ledger.CaptureHold(auditContext(c), h.store, c.Params("hold_id"), req.Amount, req.Currency, h.policy)
+	if err != nil {
+		return holdError(c, err, "Failed to capture hold")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(CaptureHoldResponse{
+		Hold:          hold,
+		TransactionID: debit.TransactionID,
+		Balance:       balance,
+	})
+}
+
+// VoidHold handles voiding a hold
+// @Summary Void a hold
+// @Description Releases an active hold without debiting anything
+// @Tags holds
+// @Produce json
+// @Param hold_id path string true "Hold ID"
+// @Success 200 {object} models.Hold "Hold voided successfully"
+// @Failure 403 {object} models.ErrorResponse "Token of a customer rather than an operator"
+// @Failure 404 {object} models.ErrorResponse "Hold not found"
+// @Failure 409 {object} models.ErrorResponse "Hold is no longer active"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /holds/{hold_id}/void [post]
+func (h *HoldHandler) VoidHold(c *fiber.Ctx) error {
+	if err := h.checkHoldAccess(c, c.Params("hold_id")); err != nil {
+		return holdError(c, err, "Failed to fetch hold")
+	}
+	hold, err := ledger.VoidHold(auditContext(c), h.store, c.Params("hold_id"))
+	if err != nil {
+		return holdError(c, err, "Failed to void hold")
+	}
+	return c.Status(fiber.StatusOK).JSON(hold)
+}
+
+// ListCustomerHolds handles listing the holds of a customer
+// @Summary List customer holds
+// @Description Lists the holds of a customer, oldest first
+// @Tags holds
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Param status query string false "Only holds in this state" Enums(active, captured, voided, expired)
+// @Success 200 {object} HoldListResponse "Holds retrieved successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id}/holds [get]
+func (h *HoldHandler) ListCustomerHolds(c *fiber.Ctx) error {
+	customerID := c.Params("customer_id")
+	status := c.Query("status")
+	switch status {
+	case "", models.HoldStatusActive, models.HoldStatusCaptured, models.HoldStatusVoided, models.HoldStatusExpired:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "status must be 'active', 'captured', 'voided' or 'expired'"})
+	}
+
+	if _, err := h.store.GetCustomer(c.Context(), customerID); err != nil {
+		return holdError(c, err, "Failed to fetch customer")
+	}
+	holds, err := h.store.ListHolds(c.Context(), customerID, status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch holds"})
+	}
+	return c.Status(fiber.StatusOK).JSON(HoldListResponse{Holds: holds})
+}
+
+// checkHoldAccess fails with store.ErrHoldNotFound when the hold belongs to a
+// customer the request may not access, so that its existence is not disclosed
+func (h *HoldHandler) checkHoldAccess(c *fiber.Ctx, holdID string) error {
+	if !principalOf(c).IsRestricted() {
+		return nil
+	}
+	hold, err := h.store.GetHold(c.Context(), holdID)
+	if err != nil {
+		return err
+	}
+	if !canAccessCustomer(c, hold.CustomerID) {
+		return store.ErrHoldNotFound
+	}
+	return nil
+}
+
+// requestCurrency returns the currency with the ISO 4217 code given in a
+// request, in any case, or the default currency if none was given
+func requestCurrency(code string) (models.Currency, error) {
+	return models.LookupCurrency(models.CurrencyOrDefault(code))
+}
+
+// validAmount checks that a hold amount is positive and fits currency
+func validAmount(amount models.Money, currency models.Currency) (models.Money, error) {
+	if !amount.IsPositive() {
+		return models.Money{}, errors.New("amount must be greater than 0")
+	}
+	amount, err := amount.InCurrency(currency)
+	if err != nil {
+		return models.Money{}, errors.New("amount has more decimal places than " + currency.Code + " allows")
+	}
+	return amount, nil
+}
+
+// holdError writes the response for an error from a hold operation
+func holdError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, store.ErrCustomerNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
+	case errors.Is(err, store.ErrHoldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Hold not found"})
+	case errors.Is(err, models.ErrCaptureExceedsHold), errors.Is(err, ledger.ErrInvalidAmount),
+		errors.Is(err, models.ErrExcessPrecision), errors.Is(err, models.ErrMoneyOverflow), errors.Is(err, models.ErrUnsupportedCurrency):
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: "Currency does not match the hold"})
+	case errors.Is(err, models.ErrHoldNotActive):
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Hold is no longer active"})
+	case errors.Is(err, models.ErrHoldExpired):
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Hold has expired"})
+	case errors.Is(err, models.ErrAccountClosed):
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Account is closed"})
+	case errors.Is(err, models.ErrAccountFrozen):
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Account is frozen"})
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: "Insufficient funds"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: fallback})
+}
+
+// RegisterRoutes registers the hold routes
+func (h *HoldHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/holds", h.CreateHold)
+	app.Get("/holds/:hold_id", h.GetHold)
+	app.Post("/holds/:hold_id/capture", h.CaptureHold)
+	app.Post("/holds/:hold_id/void", h.VoidHold)
+	app.Get("/customers/:customer_id/holds", h.ListCustomerHolds)
+}
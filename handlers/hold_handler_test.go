@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHoldEndpoints(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	NewHoldHandler(ledgerStore, ledger.DefaultPolicy).RegisterRoutes(app)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+
+	do := func(method, target, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if out != nil {
+			json.Unmarshal(raw, out)
+		}
+		return resp.StatusCode
+	}
+	balances := func() (models.Money, models.Money) {
+		t.Helper()
+		var balance models.BalanceResponse
+		if status := do(fiber.MethodGet, "/customers/test_customer/balance", "", &balance); status != fiber.StatusOK {
+			t.Fatalf("Expected status %d for balance, got %d", fiber.StatusOK, status)
+		}
+		if balance.AvailableBalance == nil {
+			t.Fatal("Balance response has no available_balance")
+		}
+		return balance.LedgerBalance, *balance.AvailableBalance
+	}
+
+	var hold models.Hold
+	if status := do(fiber.MethodPost, "/holds", `{"customer_id": "test_customer", "amount": 60, "description": "card auth"}`, &hold); status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d placing hold, got %d", fiber.StatusCreated, status)
+	}
+	if hold.Status != models.HoldStatusActive || hold.ExpiresAt.IsZero() {
+		t.Errorf("Placed hold = %+v, want active with an expiry time", hold)
+	}
+	if ledgerBalance, available := balances(); !ledgerBalance.Equal(models.MustParseMoney("100")) || !available.Equal(models.MustParseMoney("40")) {
+		t.Errorf("Balances = %s ledger, %s available; want 100 and 40", ledgerBalance, available)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		requestBody    string
+		expectedStatus int
+	}{
+		{"hold beyond available balance", fiber.MethodPost, "/holds", `{"customer_id": "test_customer", "amount": 41}`, fiber.StatusUnprocessableEntity},
+		{"hold without amount", fiber.MethodPost, "/holds", `{"customer_id": "test_customer"}`, fiber.StatusBadRequest},
+		{"hold expiring in the past", fiber.MethodPost, "/holds", `{"customer_id": "test_customer", "amount": 1, "expires_at": "2020-01-01T00:00:00Z"}`, fiber.StatusBadRequest},
+		{"hold for missing customer", fiber.MethodPost, "/holds", `{"customer_id": "missing", "amount": 1}`, fiber.StatusNotFound},
+		{"get hold", fiber.MethodGet, "/holds/" + hold.HoldID, "", fiber.StatusOK},
+		{"get missing hold", fiber.MethodGet, "/holds/missing", "", fiber.StatusNotFound},
+		{"capture more than held", fiber.MethodPost, "/holds/" + hold.HoldID + "/capture", `{"amount": 61}`, fiber.StatusBadRequest},
+		{"partial capture", fiber.MethodPost, "/holds/" + hold.HoldID + "/capture", `{"amount": 45}`, fiber.StatusOK},
+		{"capture twice", fiber.MethodPost, "/holds/" + hold.HoldID + "/capture", "", fiber.StatusConflict},
+		{"void captured hold", fiber.MethodPost, "/holds/" + hold.HoldID + "/void", "", fiber.StatusConflict},
+		{"list captured holds", fiber.MethodGet, "/customers/test_customer/holds?status=captured", "", fiber.StatusOK},
+		{"list with invalid status", fiber.MethodGet, "/customers/test_customer/holds?status=pending", "", fiber.StatusBadRequest},
+		{"list for missing customer", fiber.MethodGet, "/customers/missing/holds", "", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := do(tt.method, tt.target, tt.requestBody, nil); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+
+	if ledgerBalance, available := balances(); !ledgerBalance.Equal(models.MustParseMoney("55")) || !available.Equal(models.MustParseMoney("55")) {
+		t.Errorf("Balances after partial capture = %s ledger, %s available; want 55 and 55", ledgerBalance, available)
+	}
+
+	var voided models.Hold
+	do(fiber.MethodPost, "/holds", `{"customer_id": "test_customer", "amount": 5}`, &hold)
+	if status := do(fiber.MethodPost, "/holds/"+hold.HoldID+"/void", "", &voided); status != fiber.StatusOK || voided.Status != models.HoldStatusVoided {
+		t.Errorf("Voiding hold returned %d with %+v, want 200 and a voided hold", status, voided)
+	}
+
+	var list HoldListResponse
+	do(fiber.MethodGet, "/customers/test_customer/holds", "", &list)
+	if len(list.Holds) != 2 || list.Holds[0].Status != models.HoldStatusCaptured || list.Holds[1].Status != models.HoldStatusVoided {
+		t.Errorf("Holds = %+v, want the captured hold and then the voided one", list.Holds)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make POST /transactions safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses that were replayed from a stored result
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const (
+	maxIdempotencyKeyLength = 255
+	idempotencyKeyTTL       = 24 * time.Hour
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// idempotencyWaitTimeout is how long a duplicate request waits for the original to finish
+var idempotencyWaitTimeout = 5 * time.Second
+
+// transactionFingerprint identifies the content of a transaction request so a
+// reused key can be told apart from a genuine retry
+func transactionFingerprint(customerID, transactionType string, amount models.Money, currency string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{customerID, transactionType, amount.String(), currency}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// namespacedIdempotencyKey returns the key under which a caller's
+// idempotency key is stored, so that callers choosing the same key never see
+// each other's transactions. Callers are told apart by their principal or,
+// when authentication is disabled, by the client ID they send.
+func namespacedIdempotencyKey(principal auth.Principal, clientID, key string) string {
+	if principal.Kind == "" {
+		return "client/" + url.PathEscape(clientID) + "/" + key
+	}
+	return principal.Kind + "/" + url.PathEscape(principal.ID) + "/" + key
+}
+
+// respondToDuplicate answers a request whose idempotency key was already claimed
+func (h *TransactionHandler) respondToDuplicate(c *fiber.Ctx, record models.IdempotencyRecord, fingerprint string) error {
+	if record.Fingerprint != fingerprint {
+		return c.Status(fiber.StatusConflict).JSON(errorResponse(models.ErrorCodeIdempotencyConflict, models.ErrorCodeIdempotencyConflict.Message()))
+	}
+
+	// Give the original request a chance to finish
+	deadline := time.Now().Add(idempotencyWaitTimeout)
+	for record.Status != models.IdempotencyStatusCompleted && time.Now().Before(deadline) {
+		time.Sleep(idempotencyPollInterval)
+		latest, err := h.idempotency.GetIdempotencyRecord(c.Context(), record.Key)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to read idempotency key"})
+		}
+		record = latest
+	}
+
+	if record.Status != models.IdempotencyStatusCompleted || record.Response == nil {
+		return c.Status(fiber.StatusAccepted).JSON(models.TransactionStatusResponse{
+			TransactionID: record.TransactionID,
+			Status:        models.IdempotencyStatusProcessing,
+		})
+	}
+
+	c.Set(IdempotentReplayedHeader, "true")
+	return c.Status(record.StatusCode).JSON(record.Response)
+}
+
+// completeIdempotencyKey stores the final response for key, if the request had one
+func (h *TransactionHandler) completeIdempotencyKey(key string, statusCode int, response models.TransactionStatusResponse) {
+	if key == "" {
+		return
+	}
+	if err := h.idempotency.CompleteIdempotencyKey(context.Background(), key, statusCode, response); err != nil {
+		log.Printf("failed to complete idempotency key %s: %v", key, err)
+	}
+}
+
+// completeIdempotencyKeyWhenSettled stores the outcome of a transaction for
+// key, if the request had one, once it arrives on completion
+func (h *TransactionHandler) completeIdempotencyKeyWhenSettled(key string, completion <-chan models.TransactionStatusResponse) {
+	if key == "" {
+		return
+	}
+	go func() {
+		select {
+		case status := <-completion:
+			h.completeIdempotencyKey(key, transactionStatusCode(status), status)
+		case <-time.After(idempotencyKeyTTL):
+		}
+	}()
+}
+
+// releaseIdempotencyKey forgets a claimed key whose request never reached the
+// ledger, so the client can retry with the same key
+func (h *TransactionHandler) releaseIdempotencyKey(key string) {
+	if key == "" {
+		return
+	}
+	if err := h.idempotency.ReleaseIdempotencyKey(context.Background(), key); err != nil {
+		log.Printf("failed to release idempotency key %s: %v", key, err)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LedgerHandler exposes the double-entry journal
+type LedgerHandler struct {
+	store   store.LedgerStore
+	journal store.JournalStore
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(ledgerStore store.LedgerStore, journalStore store.JournalStore) *LedgerHandler {
+	return &LedgerHandler{
+		store:   ledgerStore,
+		journal: journalStore,
+	}
+}
+
+// GetTrialBalance handles retrieving the trial balance
+// @Summary Get trial balance
+// @Description Lists the net balance of every journal account. Debits are positive and credits negative, so a balanced ledger totals zero.
+// @Tags ledger
+// @Produce json
+// @Success 200 {object} models.TrialBalanceResponse "Trial balance retrieved successfully"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /ledger/trial-balance [get]
+func (h *LedgerHandler) GetTrialBalance(c *fiber.Ctx) error {
+	accounts, err := h.journal.GetTrialBalance(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to compute trial balance"})
+	}
+
+	var total models.Money
+	for _, account := range accounts {
+		total = total.Add(account.Balance)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.TrialBalanceResponse{
+		Accounts: accounts,
+		Total:    total,
+		Balanced: total.IsZero(),
+	})
+}
+
+// GetReconciliation handles checking a customer's balance against the journal
+// @Summary Reconcile customer balance
+// @Description Compares the stored balance of a customer in one currency with the balance derived from its journal
+// @Description account, and checks that its transaction sequence has no gaps and its balance snapshots are continuous
+// @Tags ledger
+// @Produce json
+// @Param customer_id path string true "Customer ID"
+// @Param currency query string false "ISO 4217 code of the balance to compare (default USD)"
+// @Success 200 {object} models.ReconciliationResponse "Reconciliation computed successfully"
+// @Failure 400 {object} models.ErrorResponse "Unsupported currency"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /ledger/customers/{customer_id}/reconciliation [get]
+func (h *LedgerHandler) GetReconciliation(c *fiber.Ctx) error {
+	customerID := c.Params("customer_id")
+	currency, err := requestCurrency(c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	customer, err := h.store.GetCustomer(c.Context(), customerID)
+	if err != nil {
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch customer"})
+	}
+
+	journalBalance, err := ledger.JournalBalance(c.Context(), h.journal, customerID, currency.Code)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to compute journal balance"})
+	}
+
+	transactions, err := h.store.GetTransactionHistory(c.Context(), customerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch transactions"})
+	}
+
+	storedBalance := customer.BalanceIn(currency.Code).Balance
+	response := models.ReconciliationResponse{
+		CustomerID:        customer.CustomerID,
+		Currency:          currency.Code,
+		StoredBalance:     storedBalance,
+		JournalBalance:    journalBalance,
+		InBalance:         storedBalance.Equal(journalBalance),
+		LastSequence:      customer.LastSequence,
+		HistoryContinuous: true,
+	}
+	if err := ledger.VerifyHistory(customer, transactions); err != nil {
+		response.HistoryContinuous = false
+		response.HistoryIssue = err.Error()
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// RegisterRoutes registers the ledger routes
+func (h *LedgerHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/ledger/trial-balance", h.GetTrialBalance)
+	app.Get("/ledger/customers/:customer_id/reconciliation", h.GetReconciliation)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"ledger-service/store"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
+)
+
+// errInvalidCursor is returned for a next token the service did not issue
+var errInvalidCursor = errors.New("invalid cursor")
+
+// transactionCursorToken is the JSON form of a store.TransactionCursor inside
+// the opaque next token
+type transactionCursorToken struct {
+	Timestamp     time.Time `json:"t"`
+	TransactionID string    `json:"id"`
+}
+
+// encodeTransactionCursor turns the position after t into an opaque token
+func encodeTransactionCursor(t models.Transaction) string {
+	raw, _ := json.Marshal(transactionCursorToken{Timestamp: t.Timestamp, TransactionID: t.TransactionID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTransactionCursor parses a token made by encodeTransactionCursor
+func decodeTransactionCursor(token string) (*store.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var decoded transactionCursorToken
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded.TransactionID == "" {
+		return nil, errInvalidCursor
+	}
+	return &store.TransactionCursor{Timestamp: decoded.Timestamp, TransactionID: decoded.TransactionID}, nil
+}
+
+// parseTransactionQuery reads the history filters, sort order and page from
+// the query string
+func parseTransactionQuery(c *fiber.Ctx, customerID string) (store.TransactionQuery, error) {
+	query := store.TransactionQuery{CustomerID: customerID, Limit: defaultHistoryPageSize}
+
+	switch transactionType := c.Query("type"); transactionType {
+	case "", "credit", "debit":
+		query.Type = transactionType
+	default:
+		return query, errors.New("type must be 'credit' or 'debit'")
+	}
+
+	if raw := c.Query("currency"); raw != "" {
+		currency, err := models.LookupCurrency(raw)
+		if err != nil {
+			return query, err
+		}
+		query.Currency = currency.Code
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **models.Money
+	}{{"min_amount", &query.MinAmount}, {"max_amount", &query.MaxAmount}} {
+		if raw := c.Query(bound.name); raw != "" {
+			amount, err := models.ParseMoney(raw)
+			if err != nil {
+				return query, fmt.Errorf("%s must be a decimal amount", bound.name)
+			}
+			*bound.target = &amount
+		}
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if raw := c.Query(bound.name); raw != "" {
+			timestamp, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.name)
+			}
+			*bound.target = timestamp
+		}
+	}
+
+	switch strings.ToLower(c.Query("sort", "asc")) {
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("sort must be 'asc' or 'desc'")
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxHistoryPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxHistoryPageSize)
+		}
+		query.Limit = limit
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		after, err := decodeTransactionCursor(token)
+		if err != nil {
+			return query, err
+		}
+		query.After = after
+	}
+	return query, nil
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReversalHandler handles reversing posted transactions
+type ReversalHandler struct {
+	store  store.LedgerStore
+	policy ledger.Policy
+}
+
+// NewReversalHandler creates a new reversal handler that posts with policy
+func NewReversalHandler(ledgerStore store.LedgerStore, policy ledger.Policy) *ReversalHandler {
+	return &ReversalHandler{
+		store:  ledgerStore,
+		policy: policy,
+	}
+}
+
+// ReverseTransactionRequest represents the request body for reversing a transaction
+type ReverseTransactionRequest struct {
+	// Amount defaults to everything not reversed yet
+	Amount *models.Money `json:"amount,omitempty" swaggertype:"number" example:"25"`
+	// Currency, if given, must be the currency of the original transaction
+	Currency string `json:"currency,omitempty" example:"USD"`
+	Reason   string `json:"reason" example:"duplicate charge"`
+}
+
+// ReverseTransaction handles reversing all or part of a posted transaction
+// @Summary Reverse a transaction
+// @Description Posts a compensating transaction of the opposite type that references the original, and records
+// @Description the reversed amount and reversal status on the original. Without an amount everything not yet
+// @Description reversed is reversed. Reversals are in the currency of the original and never add up to more than
+// @Description the original amount. Reversals and the legs of transfers and conversions cannot be reversed.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param transaction_id path string true "ID of the transaction to reverse"
+// @Param reversal body ReverseTransactionRequest false "Reversal details"
+// @Success 201 {object} models.ReversalResponse "Transaction reversed successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unsupported currency"
+// @Failure 403 {object} models.ErrorResponse "Token of a customer rather than an operator"
+// @Failure 404 {object} models.ErrorResponse "Transaction not found"
+// @Failure 409 {object} models.ErrorResponse "Transaction is fully reversed or not reversible, or the account is frozen or closed"
+// @Failure 422 {object} models.ErrorResponse "Reversal exceeds the original amount, currency does not match the original, or insufficient funds"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transactions/{transaction_id}/reverse [post]
+func (h *ReversalHandler) ReverseTransaction(c *fiber.Ctx) error {
+	var req ReverseTransactionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
+		}
+	}
+	if req.Currency != "" {
+		currency, err := requestCurrency(req.Currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeUnsupportedCurrency, err.Error()))
+		}
+		req.Currency = currency.Code
+	}
+	if err := h.checkTransactionAccess(c, c.Params("transaction_id")); err != nil {
+		code := reversalErrorCode(err)
+		return c.Status(errorCodeStatus(code)).JSON(errorResponse(code, code.Message()))
+	}
+
+	// The amount is checked against the currency of the original when it is reversed
+	reversal, original, balance, err := ledger.ReverseTransaction(auditContext(c), h.store, c.Params("transaction_id"), req.Amount, req.Currency, req.Reason, h.policy)
+	if err != nil {
+		code := reversalErrorCode(err)
+		return c.Status(errorCodeStatus(code)).JSON(errorResponse(code, code.Message()))
+	}
+
+	c.Location("/transactions/" + reversal.TransactionID)
+	return c.Status(fiber.StatusCreated).JSON(models.ReversalResponse{
+		TransactionID:         reversal.TransactionID,
+		OriginalTransactionID: original.TransactionID,
+		CustomerID:            reversal.CustomerID,
+		Type:                  reversal.Type,
+		Amount:                reversal.Amount,
+		Currency:              reversal.CurrencyCode(),
+		ReversedAmount:        *original.ReversedAmount,
+		ReversalStatus:        original.ReversalStatus,
+		Balance:               balance,
+		Timestamp:             reversal.Timestamp.Format(time.RFC3339),
+	})
+}
+
+// checkTransactionAccess fails with store.ErrTransactionNotFound when the
+// transaction belongs to a customer the request may not access, so that its
+// existence is not disclosed
+func (h *ReversalHandler) checkTransactionAccess(c *fiber.Ctx, transactionID string) error {
+	if !principalOf(c).IsRestricted() {
+		return nil
+	}
+	return h.store.WithTransaction(c.Context(), func(tx store.Tx) error {
+		transaction, err := tx.GetTransaction(transactionID)
+		if err != nil {
+			return err
+		}
+		if !canAccessCustomer(c, transaction.CustomerID) {
+			return store.ErrTransactionNotFound
+		}
+		return nil
+	})
+}
+
+// reversalErrorCode maps an error from ledger.ReverseTransaction to its error code
+func reversalErrorCode(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, store.ErrTransactionNotFound):
+		return models.ErrorCodeTransactionNotFound
+	case errors.Is(err, models.ErrAlreadyReversed), errors.Is(err, models.ErrNotReversible):
+		return models.ErrorCodeNotReversible
+	case errors.Is(err, models.ErrReversalExceedsOriginal):
+		return models.ErrorCodeReversalExceedsOriginal
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		return models.ErrorCodeCurrencyMismatch
+	case errors.Is(err, ledger.ErrInvalidAmount), errors.Is(err, models.ErrExcessPrecision), errors.Is(err, models.ErrMoneyOverflow):
+		return models.ErrorCodeValidationFailed
+	case errors.Is(err, store.ErrCustomerNotFound):
+		return models.ErrorCodeCustomerNotFound
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return models.ErrorCodeInsufficientFunds
+	case errors.Is(err, models.ErrAccountClosed):
+		return models.ErrorCodeAccountClosed
+	case errors.Is(err, models.ErrAccountFrozen):
+		return models.ErrorCodeAccountFrozen
+	}
+	return models.ErrorCodeInternal
+}
+
+// RegisterRoutes registers the reversal routes
+func (h *ReversalHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/transactions/:transaction_id/reverse", h.ReverseTransaction)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	charge := models.Transaction{TransactionID: "charge", CustomerID: "test_customer", Type: "debit", Amount: models.MustParseMoney("40"), Timestamp: time.Now()}
+	err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+		_, err := ledger.ApplyTransaction(tx, charge, ledger.DefaultPolicy)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to post transaction: %v", err)
+	}
+
+	app := fiber.New()
+	NewReversalHandler(ledgerStore, ledger.DefaultPolicy).RegisterRoutes(app)
+	NewCustomerHandler(ledgerStore).RegisterRoutes(app)
+
+	tests := []struct {
+		name           string
+		target         string
+		requestBody    string
+		expectedStatus int
+		expectedCode   models.ErrorCode
+		expectedState  string
+	}{
+		{"other currency", "/transactions/charge/reverse", `{"amount": 15, "currency": "EUR"}`, fiber.StatusUnprocessableEntity, models.ErrorCodeCurrencyMismatch, ""},
+		{"unsupported currency", "/transactions/charge/reverse", `{"amount": 15, "currency": "XYZ"}`, fiber.StatusBadRequest, models.ErrorCodeUnsupportedCurrency, ""},
+		{"partial reversal", "/transactions/charge/reverse", `{"amount": 15, "reason": "partial refund"}`, fiber.StatusCreated, "", models.ReversalStatusPartial},
+		{"more than is left", "/transactions/charge/reverse", `{"amount": 30}`, fiber.StatusUnprocessableEntity, models.ErrorCodeReversalExceedsOriginal, ""},
+		{"invalid amount", "/transactions/charge/reverse", `{"amount": -1}`, fiber.StatusBadRequest, models.ErrorCodeValidationFailed, ""},
+		{"rest without a body", "/transactions/charge/reverse", "", fiber.StatusCreated, "", models.ReversalStatusFull},
+		{"fully reversed", "/transactions/charge/reverse", "", fiber.StatusConflict, models.ErrorCodeNotReversible, ""},
+		{"missing transaction", "/transactions/missing/reverse", "", fiber.StatusNotFound, models.ErrorCodeTransactionNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.target, strings.NewReader(tt.requestBody))
+			if tt.requestBody != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != fiber.StatusCreated {
+				var errorBody models.ErrorResponse
+				json.NewDecoder(resp.Body).Decode(&errorBody)
+				if errorBody.Code != tt.expectedCode {
+					t.Errorf("Expected code %s, got %s", tt.expectedCode, errorBody.Code)
+				}
+				return
+			}
+			var reversal models.ReversalResponse
+			json.NewDecoder(resp.Body).Decode(&reversal)
+			if reversal.OriginalTransactionID != "charge" || reversal.Type != "credit" || reversal.ReversalStatus != tt.expectedState {
+				t.Errorf("Reversal = %+v, want a credit reversing charge leaving it %s", reversal, tt.expectedState)
+			}
+			if location := resp.Header.Get(fiber.HeaderLocation); location != "/transactions/"+reversal.TransactionID {
+				t.Errorf("Location = %q, want /transactions/%s", location, reversal.TransactionID)
+			}
+		})
+	}
+
+	// The history links the reversals to the original
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/test_customer/transactions", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var page TransactionHistoryPage
+	json.NewDecoder(resp.Body).Decode(&page)
+	if len(page.Transactions) != 3 {
+		t.Fatalf("History has %d transactions, want the charge and two reversals", len(page.Transactions))
+	}
+	original := page.Transactions[0]
+	if original.ReversalStatus != models.ReversalStatusFull || original.ReversedAmount == nil || !original.ReversedAmount.Equal(models.MustParseMoney("40")) {
+		t.Errorf("Original in history = %+v, want fully reversed", original)
+	}
+	for _, reversal := range page.Transactions[1:] {
+		if reversal.ReversalOf != "charge" {
+			t.Errorf("Reversal in history = %+v, want it to reference charge", reversal)
+		}
+	}
+}
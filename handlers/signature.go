@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/auth"
+	"ledger-service/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request signing headers
+const (
+	ClientIDHeader           = "X-Client-ID"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureNonceHeader     = "X-Signature-Nonce"
+	SignatureHeader          = "X-Signature"
+)
+
+// SignatureChecker is the middleware that lets a request through only if it
+// is signed by a known client, recently and with a nonce not seen before.
+// Mount it on the route groups that require signed requests.
+type SignatureChecker struct {
+	verifier *auth.RequestVerifier
+}
+
+// NewSignatureChecker creates a new signature checker verifying requests with verifier
+func NewSignatureChecker(verifier *auth.RequestVerifier) *SignatureChecker {
+	return &SignatureChecker{verifier: verifier}
+}
+
+// Handle checks the signature of the request and passes it on. Unsigned or
+// badly signed requests are rejected with INVALID_SIGNATURE, requests signed
+// too long ago or too far ahead with STALE_TIMESTAMP, and repeated requests
+// with REPLAYED_NONCE, all with 401.
+func (s *SignatureChecker) Handle(c *fiber.Ctx) error {
+	err := s.verifier.Verify(c.Context(), auth.SignedRequest{
+		ClientID:  c.Get(ClientIDHeader),
+		Timestamp: c.Get(SignatureTimestampHeader),
+		Nonce:     c.Get(SignatureNonceHeader),
+		Signature: c.Get(SignatureHeader),
+		Method:    c.Method(),
+		Target:    c.OriginalURL(),
+		Body:      c.Body(),
+	})
+	switch {
+	case err == nil:
+		return c.Next()
+	case errors.Is(err, auth.ErrInvalidSignature):
+		return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeInvalidSignature, err.Error()))
+	case errors.Is(err, auth.ErrStaleTimestamp):
+		return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeStaleTimestamp, models.ErrorCodeStaleTimestamp.Message()))
+	case errors.Is(err, auth.ErrReplayedNonce):
+		return c.Status(fiber.StatusUnauthorized).JSON(errorResponse(models.ErrorCodeReplayedNonce, models.ErrorCodeReplayedNonce.Message()))
+	}
+	return c.Status(fiber.StatusServiceUnavailable).JSON(errorResponse(models.ErrorCodeStorageUnavailable, "Failed to record request nonce"))
+}
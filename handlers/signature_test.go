@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"ledger-service/auth"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSignatureChecker(t *testing.T) {
+	secret := "back-office-secret-0123456789abcdef"
+	verifier, err := auth.NewRequestVerifier([]auth.SigningClient{{ClientID: "back-office", Secret: secret}}, setupTestStore(t), auth.DefaultSignatureWindow)
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use("/transactions", NewSignatureChecker(verifier).Handle)
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Post("/transactions", ok)
+	app.Get("/customers", ok)
+
+	body := `{"customer_id": "c1", "type": "credit", "amount": 10}`
+	type signing struct {
+		target    string
+		signedAs  string
+		timestamp time.Time
+		nonce     string
+		body      string
+	}
+	do := func(s signing) (int, models.ErrorCode) {
+		t.Helper()
+		ts := strconv.FormatInt(s.timestamp.Unix(), 10)
+		req := httptest.NewRequest(fiber.MethodPost, s.target, strings.NewReader(s.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(ClientIDHeader, "back-office")
+		req.Header.Set(SignatureTimestampHeader, ts)
+		req.Header.Set(SignatureNonceHeader, s.nonce)
+		req.Header.Set(SignatureHeader, auth.SignRequest([]byte(secret), fiber.MethodPost, s.signedAs, ts, s.nonce, []byte(body)))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var response models.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, response.Code
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name           string
+		request        signing
+		expectedStatus int
+		expectedCode   models.ErrorCode
+	}{
+		{"signed", signing{"/transactions", "/transactions", now, "nonce-0000000001", body}, fiber.StatusOK, ""},
+		{"replayed", signing{"/transactions", "/transactions", now, "nonce-0000000001", body}, fiber.StatusUnauthorized, models.ErrorCodeReplayedNonce},
+		{"stale", signing{"/transactions", "/transactions", now.Add(-time.Hour), "nonce-0000000002", body}, fiber.StatusUnauthorized, models.ErrorCodeStaleTimestamp},
+		{"tampered body", signing{"/transactions", "/transactions", now, "nonce-0000000003", `{"customer_id": "c1", "type": "credit", "amount": 99}`}, fiber.StatusUnauthorized, models.ErrorCodeInvalidSignature},
+		{"query string not signed", signing{"/transactions?mode=async", "/transactions", now, "nonce-0000000004", body}, fiber.StatusUnauthorized, models.ErrorCodeInvalidSignature},
+		{"query string signed", signing{"/transactions?mode=async", "/transactions?mode=async", now, "nonce-0000000005", body}, fiber.StatusOK, ""},
+		{"other case", signing{"/Transactions", "/transactions", now, "nonce-0000000006", body}, fiber.StatusUnauthorized, models.ErrorCodeInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := do(tt.request)
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if tt.expectedCode != "" && code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, code)
+			}
+		})
+	}
+
+	unsigned := httptest.NewRequest(fiber.MethodPost, "/transactions", strings.NewReader(body))
+	resp, err := app.Test(unsigned)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d for an unsigned request, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/customers", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d outside the signed route group, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatementResponse is a customer's account statement for a period
+type StatementResponse struct {
+	CustomerID     string                       `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Currency       string                       `json:"currency" example:"USD"`
+	From           string                       `json:"from" example:"2025-04-01T00:00:00Z"`
+	To             string                       `json:"to" example:"2025-05-01T00:00:00Z"`
+	OpeningBalance models.Money                 `json:"opening_balance" swaggertype:"number" example:"100.00"`
+	TotalCredits   models.Money                 `json:"total_credits" swaggertype:"number" example:"250.00"`
+	TotalDebits    models.Money                 `json:"total_debits" swaggertype:"number" example:"75.00"`
+	ClosingBalance models.Money                 `json:"closing_balance" swaggertype:"number" example:"275.00"`
+	Entries        []TransactionHistoryResponse `json:"entries"`
+}
+
+// statementCSVHeader is the column row of a CSV statement
+var statementCSVHeader = []string{"timestamp", "transaction_id", "type", "description", "credit", "debit", "balance"}
+
+// statementHTML renders a statement as a printable page
+var statementHTML = template.Must(template.New("statement").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.CustomerID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.amount, th.amount { text-align: right; font-variant-numeric: tabular-nums; }
+tr.summary td { font-weight: bold; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>Customer {{.CustomerID}}<br>Currency {{.Currency}}<br>Period {{.From}} to {{.To}}</p>
+<table>
+<thead>
+<tr><th>Date</th><th>Transaction</th><th>Description</th><th class="amount">Credit</th><th class="amount">Debit</th><th class="amount">Balance</th></tr>
+</thead>
+<tbody>
+<tr class="summary"><td colspan="5">Opening balance</td><td class="amount">{{.OpeningBalance}}</td></tr>
+{{- range .Entries}}
+<tr><td>{{.Timestamp}}</td><td>{{.TransactionID}}</td><td>{{.Type}}{{if .TransferID}} (transfer {{.TransferID}}){{else if .HoldID}} (capture of hold {{.HoldID}}){{else if .ConversionID}} (conversion {{.ConversionID}}){{else if .ReversalOf}} (reversal of {{.ReversalOf}}){{end}}</td><td class="amount">{{if eq .Type "credit"}}{{.Amount}}{{end}}</td><td class="amount">{{if eq .Type "debit"}}{{.Amount}}{{end}}</td><td class="amount">{{.RunningBalance}}</td></tr>
+{{- end}}
+<tr class="summary"><td colspan="3">Totals</td><td class="amount">{{.TotalCredits}}</td><td class="amount">{{.TotalDebits}}</td><td></td></tr>
+<tr class="summary"><td colspan="5">Closing balance</td><td class="amount">{{.ClosingBalance}}</td></tr>
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// GetStatement handles building a customer's account statement
+// @Summary Get account statement
+// @Description Builds a statement of a customer's account for the period from `from` up to, but excluding, `to`,
+// @Description with the opening balance, every transaction with its running balance, the credit and debit totals
+// @Description and the closing balance. A YYYY-MM-DD date as `to` includes that whole day. A statement covers
+// @Description one currency, USD unless another is given.
+// @Tags customers
+// @Produce json
+// @Produce text/csv
+// @Produce text/html
+// @Param customer_id path string true "Customer ID"
+// @Param from query string true "Start of the period, as an RFC 3339 timestamp or a YYYY-MM-DD date"
+// @Param to query string true "End of the period, as an RFC 3339 timestamp or a YYYY-MM-DD date"
+// @Param currency query string false "ISO 4217 code of the currency to report (default USD)"
+// @Param format query string false "Output format" Enums(json, csv, html) default(json)
+// @Success 200 {object} StatementResponse "Statement built successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /customers/{customer_id}/statements [get]
+func (h *CustomerHandler) GetStatement(c *fiber.Ctx) error {
+	customerID := c.Params("customer_id")
+	if customerID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Customer ID is required",
+		})
+	}
+
+	from, err := parsePeriodBound(c.Query("from"), "from", false)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	to, err := parsePeriodBound(c.Query("to"), "to", true)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	currency, err := requestCurrency(c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" && format != "html" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "format must be 'json', 'csv' or 'html'",
+		})
+	}
+
+	statement, err := ledger.BuildStatement(c.Context(), h.store, customerID, currency.Code, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, ledger.ErrInvalidPeriod):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, store.ErrCustomerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Customer not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Failed to build statement",
+		})
+	}
+
+	response := newStatementResponse(statement)
+	switch format {
+	case "csv":
+		body, err := renderStatementCSV(response)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Failed to render statement",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="statement-%s-%s-%s.csv"`, customerID, currency.Code, from.Format(time.DateOnly)))
+		return c.Status(fiber.StatusOK).Send(body)
+	case "html":
+		var body bytes.Buffer
+		if err := statementHTML.Execute(&body, response); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Failed to render statement",
+			})
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Status(fiber.StatusOK).Send(body.Bytes())
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// newStatementResponse converts a statement to its response form
+func newStatementResponse(statement ledger.Statement) StatementResponse {
+	response := StatementResponse{
+		CustomerID:     statement.CustomerID,
+		Currency:       statement.Currency,
+		From:           statement.From.Format(time.RFC3339),
+		To:             statement.To.Format(time.RFC3339),
+		OpeningBalance: statement.OpeningBalance,
+		TotalCredits:   statement.TotalCredits,
+		TotalDebits:    statement.TotalDebits,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        make([]TransactionHistoryResponse, len(statement.Entries)),
+	}
+	for i, entry := range statement.Entries {
+		runningBalance := entry.RunningBalance
+		response.Entries[i] = newTransactionHistoryResponse(entry.Transaction)
+		response.Entries[i].RunningBalance = &runningBalance
+	}
+	return response
+}
+
+// renderStatementCSV writes a statement as CSV, framed by an opening and a
+// closing balance row and followed by a totals row
+func renderStatementCSV(statement StatementResponse) ([]byte, error) {
+	var body bytes.Buffer
+	w := csv.NewWriter(&body)
+	w.Write(statementCSVHeader)
+	w.Write([]string{statement.From, "", "", "opening balance", "", "", statement.OpeningBalance.String()})
+	for _, entry := range statement.Entries {
+		credit, debit := "", ""
+		if entry.Type == "credit" {
+			credit = entry.Amount.String()
+		} else {
+			debit = entry.Amount.String()
+		}
+		description := ""
+		switch {
+		case entry.TransferID != "":
+			description = "transfer " + entry.TransferID
+		case entry.HoldID != "":
+			description = "capture of hold " + entry.HoldID
+		case entry.ConversionID != "":
+			description = "conversion " + entry.ConversionID
+		case entry.ReversalOf != "":
+			description = "reversal of " + entry.ReversalOf
+		}
+		w.Write([]string{entry.Timestamp, entry.TransactionID, entry.Type, description, credit, debit, entry.RunningBalance.String()})
+	}
+	w.Write([]string{statement.To, "", "", "totals", statement.TotalCredits.String(), statement.TotalDebits.String(), ""})
+	w.Write([]string{statement.To, "", "", "closing balance", "", "", statement.ClosingBalance.String()})
+	w.Flush()
+	return body.Bytes(), w.Error()
+}
+
+// parsePeriodBound reads a required statement period bound. A bare date
+// stands for the start of that day in UTC, or for the start of the next day
+// when it ends the period, so the period includes it.
+func parsePeriodBound(raw, name string, end bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("%s is required", name)
+	}
+	if bound, err := time.Parse(time.RFC3339, raw); err == nil {
+		return bound, nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+	}
+	if end {
+		return day.AddDate(0, 0, 1), nil
+	}
+	return day, nil
+}
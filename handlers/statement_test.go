@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetStatement(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewCustomerHandler(ledgerStore)
+
+	customer := models.Customer{CustomerID: "test_customer", Name: "Test Customer", Balance: models.MustParseMoney("100"), Status: models.CustomerStatusActive}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	for i, posting := range []struct {
+		transactionType, amount string
+		timestamp               time.Time
+	}{
+		{"credit", "50", time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)},
+		{"debit", "30", time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"credit", "5", time.Date(2025, 4, 30, 18, 0, 0, 0, time.UTC)},
+		{"debit", "1", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		transaction := models.Transaction{
+			TransactionID: fmt.Sprintf("t%d", i+1),
+			CustomerID:    "test_customer",
+			Type:          posting.transactionType,
+			Amount:        models.MustParseMoney(posting.amount),
+			Timestamp:     posting.timestamp,
+		}
+		err := ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+			_, err := ledger.ApplyTransaction(tx, transaction, ledger.DefaultPolicy)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to post transaction: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	get := func(target string) (int, string, string) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), string(body)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		status, _, body := get("/customers/test_customer/statements?from=2025-04-01&to=2025-04-30")
+		if status != fiber.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", fiber.StatusOK, status, body)
+		}
+		var statement StatementResponse
+		if err := json.Unmarshal([]byte(body), &statement); err != nil {
+			t.Fatalf("Failed to decode statement: %v", err)
+		}
+		if statement.From != "2025-04-01T00:00:00Z" || statement.To != "2025-05-01T00:00:00Z" {
+			t.Errorf("Period = %s to %s, want April 2025", statement.From, statement.To)
+		}
+		var entries []string
+		for _, entry := range statement.Entries {
+			entries = append(entries, entry.TransactionID+"="+entry.RunningBalance.String())
+		}
+		if got := strings.Join(entries, ","); got != "t2=120,t3=125" {
+			t.Errorf("Entries = %s, want t2=120,t3=125", got)
+		}
+		if !statement.OpeningBalance.Equal(models.MustParseMoney("150")) || !statement.ClosingBalance.Equal(models.MustParseMoney("125")) ||
+			!statement.TotalCredits.Equal(models.MustParseMoney("5")) || !statement.TotalDebits.Equal(models.MustParseMoney("30")) {
+			t.Errorf("Statement = %+v, want opening 150, credits 5, debits 30 and closing 125", statement)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		status, contentType, body := get("/customers/test_customer/statements?from=2025-04-01&to=2025-04-30&format=csv")
+		if status != fiber.StatusOK || !strings.HasPrefix(contentType, "text/csv") {
+			t.Fatalf("Expected status %d with CSV, got %d with %s", fiber.StatusOK, status, contentType)
+		}
+		records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		if len(records) != 6 {
+			t.Fatalf("CSV has %d rows, want header, opening, 2 entries, totals and closing", len(records))
+		}
+		if records[1][6] != "150" || records[3][1] != "t3" || records[3][6] != "125" || records[4][4] != "5" || records[5][6] != "125" {
+			t.Errorf("CSV rows = %v", records)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		status, contentType, body := get("/customers/test_customer/statements?from=2025-04-01&to=2025-04-30&format=html")
+		if status != fiber.StatusOK || !strings.HasPrefix(contentType, "text/html") {
+			t.Fatalf("Expected status %d with HTML, got %d with %s", fiber.StatusOK, status, contentType)
+		}
+		for _, want := range []string{"Opening balance", "t2", "t3", "Closing balance", "125"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("HTML statement does not contain %q", want)
+			}
+		}
+	})
+
+	tests := []struct {
+		name           string
+		target         string
+		expectedStatus int
+	}{
+		{"missing from", "/customers/test_customer/statements?to=2025-04-30", fiber.StatusBadRequest},
+		{"invalid to", "/customers/test_customer/statements?from=2025-04-01&to=soon", fiber.StatusBadRequest},
+		{"period ends before it starts", "/customers/test_customer/statements?from=2025-04-30&to=2025-04-01", fiber.StatusBadRequest},
+		{"unknown format", "/customers/test_customer/statements?from=2025-04-01&to=2025-04-30&format=pdf", fiber.StatusBadRequest},
+		{"missing customer", "/customers/missing/statements?from=2025-04-01&to=2025-04-30", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _, body := get(tt.target); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, status, body)
+			}
+		})
+	}
+}
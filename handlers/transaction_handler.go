@@ -1,78 +1,103 @@
 package handlers
 
 import (
+	"errors"
+	"ledger-service/audit"
 	"ledger-service/models"
 	"ledger-service/queue"
+	"ledger-service/store"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // TransactionHandler handles transaction-related requests
 type TransactionHandler struct {
-	queue                  *queue.TransactionQueue
-	customersCollection    *mongo.Collection
-	transactionsCollection *mongo.Collection
+	dispatcher  *queue.Dispatcher
+	store       store.LedgerStore
+	idempotency store.IdempotencyStore
 }
 
 // NewTransactionHandler creates a new transaction handler
 func NewTransactionHandler(
-	queue *queue.TransactionQueue,
-	customersCollection *mongo.Collection,
-	transactionsCollection *mongo.Collection,
+	dispatcher *queue.Dispatcher,
+	ledgerStore store.LedgerStore,
+	idempotencyStore store.IdempotencyStore,
 ) *TransactionHandler {
 	return &TransactionHandler{
-		queue:                  queue,
-		customersCollection:    customersCollection,
-		transactionsCollection: transactionsCollection,
+		dispatcher:  dispatcher,
+		store:       ledgerStore,
+		idempotency: idempotencyStore,
 	}
 }
 
 // CreateTransactionRequest represents the request body for creating a transaction
 type CreateTransactionRequest struct {
-	CustomerID string  `json:"customer_id" example:"ef48ae68-182f-4f2f-bb62-8a0016a9ca94"`
-	Type       string  `json:"type" example:"credit"`
-	Amount     float64 `json:"amount" example:"100"`
+	CustomerID string       `json:"customer_id" example:"ef48ae68-182f-4f2f-bb62-8a0016a9ca94"`
+	Type       string       `json:"type" example:"credit"`
+	Amount     models.Money `json:"amount" swaggertype:"number" example:"100"`
+	Currency   string       `json:"currency,omitempty" example:"USD"`
 }
 
 // CreateTransaction handles the creation of a new transaction
 // @Summary Create a new transaction
-// @Description Creates a new credit or debit transaction for a customer
+// @Description Creates a new credit or debit transaction for a customer in an ISO 4217 currency, USD unless given.
+// @Description Send an Idempotency-Key header to make retries safe: a repeated request returns the original result.
+// @Description Use ?mode=async or a "Prefer: respond-async" header to get a 202 right away and poll GET /transactions/{transaction_id}.
 // @Tags transactions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Unique key identifying this request (max 255 characters)"
+// @Param Prefer header string false "Send respond-async to process the transaction asynchronously"
+// @Param mode query string false "Set to async to process the transaction asynchronously" Enums(sync, async)
 // @Param transaction body CreateTransactionRequest true "Transaction details"
+// @Description Failures carry a machine-readable code: VALIDATION_FAILED or UNSUPPORTED_CURRENCY (400), CUSTOMER_NOT_FOUND (404), ACCOUNT_FROZEN, ACCOUNT_CLOSED or IDEMPOTENCY_KEY_CONFLICT (409),
+// @Description INSUFFICIENT_FUNDS (422), PROCESSING_TIMEOUT (408), STORAGE_UNAVAILABLE or QUEUE_UNAVAILABLE (503) and INTERNAL_ERROR (500).
+// @Description Once a transaction was accepted its failures are reported as a TransactionStatusResponse with error_code and failure_reason.
 // @Success 200 {object} models.TransactionStatusResponse "Transaction processed successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid request"
-// @Failure 404 {object} models.ErrorResponse "Customer not found"
-// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Success 202 {object} models.TransactionStatusResponse "Transaction accepted for asynchronous processing, or a request with the same Idempotency-Key is still processing"
+// @Failure 400 {object} models.ErrorResponse "Invalid request (VALIDATION_FAILED) or unsupported currency (UNSUPPORTED_CURRENCY)"
+// @Failure 403 {object} models.ErrorResponse "Token of another customer (CUSTOMER_ACCESS_DENIED), or a customer's token posting a credit (INSUFFICIENT_SCOPE)"
+// @Failure 404 {object} models.ErrorResponse "Customer not found (CUSTOMER_NOT_FOUND)"
+// @Failure 408 {object} models.ErrorResponse "Outcome not known in time (PROCESSING_TIMEOUT)"
+// @Failure 409 {object} models.ErrorResponse "Account frozen or closed (ACCOUNT_FROZEN, ACCOUNT_CLOSED) or Idempotency-Key reused with a different request (IDEMPOTENCY_KEY_CONFLICT)"
+// @Failure 422 {object} models.TransactionStatusResponse "Debit exceeds the balance (INSUFFICIENT_FUNDS)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error (INTERNAL_ERROR)"
+// @Failure 503 {object} models.TransactionStatusResponse "Ledger or queue unavailable (STORAGE_UNAVAILABLE, QUEUE_UNAVAILABLE)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /transactions [post]
 func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 	var req CreateTransactionRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, err.Error()))
 	}
 
 	// Validate transaction type
 	if req.Type != "credit" && req.Type != "debit" {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Invalid transaction type. Must be 'credit' or 'debit'"})
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "Invalid transaction type. Must be 'credit' or 'debit'"))
 	}
 
 	// Validate amount
-	if req.Amount <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Amount must be greater than 0"})
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "Amount must be greater than 0"))
 	}
 
-	// Check if customer exists
-	var customer models.Customer
-	err := h.customersCollection.FindOne(c.Context(), bson.M{"_id": req.CustomerID}).Decode(&customer)
+	currency, err := requestCurrency(req.Currency)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to check customer existence"})
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeUnsupportedCurrency, err.Error()))
+	}
+	amount, err := req.Amount.InCurrency(currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "Amount has more decimal places than "+currency.Code+" allows"))
+	}
+
+	if !canAccessCustomer(c, req.CustomerID) {
+		return customerAccessDenied(c)
+	}
+	if !principalOf(c).CanPost(req.Type) {
+		return operatorOnly(c)
 	}
 
 	// Create transaction with generated ID and timestamp
@@ -80,33 +105,141 @@ func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 		TransactionID: models.GenerateTransactionID(),
 		CustomerID:    req.CustomerID,
 		Type:          req.Type,
-		Amount:        req.Amount,
+		Amount:        amount,
+		Currency:      currency.Code,
 		Timestamp:     models.GenerateTimestamp(),
 	}
+	origin := audit.OriginFrom(auditContext(c))
+	transaction.Origin = &origin
+
+	// Claim the idempotency key, or answer from the request that already
+	// holds it. This comes before the account checks so that a retry gets the
+	// original result even if the account was closed since.
+	idempotencyKey := c.Get(IdempotencyKeyHeader)
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(errorResponse(models.ErrorCodeValidationFailed, "Idempotency-Key must be at most 255 characters"))
+		}
+
+		idempotencyKey = namespacedIdempotencyKey(principalOf(c), c.Get(ClientIDHeader), idempotencyKey)
+		fingerprint := transactionFingerprint(transaction.CustomerID, transaction.Type, transaction.Amount, transaction.Currency)
+		record, claimed, err := h.idempotency.ClaimIdempotencyKey(c.Context(), models.IdempotencyRecord{
+			Key:           idempotencyKey,
+			Fingerprint:   fingerprint,
+			Status:        models.IdempotencyStatusProcessing,
+			TransactionID: transaction.TransactionID,
+			CreatedAt:     transaction.Timestamp,
+			ExpiresAt:     transaction.Timestamp.Add(idempotencyKeyTTL),
+		})
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(errorResponse(models.ErrorCodeStorageUnavailable, "Failed to store idempotency key"))
+		}
+		if !claimed {
+			return h.respondToDuplicate(c, record, fingerprint)
+		}
+	}
+
+	// Check if customer exists and can still transact. A key claimed for a
+	// request rejected here is released, as nothing was posted.
+	customer, err := h.store.GetCustomer(c.Context(), req.CustomerID)
+	if err != nil {
+		h.releaseIdempotencyKey(idempotencyKey)
+		if errors.Is(err, store.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(errorResponse(models.ErrorCodeCustomerNotFound, "Customer not found"))
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(errorResponse(models.ErrorCodeStorageUnavailable, "Failed to check customer existence"))
+	}
+	if customer.IsClosed() {
+		h.releaseIdempotencyKey(idempotencyKey)
+		return c.Status(fiber.StatusConflict).JSON(errorResponse(models.ErrorCodeAccountClosed, models.ErrorCodeAccountClosed.Message()))
+	}
+
+	// Persist the transaction as pending so its progress can be polled
+	if err := h.store.SaveTransactionStatus(c.Context(), models.PendingStatus(transaction)); err != nil {
+		h.releaseIdempotencyKey(idempotencyKey)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(errorResponse(models.ErrorCodeStorageUnavailable, "Failed to record transaction"))
+	}
 
-	// Create a worker for this customer if one doesn't exist
-	worker := queue.NewWorker(
-		transaction.CustomerID,
-		h.queue,
-		h.customersCollection,
-		h.transactionsCollection,
-	)
-	worker.Start()
-	defer worker.Stop()
+	// Hand the transaction to its customer's worker
+	completion, err := h.dispatcher.Submit(transaction)
+	if err != nil {
+		h.store.SaveTransactionStatus(c.Context(), models.FailedStatus(transaction, models.ErrorCodeQueueUnavailable, "failed to queue transaction"))
+		h.releaseIdempotencyKey(idempotencyKey)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.TransactionStatusResponse{
+			TransactionID: transaction.TransactionID,
+			Status:        models.TransactionStatusFailed,
+			ErrorCode:     models.ErrorCodeQueueUnavailable,
+			FailureReason: models.ErrorCodeQueueUnavailable.Message(),
+		})
+	}
 
-	// Enqueue the transaction
-	h.queue.Enqueue(transaction)
+	if wantsAsync(c) {
+		accepted := models.TransactionStatusResponse{
+			TransactionID: transaction.TransactionID,
+			Status:        models.TransactionStatusPending,
+		}
+		// The key stays processing until the outcome is known, so replays get it
+		h.completeIdempotencyKeyWhenSettled(idempotencyKey, completion)
+		c.Set(fiber.HeaderLocation, "/transactions/"+transaction.TransactionID)
+		c.Set("Preference-Applied", "respond-async")
+		return c.Status(fiber.StatusAccepted).JSON(accepted)
+	}
 
 	// Wait for transaction completion with timeout
 	select {
-	case status := <-worker.GetCompletionChan():
-		return c.Status(fiber.StatusOK).JSON(status)
+	case status := <-completion:
+		statusCode := transactionStatusCode(status)
+		h.completeIdempotencyKey(idempotencyKey, statusCode, status)
+		return c.Status(statusCode).JSON(status)
 	case <-time.After(30 * time.Second):
-		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{Error: "Transaction processing timed out"})
+		// Keep listening so retries get the real outcome once it arrives
+		h.completeIdempotencyKeyWhenSettled(idempotencyKey, completion)
+		return c.Status(fiber.StatusRequestTimeout).JSON(errorResponse(models.ErrorCodeTimeout, "Transaction processing timed out; poll /transactions/"+transaction.TransactionID+" for the outcome"))
+	}
+}
+
+// GetTransaction handles retrieving the processing state of a transaction
+// @Summary Get transaction status
+// @Description Reports whether a submitted transaction is pending, completed or failed, with the error code and failure reason or resulting balance
+// @Tags transactions
+// @Produce json
+// @Param transaction_id path string true "Transaction ID"
+// @Success 200 {object} models.TransactionStatusRecord "Transaction status retrieved successfully"
+// @Failure 404 {object} models.ErrorResponse "Transaction not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transactions/{transaction_id} [get]
+func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
+	record, err := h.store.GetTransactionStatus(c.Context(), c.Params("transaction_id"))
+	if err == nil && !canAccessCustomer(c, record.CustomerID) {
+		// Another customer's transaction is reported as missing to not disclose that it exists
+		err = store.ErrTransactionNotFound
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrTransactionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Transaction not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to fetch transaction"})
+	}
+	return c.Status(fiber.StatusOK).JSON(record)
+}
+
+// wantsAsync reports whether the client asked for asynchronous processing
+func wantsAsync(c *fiber.Ctx) bool {
+	if strings.EqualFold(c.Query("mode"), "async") {
+		return true
+	}
+	for _, preference := range strings.Split(c.Get("Prefer"), ",") {
+		if strings.EqualFold(strings.TrimSpace(preference), "respond-async") {
+			return true
+		}
 	}
+	return false
 }
 
 // RegisterRoutes registers the transaction routes
 func (h *TransactionHandler) RegisterRoutes(app *fiber.App) {
 	app.Post("/transactions", h.CreateTransaction)
+	app.Get("/transactions/:transaction_id", h.GetTransaction)
 }
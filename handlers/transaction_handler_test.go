@@ -2,82 +2,92 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"ledger-service/auth"
+	"ledger-service/ledger"
 	"ledger-service/models"
 	"ledger-service/queue"
+	"ledger-service/store"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func setupTestDB(t *testing.T) (*mongo.Collection, *mongo.Collection) {
-	// Get the current directory and go back one level
+func setupTestStore(t *testing.T) *store.MemoryStore {
+	t.Helper()
+	return store.NewMemoryStore()
+}
+
+// setupMongoStore returns a store on an emptied test database of the cluster
+// in MONGO_CLUSTER, taken from the environment or the .env file one level
+// up. The test is skipped when no cluster is configured.
+func setupMongoStore(t *testing.T) *store.MongoStore {
+	t.Helper()
+
 	currentDir, err := os.Getwd()
 	if err != nil {
 		t.Fatalf("Failed to get current directory: %v", err)
 	}
-
-	// Go back one level to find the .env file
-	envPath := filepath.Join(filepath.Dir(currentDir), ".env")
-
-	if err := godotenv.Load(envPath); err != nil {
-		t.Fatalf("Error loading .env file from %s: %v", envPath, err)
-	}
+	godotenv.Load(filepath.Join(filepath.Dir(currentDir), ".env"))
 
 	databaseURL := os.Getenv("MONGO_CLUSTER")
 	if databaseURL == "" {
-		t.Fatal("MONGO_CLUSTER environment variable is not set in .env file")
+		t.Skip("MONGO_CLUSTER is not set")
 	}
 
-	clientOptions := options.Client().ApplyURI(databaseURL)
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(databaseURL))
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
-
-	// Test the connection
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	if err := client.Ping(ctx, nil); err != nil {
 		t.Fatalf("Failed to ping MongoDB: %v", err)
 	}
 
 	// Use a separate test database
-	db := client.Database("kryptovate_test")
-	customers := db.Collection("customers")
-	transactions := db.Collection("transactions")
-
-	// Clean up collections before tests
-	_, err = customers.DeleteMany(context.Background(), bson.M{})
-	if err != nil {
-		t.Fatalf("Failed to clean customers collection: %v", err)
+	db := client.Database("kryptovate_test_handlers")
+	if err := db.Drop(ctx); err != nil {
+		t.Fatalf("Failed to drop test database: %v", err)
 	}
-	_, err = transactions.DeleteMany(context.Background(), bson.M{})
-	if err != nil {
-		t.Fatalf("Failed to clean transactions collection: %v", err)
+	mongoStore := store.NewMongoStore(db)
+	if err := mongoStore.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("Failed to create indexes: %v", err)
 	}
-
-	return customers, transactions
+	return mongoStore
 }
 
 func TestCreateTransaction(t *testing.T) {
-	customers, transactions := setupTestDB(t)
-	transactionQueue := queue.NewTransactionQueue()
-	handler := NewTransactionHandler(transactionQueue, customers, transactions)
+	testCreateTransaction(t, setupTestStore(t))
+}
+
+func TestCreateTransactionMongo(t *testing.T) {
+	testCreateTransaction(t, setupMongoStore(t))
+}
+
+func testCreateTransaction(t *testing.T, ledgerStore store.Store) {
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
 
 	// Create a test customer
 	customer := models.Customer{
 		CustomerID: "test_customer",
-		Name:      "Test Customer",
-		Balance:   1000,
+		Name:       "Test Customer",
+		Balance:    models.MustParseMoney("1000"),
 	}
-	_, err := customers.InsertOne(context.Background(), customer)
+	err := ledgerStore.CreateCustomer(context.Background(), customer)
 	if err != nil {
 		t.Fatalf("Failed to create test customer: %v", err)
 	}
@@ -89,6 +99,7 @@ func TestCreateTransaction(t *testing.T) {
 		name           string
 		requestBody    string
 		expectedStatus int
+		expectedCode   models.ErrorCode
 	}{
 		{
 			name:           "valid credit transaction",
@@ -110,10 +121,44 @@ func TestCreateTransaction(t *testing.T) {
 			requestBody:    `{"customer_id": "test_customer", "type": "credit", "amount": -100}`,
 			expectedStatus: fiber.StatusBadRequest,
 		},
+		{
+			name:           "too many decimal places",
+			requestBody:    `{"customer_id": "test_customer", "type": "credit", "amount": 10.005}`,
+			expectedStatus: fiber.StatusBadRequest,
+		},
 		{
 			name:           "non-existent customer",
 			requestBody:    `{"customer_id": "non_existent", "type": "credit", "amount": 100}`,
 			expectedStatus: fiber.StatusNotFound,
+			expectedCode:   models.ErrorCodeCustomerNotFound,
+		},
+		{
+			name:           "insufficient funds",
+			requestBody:    `{"customer_id": "test_customer", "type": "debit", "amount": 5000}`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedCode:   models.ErrorCodeInsufficientFunds,
+		},
+		{
+			name:           "credit in another currency",
+			requestBody:    `{"customer_id": "test_customer", "type": "credit", "amount": 100, "currency": "eur"}`,
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "debit beyond the balance in that currency",
+			requestBody:    `{"customer_id": "test_customer", "type": "debit", "amount": 200, "currency": "EUR"}`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedCode:   models.ErrorCodeInsufficientFunds,
+		},
+		{
+			name:           "unsupported currency",
+			requestBody:    `{"customer_id": "test_customer", "type": "credit", "amount": 100, "currency": "XYZ"}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedCode:   models.ErrorCodeUnsupportedCurrency,
+		},
+		{
+			name:           "amount finer than the currency allows",
+			requestBody:    `{"customer_id": "test_customer", "type": "credit", "amount": 10.5, "currency": "JPY"}`,
+			expectedStatus: fiber.StatusBadRequest,
 		},
 	}
 
@@ -130,6 +175,319 @@ func TestCreateTransaction(t *testing.T) {
 			if resp.StatusCode != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
+
+			if tt.expectedCode != "" {
+				var body struct {
+					Code      models.ErrorCode `json:"code"`
+					ErrorCode models.ErrorCode `json:"error_code"`
+				}
+				json.NewDecoder(resp.Body).Decode(&body)
+				if body.Code != tt.expectedCode && body.ErrorCode != tt.expectedCode {
+					t.Errorf("Expected error code %s, got %+v", tt.expectedCode, body)
+				}
+			}
 		})
 	}
-} 
\ No newline at end of file
+}
+func TestCreateTransactionIdempotency(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
+
+	customer := models.Customer{
+		CustomerID: "test_customer",
+		Name:       "Test Customer",
+		Balance:    models.MustParseMoney("1000"),
+	}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/transactions", handler.CreateTransaction)
+
+	post := func(key, body string, clientID ...string) (int, models.TransactionStatusResponse, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/transactions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, key)
+		if len(clientID) > 0 {
+			req.Header.Set(ClientIDHeader, clientID[0])
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var status models.TransactionStatusResponse
+		json.NewDecoder(resp.Body).Decode(&status)
+		return resp.StatusCode, status, resp.Header.Get(IdempotentReplayedHeader)
+	}
+
+	body := `{"customer_id": "test_customer", "type": "debit", "amount": 100}`
+	code, first, _ := post("key-1", body)
+	if code != fiber.StatusOK || first.Status != "completed" {
+		t.Fatalf("First request returned %d %+v", code, first)
+	}
+
+	code, replay, replayed := post("key-1", body)
+	if code != fiber.StatusOK || replayed != "true" {
+		t.Errorf("Replay returned status %d, replayed header %q", code, replayed)
+	}
+	if replay.TransactionID != first.TransactionID || !replay.Balance.Equal(first.Balance) {
+		t.Errorf("Replay returned %+v, want %+v", replay, first)
+	}
+
+	stored, _ := ledgerStore.GetCustomer(context.Background(), "test_customer")
+	if !stored.Balance.Equal(models.MustParseMoney("900")) {
+		t.Errorf("Balance = %s, want 900 after a single debit", stored.Balance)
+	}
+
+	code, _, _ = post("key-1", `{"customer_id": "test_customer", "type": "debit", "amount": 200}`)
+	if code != fiber.StatusConflict {
+		t.Errorf("Reused key with a different body returned %d, want %d", code, fiber.StatusConflict)
+	}
+
+	// A request that never finished leaves its key in the processing state
+	idempotencyWaitTimeout = 200 * time.Millisecond
+	fingerprint := transactionFingerprint("test_customer", "credit", models.MustParseMoney("5.00"), models.DefaultCurrency)
+	ledgerStore.ClaimIdempotencyKey(context.Background(), models.IdempotencyRecord{
+		Key:           namespacedIdempotencyKey(auth.Principal{}, "", "key-2"),
+		Fingerprint:   fingerprint,
+		Status:        models.IdempotencyStatusProcessing,
+		TransactionID: "in-flight",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	code, pending, _ := post("key-2", `{"customer_id": "test_customer", "type": "credit", "amount": 5}`)
+	if code != fiber.StatusAccepted || pending.Status != "processing" || pending.TransactionID != "in-flight" {
+		t.Errorf("In-flight duplicate returned %d %+v", code, pending)
+	}
+
+	// Keys are per client, so another client choosing the same key gets its own transaction
+	code, mine, _ := post("key-3", body, "client-a")
+	if code != fiber.StatusOK {
+		t.Fatalf("First client's request returned %d", code)
+	}
+	code, theirs, replayed := post("key-3", `{"customer_id": "test_customer", "type": "debit", "amount": 1}`, "client-b")
+	if code != fiber.StatusOK || replayed != "" || theirs.TransactionID == mine.TransactionID {
+		t.Errorf("Second client's request with the same key returned %d %+v, replayed header %q", code, theirs, replayed)
+	}
+
+	// A replay gets the original result even after the account was closed
+	ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "closing_customer", Balance: models.MustParseMoney("100")})
+	closingBody := `{"customer_id": "closing_customer", "type": "debit", "amount": 100}`
+	code, emptied, _ := post("key-4", closingBody)
+	if code != fiber.StatusOK {
+		t.Fatalf("Emptying the account returned %d %+v", code, emptied)
+	}
+	if _, err := ledger.ChangeAccountStatus(context.Background(), ledgerStore, "closing_customer", models.CustomerStatusClosed, "test", "tester"); err != nil {
+		t.Fatalf("Failed to close the account: %v", err)
+	}
+	code, replay, replayed = post("key-4", closingBody)
+	if code != fiber.StatusOK || replayed != "true" || replay.TransactionID != emptied.TransactionID {
+		t.Errorf("Replay after closing the account returned %d %+v, replayed header %q", code, replay, replayed)
+	}
+	code, _, _ = post("key-5", closingBody)
+	if code != fiber.StatusConflict {
+		t.Errorf("New request to the closed account returned %d, want %d", code, fiber.StatusConflict)
+	}
+}
+
+func TestNamespacedIdempotencyKey(t *testing.T) {
+	// Each caller sends the same key, or one that shifts its ID into the key
+	callers := []struct {
+		name      string
+		principal auth.Principal
+		clientID  string
+		key       string
+	}{
+		{"api key", auth.Principal{Kind: auth.PrincipalAPIKey, ID: "k1"}, "", "key"},
+		{"token of the same ID", auth.Principal{Kind: auth.PrincipalToken, ID: "k1"}, "", "key"},
+		{"token with a slash", auth.Principal{Kind: auth.PrincipalToken, ID: "k1/key"}, "", ""},
+		{"client of the same ID", auth.Principal{}, "k1", "key"},
+		{"anonymous client", auth.Principal{}, "", "k1/key"},
+	}
+	seen := make(map[string]string)
+	for _, caller := range callers {
+		key := namespacedIdempotencyKey(caller.principal, caller.clientID, caller.key)
+		if other, ok := seen[key]; ok {
+			t.Errorf("%s and %s share the stored key %q", caller.name, other, key)
+		}
+		seen[key] = caller.name
+	}
+}
+
+// statusDownStore fails to save transaction statuses
+type statusDownStore struct {
+	*store.MemoryStore
+}
+
+func (s statusDownStore) SaveTransactionStatus(ctx context.Context, record models.TransactionStatusRecord) error {
+	return errors.New("storage unavailable")
+}
+
+func TestCreateTransactionReleasesKeyWhenNotRecorded(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewTransactionHandler(dispatcher, statusDownStore{ledgerStore}, ledgerStore)
+
+	if err := ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "test_customer", Balance: models.MustParseMoney("1000")}); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/transactions", handler.CreateTransaction)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/transactions", strings.NewReader(`{"customer_id": "test_customer", "type": "credit", "amount": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	// Nothing was queued, so a retry must be free to claim the key again
+	_, err = ledgerStore.GetIdempotencyRecord(context.Background(), namespacedIdempotencyKey(auth.Principal{}, "", "key-1"))
+	if !errors.Is(err, store.ErrIdempotencyKeyNotFound) {
+		t.Errorf("GetIdempotencyRecord() error = %v, want %v", err, store.ErrIdempotencyKeyNotFound)
+	}
+}
+
+func TestCreateTransactionAfterShutdown(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	dispatcher.Stop()
+	handler := NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
+
+	if err := ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "test_customer", Balance: models.MustParseMoney("1000")}); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/transactions", handler.CreateTransaction)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/transactions", strings.NewReader(`{"customer_id": "test_customer", "type": "credit", "amount": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var status models.TransactionStatusResponse
+	json.NewDecoder(resp.Body).Decode(&status)
+	if resp.StatusCode != fiber.StatusServiceUnavailable || status.ErrorCode != models.ErrorCodeQueueUnavailable {
+		t.Errorf("Request during shutdown returned %d %+v, want %d with %s", resp.StatusCode, status, fiber.StatusServiceUnavailable, models.ErrorCodeQueueUnavailable)
+	}
+	_, err = ledgerStore.GetIdempotencyRecord(context.Background(), namespacedIdempotencyKey(auth.Principal{}, "", "key-1"))
+	if !errors.Is(err, store.ErrIdempotencyKeyNotFound) {
+		t.Errorf("GetIdempotencyRecord() error = %v, want %v", err, store.ErrIdempotencyKeyNotFound)
+	}
+}
+
+func TestCreateTransactionAsync(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	dispatcher := queue.NewDispatcher(ledgerStore, queue.NewTransactionQueue(), queue.DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	handler := NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
+
+	customer := models.Customer{
+		CustomerID: "test_customer",
+		Name:       "Test Customer",
+		Balance:    models.MustParseMoney("100"),
+	}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	submit := func(target, prefer, body string) models.TransactionStatusResponse {
+		req := httptest.NewRequest(fiber.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if prefer != "" {
+			req.Header.Set("Prefer", prefer)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d", fiber.StatusAccepted, resp.StatusCode)
+		}
+		var accepted models.TransactionStatusResponse
+		json.NewDecoder(resp.Body).Decode(&accepted)
+		if accepted.Status != "pending" || resp.Header.Get(fiber.HeaderLocation) != "/transactions/"+accepted.TransactionID {
+			t.Errorf("Accepted response %+v with Location %q", accepted, resp.Header.Get(fiber.HeaderLocation))
+		}
+		return accepted
+	}
+
+	poll := func(transactionID string) models.TransactionStatusRecord {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/transactions/"+transactionID, nil))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			var record models.TransactionStatusRecord
+			json.NewDecoder(resp.Body).Decode(&record)
+			if record.Status != "pending" || time.Now().After(deadline) {
+				return record
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	credit := submit("/transactions?mode=async", "", `{"customer_id": "test_customer", "type": "credit", "amount": 25}`)
+	record := poll(credit.TransactionID)
+	if record.Status != "completed" || record.Balance == nil || !record.Balance.Equal(models.MustParseMoney("125")) {
+		t.Errorf("Completed record = %+v, want completed with balance 125", record)
+	}
+
+	debit := submit("/transactions", "respond-async", `{"customer_id": "test_customer", "type": "debit", "amount": 500}`)
+	record = poll(debit.TransactionID)
+	if record.Status != "failed" || record.ErrorCode != models.ErrorCodeInsufficientFunds || record.FailureReason == "" || record.Balance != nil {
+		t.Errorf("Failed record = %+v, want failed with INSUFFICIENT_FUNDS and a reason", record)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/transactions/unknown", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Unknown transaction returned %d, want %d", resp.StatusCode, fiber.StatusNotFound)
+	}
+
+	// A replay of an asynchronous request gets the outcome once it is known
+	keyed := func() *http.Response {
+		req := httptest.NewRequest(fiber.MethodPost, "/transactions?mode=async", strings.NewReader(`{"customer_id": "test_customer", "type": "credit", "amount": 10}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "async-key")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+	resp = keyed()
+	var accepted models.TransactionStatusResponse
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("Keyed request returned %d, want %d", resp.StatusCode, fiber.StatusAccepted)
+	}
+	poll(accepted.TransactionID)
+	resp = keyed()
+	var replay models.TransactionStatusResponse
+	json.NewDecoder(resp.Body).Decode(&replay)
+	if resp.StatusCode != fiber.StatusOK || replay.Status != "completed" || replay.TransactionID != accepted.TransactionID || !replay.Balance.Equal(models.MustParseMoney("135")) {
+		t.Errorf("Replay returned %d %+v, want the completed transaction with balance 135", resp.StatusCode, replay)
+	}
+}
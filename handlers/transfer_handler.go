@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TransferHandler handles customer-to-customer transfers
+type TransferHandler struct {
+	store  store.LedgerStore
+	policy ledger.Policy
+}
+
+// NewTransferHandler creates a new transfer handler that posts with policy
+func NewTransferHandler(ledgerStore store.LedgerStore, policy ledger.Policy) *TransferHandler {
+	return &TransferHandler{
+		store:  ledgerStore,
+		policy: policy,
+	}
+}
+
+// CreateTransferRequest represents the request body for creating a transfer
+type CreateTransferRequest struct {
+	FromCustomerID string       `json:"from_customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ToCustomerID   string       `json:"to_customer_id" example:"ef48ae68-182f-4f2f-bb62-8a0016a9ca94"`
+	Amount         models.Money `json:"amount" swaggertype:"number" example:"25"`
+	Currency       string       `json:"currency,omitempty" example:"USD"`
+}
+
+// CreateTransfer handles moving funds between two customers
+// @Summary Transfer funds between customers
+// @Description Debits the source customer and credits the destination customer atomically, in one currency that defaults to USD. Both legs share the transfer ID and appear in each customer's transaction history.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param transfer body CreateTransferRequest true "Transfer details"
+// @Success 200 {object} models.TransferResponse "Transfer completed successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Token of a customer other than the source customer"
+// @Failure 404 {object} models.ErrorResponse "Customer not found"
+// @Failure 409 {object} models.ErrorResponse "Account is frozen or closed"
+// @Failure 422 {object} models.ErrorResponse "Insufficient funds"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers [post]
+func (h *TransferHandler) CreateTransfer(c *fiber.Ctx) error {
+	var req CreateTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if req.FromCustomerID == "" || req.ToCustomerID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Both from_customer_id and to_customer_id are required"})
+	}
+	if req.FromCustomerID == req.ToCustomerID {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Cannot transfer to the same customer"})
+	}
+	// Customers may send funds to anyone, but only from their own account
+	if !canAccessCustomer(c, req.FromCustomerID) {
+		return customerAccessDenied(c)
+	}
+
+	// Validate amount
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Amount must be greater than 0"})
+	}
+
+	currency, err := requestCurrency(req.Currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	amount, err := req.Amount.InCurrency(currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "Amount has more decimal places than " + currency.Code + " allows"})
+	}
+
+	transfer := models.Transfer{
+		TransferID:     models.GenerateTransferID(),
+		FromCustomerID: req.FromCustomerID,
+		ToCustomerID:   req.ToCustomerID,
+		Amount:         amount,
+		Currency:       currency.Code,
+		Timestamp:      models.GenerateTimestamp(),
+	}
+
+	debit, credit, balance, err := ledger.ExecuteTransfer(auditContext(c), h.store, transfer, h.policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrCustomerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "Customer not found"})
+		case errors.Is(err, models.ErrInsufficientFunds):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: "Insufficient funds"})
+		case errors.Is(err, models.ErrMoneyOverflow):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrAccountClosed):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Account is closed"})
+		case errors.Is(err, models.ErrAccountFrozen):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: "Account is frozen"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Failed to process transfer"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.TransferResponse{
+		TransferID:          transfer.TransferID,
+		Status:              "completed",
+		FromCustomerID:      transfer.FromCustomerID,
+		ToCustomerID:        transfer.ToCustomerID,
+		Amount:              transfer.Amount,
+		Currency:            transfer.Currency,
+		DebitTransactionID:  debit.TransactionID,
+		CreditTransactionID: credit.TransactionID,
+		Balance:             balance,
+		Timestamp:           transfer.Timestamp.Format(time.RFC3339),
+	})
+}
+
+// RegisterRoutes registers the transfer routes
+func (h *TransferHandler) RegisterRoutes(app *fiber.App) {
+	app.Post("/transfers", h.CreateTransfer)
+}
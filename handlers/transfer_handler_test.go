@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	ledgerStore := setupTestStore(t)
+	handler := NewTransferHandler(ledgerStore, ledger.DefaultPolicy)
+
+	for _, id := range []string{"source", "destination"} {
+		customer := models.Customer{CustomerID: id, Name: id, Balance: models.MustParseMoney("100")}
+		if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+			t.Fatalf("Failed to create test customer: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	app.Post("/transfers", handler.CreateTransfer)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+	}{
+		{
+			name:           "valid transfer",
+			requestBody:    `{"from_customer_id": "source", "to_customer_id": "destination", "amount": 40}`,
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "insufficient funds",
+			requestBody:    `{"from_customer_id": "source", "to_customer_id": "destination", "amount": 500}`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:           "same customer",
+			requestBody:    `{"from_customer_id": "source", "to_customer_id": "source", "amount": 1}`,
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "non-existent destination",
+			requestBody:    `{"from_customer_id": "source", "to_customer_id": "missing", "amount": 1}`,
+			expectedStatus: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/transfers", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	source, _ := ledgerStore.GetCustomer(context.Background(), "source")
+	destination, _ := ledgerStore.GetCustomer(context.Background(), "destination")
+	if !source.Balance.Equal(models.MustParseMoney("60")) || !destination.Balance.Equal(models.MustParseMoney("140")) {
+		t.Errorf("Balances after transfers = %s and %s, want 60 and 140", source.Balance, destination.Balance)
+	}
+}
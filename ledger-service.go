@@ -4,17 +4,25 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"github.com/joho/godotenv"
 	"log"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 
+	"ledger-service/auth"
+	_ "ledger-service/docs" // This is required for swagger
 	"ledger-service/handlers"
+	"ledger-service/ledger"
+	"ledger-service/models"
 	"ledger-service/queue"
-	_ "ledger-service/docs" // This is required for swagger
+	"ledger-service/store"
 
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 )
@@ -32,43 +40,204 @@ import (
 // @BasePath  /
 // @schemes   http
 
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 JWT bearer token, sent as "Bearer <token>"
+
 func main() {
 	app := fiber.New()
-	 app.Use(cors.New(cors.Config{
-			AllowOrigins: "*", // For testing, be more specific in production
-			AllowMethods: "GET,POST,PUT,DELETE",
-			AllowHeaders: "Origin, Content-Type, Accept",
-	}))
 	err := godotenv.Load()
 
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Println("No .env file found, using process environment")
 	}
 
-	databaseURL := os.Getenv("MONGO_CLUSTER")
+	// Browsers may only call the API from the origins CORS_ALLOW_ORIGINS lists;
+	// without it no CORS headers are sent, so cross-origin calls are refused
+	if allowOrigins := os.Getenv("CORS_ALLOW_ORIGINS"); allowOrigins != "" {
+		app.Use(cors.New(cors.Config{
+			AllowOrigins: allowOrigins,
+			AllowMethods: "GET,POST,PUT,DELETE",
+			AllowHeaders: "Origin, Content-Type, Accept, Idempotency-Key, Prefer, X-API-Key, Authorization, X-Client-ID, X-Signature-Timestamp, X-Signature-Nonce, X-Signature, X-Request-ID",
+		}))
+	}
 
-	clientOptions := options.Client().ApplyURI(databaseURL)
-	client, err := mongo.Connect(context.Background(), clientOptions)
-	if err != nil {
-		log.Fatal(err)
+	// Tag every request with an ID, the caller's X-Request-ID if it sent one,
+	// which is echoed back and recorded in the audit log
+	app.Use(requestid.New(requestid.Config{ContextKey: handlers.RequestIDLocal}))
+
+	// Select the storage backend
+	var ledgerStore store.Store
+	if os.Getenv("STORAGE_BACKEND") == "memory" {
+		ledgerStore = store.NewMemoryStore()
+		fmt.Println("Using in-memory storage")
+	} else {
+		databaseURL := os.Getenv("MONGO_CLUSTER")
+
+		clientOptions := options.Client().ApplyURI(databaseURL)
+		client, err := mongo.Connect(context.Background(), clientOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Test the connection
+		err = client.Ping(context.Background(), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		mongoStore := store.NewMongoStore(client.Database("kryptovate"))
+		if err := mongoStore.EnsureIndexes(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		ledgerStore = mongoStore
+		fmt.Println("Connected to MongoDB!")
 	}
 
-	// Test the connection
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
-		log.Fatal(err)
+	// Initialize the intake queue, durable when a log path is configured
+	var intake queue.Queue = queue.NewTransactionQueue()
+	if queueLogPath := os.Getenv("QUEUE_LOG_PATH"); queueLogPath != "" {
+		fileQueue, err := queue.OpenFileQueue(queueLogPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fileQueue.Close()
+		intake = fileQueue
+		fmt.Printf("Replaying %d unacknowledged transactions from %s\n", fileQueue.Unacknowledged(), queueLogPath)
 	}
 
-	// Get collections
-	customersCollection := client.Database("kryptovate").Collection("customers")
-	transactionsCollection := client.Database("kryptovate").Collection("transactions")
+	// Frozen accounts accept credits unless FROZEN_ACCEPTS_CREDITS=false
+	postingPolicy := ledger.DefaultPolicy
+	if raw := os.Getenv("FROZEN_ACCEPTS_CREDITS"); raw != "" {
+		acceptsCredits, err := strconv.ParseBool(raw)
+		if err != nil {
+			log.Fatalf("invalid FROZEN_ACCEPTS_CREDITS %q", raw)
+		}
+		postingPolicy.FrozenAcceptsCredits = acceptsCredits
+	}
+
+	// Initialize the per-customer transaction dispatcher
+	dispatcher := queue.NewDispatcher(ledgerStore, intake, queue.DefaultIdleTimeout)
+	dispatcher.SetRetryPolicy(retryPolicyFromEnv())
+	dispatcher.SetPostingPolicy(postingPolicy)
+	dispatcher.SetDeadLetterStore(ledgerStore)
+	dispatcher.Start()
+	defer dispatcher.Stop()
 
-	// Initialize transaction queue
-	transactionQueue := queue.NewTransactionQueue()
+	// Release the funds of holds that were neither captured nor voided in time
+	holdExpiryInterval := ledger.DefaultHoldExpiryInterval
+	if raw := os.Getenv("HOLD_EXPIRY_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			log.Fatalf("invalid HOLD_EXPIRY_INTERVAL %q", raw)
+		}
+		holdExpiryInterval = interval
+	}
+	holdExpirer := ledger.NewHoldExpirer(ledgerStore, holdExpiryInterval)
+	holdExpirer.Start()
+	defer holdExpirer.Stop()
+
+	// Load the exchange rate table and configure how long quotes lock a rate
+	if ratesPath := os.Getenv("FX_RATES_FILE"); ratesPath != "" {
+		loaded, err := ledger.LoadExchangeRates(context.Background(), ledgerStore, ratesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Loaded %d exchange rates from %s\n", loaded, ratesPath)
+	}
+	quoteTTL := ledger.DefaultQuoteTTL
+	if raw := os.Getenv("FX_QUOTE_TTL"); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("invalid FX_QUOTE_TTL %q", raw)
+		}
+		quoteTTL = ttl
+	}
+
+	// Require an API key or a JWT bearer token on every route but the health
+	// check and the docs, unless AUTH_DISABLED=true. BOOTSTRAP_API_KEY
+	// provisions an admin key that can create the others.
+	if bootstrapKey := os.Getenv("BOOTSTRAP_API_KEY"); bootstrapKey != "" {
+		if _, err := auth.RegisterAPIKey(context.Background(), ledgerStore, "bootstrap", bootstrapKey, []string{models.ScopeAdmin}); err != nil {
+			log.Fatalf("invalid BOOTSTRAP_API_KEY: %v", err)
+		}
+	}
+	authDisabled := false
+	if raw := os.Getenv("AUTH_DISABLED"); raw != "" {
+		authDisabled, err = strconv.ParseBool(raw)
+		if err != nil {
+			log.Fatalf("invalid AUTH_DISABLED %q", raw)
+		}
+	}
+	if authDisabled {
+		log.Println("Authentication is disabled; every route is open")
+	} else {
+		authenticator := handlers.NewAuthenticator(ledgerStore)
+		// Bearer tokens are accepted once they can be verified with an HS256
+		// secret or the public keys of a JWKS file
+		tokenConfig := auth.TokenConfig{
+			HMACSecret: []byte(os.Getenv("JWT_HS256_SECRET")),
+			JWKSPath:   os.Getenv("JWT_JWKS_FILE"),
+			Issuer:     os.Getenv("JWT_ISSUER"),
+			Audience:   os.Getenv("JWT_AUDIENCE"),
+		}
+		if len(tokenConfig.HMACSecret) > 0 || tokenConfig.JWKSPath != "" {
+			verifier, err := auth.NewTokenVerifier(tokenConfig)
+			if err != nil {
+				log.Fatalf("invalid JWT configuration: %v", err)
+			}
+			authenticator.SetTokenVerifier(verifier)
+		}
+		app.Use(authenticator.Handle)
+	}
+
+	// Require HMAC-signed requests from the clients of SIGNING_CLIENTS_FILE on
+	// the route groups of SIGNED_ROUTES, /transactions by default
+	if clientsPath := os.Getenv("SIGNING_CLIENTS_FILE"); clientsPath != "" {
+		clients, err := auth.LoadSigningClients(clientsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		window := auth.DefaultSignatureWindow
+		if raw := os.Getenv("SIGNATURE_WINDOW"); raw != "" {
+			window, err = time.ParseDuration(raw)
+			if err != nil || window <= 0 {
+				log.Fatalf("invalid SIGNATURE_WINDOW %q", raw)
+			}
+		}
+		verifier, err := auth.NewRequestVerifier(clients, ledgerStore, window)
+		if err != nil {
+			log.Fatalf("invalid SIGNING_CLIENTS_FILE: %v", err)
+		}
+		signatureChecker := handlers.NewSignatureChecker(verifier)
+		signedRoutes := "/transactions"
+		if raw := os.Getenv("SIGNED_ROUTES"); raw != "" {
+			signedRoutes = raw
+		}
+		for _, prefix := range strings.Split(signedRoutes, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				app.Use(prefix, signatureChecker.Handle)
+			}
+		}
+		fmt.Printf("Requiring signed requests from %d clients on %s\n", len(clients), signedRoutes)
+	}
 
 	// Initialize route handlers
-	customersHandler := handlers.NewCustomerHandler(customersCollection, transactionsCollection)
-	transactionsHandler := handlers.NewTransactionHandler(transactionQueue, customersCollection, transactionsCollection)
+	customersHandler := handlers.NewCustomerHandler(ledgerStore)
+	transactionsHandler := handlers.NewTransactionHandler(dispatcher, ledgerStore, ledgerStore)
+	transfersHandler := handlers.NewTransferHandler(ledgerStore, postingPolicy)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerStore, ledgerStore)
+	adminHandler := handlers.NewAdminHandler(dispatcher, ledgerStore, ledgerStore, ledgerStore)
+	holdsHandler := handlers.NewHoldHandler(ledgerStore, postingPolicy)
+	reversalsHandler := handlers.NewReversalHandler(ledgerStore, postingPolicy)
+	fxHandler := handlers.NewFXHandler(ledgerStore, ledgerStore, ledgerStore, postingPolicy, quoteTTL)
+	apiKeysHandler := handlers.NewAPIKeyHandler(ledgerStore, ledgerStore)
+	auditHandler := handlers.NewAuditHandler(ledgerStore)
 
 	// Swagger configuration
 	// app.Get("/swagger/*", swagger.New(swagger.Config{
@@ -81,6 +250,14 @@ func main() {
 	// Register routes
 	customersHandler.RegisterRoutes(app)
 	transactionsHandler.RegisterRoutes(app)
+	transfersHandler.RegisterRoutes(app)
+	ledgerHandler.RegisterRoutes(app)
+	adminHandler.RegisterRoutes(app)
+	holdsHandler.RegisterRoutes(app)
+	reversalsHandler.RegisterRoutes(app)
+	fxHandler.RegisterRoutes(app)
+	apiKeysHandler.RegisterRoutes(app)
+	auditHandler.RegisterRoutes(app)
 
 	// Health Check Route
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -89,6 +266,33 @@ func main() {
 		})
 	})
 
-	fmt.Println("Connected to MongoDB!")
 	app.Listen(":3005")
 }
+
+// retryPolicyFromEnv returns the default retry policy with any overrides
+// from RETRY_MAX_ATTEMPTS, RETRY_BASE_DELAY and RETRY_MAX_DELAY
+func retryPolicyFromEnv() queue.RetryPolicy {
+	policy := queue.DefaultRetryPolicy
+	if raw := os.Getenv("RETRY_MAX_ATTEMPTS"); raw != "" {
+		attempts, err := strconv.Atoi(raw)
+		if err != nil || attempts < 1 {
+			log.Fatalf("invalid RETRY_MAX_ATTEMPTS %q", raw)
+		}
+		policy.MaxAttempts = attempts
+	}
+	if raw := os.Getenv("RETRY_BASE_DELAY"); raw != "" {
+		delay, err := time.ParseDuration(raw)
+		if err != nil || delay < 0 {
+			log.Fatalf("invalid RETRY_BASE_DELAY %q", raw)
+		}
+		policy.BaseDelay = delay
+	}
+	if raw := os.Getenv("RETRY_MAX_DELAY"); raw != "" {
+		delay, err := time.ParseDuration(raw)
+		if err != nil || delay < 0 {
+			log.Fatalf("invalid RETRY_MAX_DELAY %q", raw)
+		}
+		policy.MaxDelay = delay
+	}
+	return policy
+}
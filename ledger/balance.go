@@ -0,0 +1,101 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/models"
+	"ledger-service/store"
+	"sort"
+	"time"
+)
+
+// PointInTimeBalance is a customer's balance as of a moment in the past
+type PointInTimeBalance struct {
+	Balance models.Money
+	// LastTransactionID is the latest transaction included in Balance, or empty
+	// if no transaction had been posted by then
+	LastTransactionID string
+}
+
+// BalanceAsOf returns a customer's balance in currency after every
+// transaction in that currency timestamped at or before asOf. It is read from
+// the balance snapshot of the last such transaction. Transactions posted
+// before snapshots existed have none; the balance is then derived by
+// unwinding the later transactions from the next snapshot or, failing that,
+// from the current balance. An empty currency means the default currency.
+func BalanceAsOf(ctx context.Context, ledgerStore store.LedgerStore, customerID, currency string, asOf time.Time) (PointInTimeBalance, error) {
+	currency = models.CurrencyOrDefault(currency)
+	customer, err := ledgerStore.GetCustomer(ctx, customerID)
+	if err != nil {
+		return PointInTimeBalance{}, err
+	}
+
+	// Query bounds are exclusive at the end and inclusive at the start
+	end := asOf.Add(time.Nanosecond)
+	included, err := ledgerStore.QueryTransactions(ctx, store.TransactionQuery{
+		CustomerID: customerID,
+		Currency:   currency,
+		To:         end,
+		Descending: true,
+		Limit:      1,
+	})
+	if err != nil {
+		return PointInTimeBalance{}, err
+	}
+
+	if len(included) > 0 {
+		// Transactions sharing the latest timestamp were posted in sequence order
+		included, err = ledgerStore.QueryTransactions(ctx, store.TransactionQuery{
+			CustomerID: customerID,
+			Currency:   currency,
+			From:       included[0].Timestamp,
+			To:         end,
+		})
+		if err != nil {
+			return PointInTimeBalance{}, err
+		}
+		sortBySequence(included)
+		included = included[len(included)-1:]
+	}
+
+	var result PointInTimeBalance
+	if len(included) > 0 {
+		result.LastTransactionID = included[0].TransactionID
+		if included[0].BalanceAfter != nil {
+			result.Balance = *included[0].BalanceAfter
+			return result, nil
+		}
+	}
+
+	later, err := ledgerStore.QueryTransactions(ctx, store.TransactionQuery{CustomerID: customerID, Currency: currency, From: end})
+	if err != nil {
+		return PointInTimeBalance{}, err
+	}
+	sortBySequence(later)
+	anchor := customer.BalanceIn(currency).Balance
+	var unwind models.Money
+	for _, t := range later {
+		if t.BalanceBefore != nil {
+			anchor = *t.BalanceBefore
+			break
+		}
+		if unwind, err = t.CalculateNewBalance(unwind); err != nil {
+			return PointInTimeBalance{}, err
+		}
+	}
+	if result.Balance, err = anchor.CheckedSub(unwind); err != nil {
+		return PointInTimeBalance{}, err
+	}
+	return result, nil
+}
+
+// sortBySequence orders transactions of one customer by timestamp and those
+// sharing a timestamp in the order they were posted
+func sortBySequence(transactions []models.Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.Sequence < b.Sequence
+	})
+}
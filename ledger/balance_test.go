@@ -0,0 +1,113 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"testing"
+	"time"
+)
+
+func TestBalanceAsOf(t *testing.T) {
+	s := setupTestStore(t)
+	day := time.Date(2025, 4, 6, 0, 0, 0, 0, time.UTC)
+
+	// Transactions posted before balance snapshots existed carry none
+	legacy := func(id, customerID string, amount string, at time.Time, balance string) {
+		err := s.WithTransaction(context.Background(), func(tx store.Tx) error {
+			if err := tx.UpdateBalance(customerID, models.DefaultCurrency, models.MustParseMoney(balance), 0, at); err != nil {
+				return err
+			}
+			return tx.InsertTransaction(models.Transaction{TransactionID: id, CustomerID: customerID, Type: "credit", Amount: models.MustParseMoney(amount), Timestamp: at})
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert legacy transaction %s: %v", id, err)
+		}
+	}
+	legacy("t1", "alice", "10", day.Add(9*time.Hour), "110")
+	legacy("b1", "bob", "5", day.Add(9*time.Hour), "15")
+	for _, p := range []models.Transaction{
+		{TransactionID: "t2", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("20"), Timestamp: day.Add(10 * time.Hour)},
+		{TransactionID: "t3", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("5"), Timestamp: day.Add(11 * time.Hour)},
+	} {
+		if err := post(t, s, p); err != nil {
+			t.Fatalf("Posting %s failed: %v", p.TransactionID, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		customerID string
+		asOf       time.Time
+		wantBal    string
+		wantLastID string
+	}{
+		{"before any transaction", "alice", day.Add(8 * time.Hour), "100", ""},
+		{"at a legacy transaction", "alice", day.Add(9 * time.Hour), "110", "t1"},
+		{"between legacy and snapshot", "alice", day.Add(9*time.Hour + 30*time.Minute), "110", "t1"},
+		{"at a snapshot", "alice", day.Add(10 * time.Hour), "90", "t2"},
+		{"after the latest transaction", "alice", day.Add(24 * time.Hour), "95", "t3"},
+		{"only legacy transactions", "bob", day.Add(8 * time.Hour), "10", ""},
+		{"after only legacy transactions", "bob", day.Add(24 * time.Hour), "15", "b1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BalanceAsOf(context.Background(), s, tt.customerID, models.DefaultCurrency, tt.asOf)
+			if err != nil {
+				t.Fatalf("BalanceAsOf() error = %v", err)
+			}
+			if !got.Balance.Equal(models.MustParseMoney(tt.wantBal)) || got.LastTransactionID != tt.wantLastID {
+				t.Errorf("BalanceAsOf() = %s after %q, want %s after %q", got.Balance, got.LastTransactionID, tt.wantBal, tt.wantLastID)
+			}
+		})
+	}
+
+	if _, err := BalanceAsOf(context.Background(), s, "missing", models.DefaultCurrency, day); !errors.Is(err, store.ErrCustomerNotFound) {
+		t.Errorf("BalanceAsOf() of missing customer error = %v, want %v", err, store.ErrCustomerNotFound)
+	}
+}
+
+func TestBalanceAsOfFollowsPostingOrder(t *testing.T) {
+	s := setupTestStore(t)
+	start := time.Date(2025, 4, 6, 10, 0, 0, 0, time.UTC)
+
+	// a was submitted first but, say after a retry, is posted after b
+	b := models.Transaction{TransactionID: "b", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("10"), Timestamp: start.Add(40 * time.Second)}
+	a := models.Transaction{TransactionID: "a", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: start.Add(10 * time.Second)}
+	for _, p := range []models.Transaction{b, a} {
+		if err := post(t, s, p); err != nil {
+			t.Fatalf("Posting %s failed: %v", p.TransactionID, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		asOf    time.Time
+		wantBal string
+	}{
+		{start.Add(30 * time.Second), "100"},
+		{start.Add(45 * time.Second), "110"},
+		{time.Now().Add(time.Hour), "111"},
+	} {
+		got, err := BalanceAsOf(context.Background(), s, "alice", models.DefaultCurrency, tt.asOf)
+		if err != nil {
+			t.Fatalf("BalanceAsOf() error = %v", err)
+		}
+		if !got.Balance.Equal(models.MustParseMoney(tt.wantBal)) {
+			t.Errorf("BalanceAsOf(%s) = %s, want %s", tt.asOf.Format(time.RFC3339), got.Balance, tt.wantBal)
+		}
+	}
+
+	statement, err := BuildStatement(context.Background(), s, "alice", models.DefaultCurrency, start.Add(30*time.Second), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("BuildStatement() error = %v", err)
+	}
+	if !statement.OpeningBalance.Equal(models.MustParseMoney("100")) || !statement.ClosingBalance.Equal(balanceOf(t, s, "alice")) {
+		t.Errorf("statement runs from %s to %s, want from 100 to the current balance %s", statement.OpeningBalance, statement.ClosingBalance, balanceOf(t, s, "alice"))
+	}
+	for _, entry := range statement.Entries {
+		if !entry.RunningBalance.Equal(*entry.Transaction.BalanceAfter) {
+			t.Errorf("entry %s runs at %s, but its snapshot is %s", entry.Transaction.TransactionID, entry.RunningBalance, entry.Transaction.BalanceAfter)
+		}
+	}
+}
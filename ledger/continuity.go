@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"sort"
+)
+
+// ErrSequenceGap is returned when a customer's transaction sequence skips or repeats a number
+var ErrSequenceGap = errors.New("transaction sequence has a gap")
+
+// ErrBalanceDiscontinuity is returned when a transaction's balance snapshot does not follow from the previous one
+var ErrBalanceDiscontinuity = errors.New("transaction balances are not continuous")
+
+// VerifyContinuity checks that consecutive sequenced transactions of one
+// customer follow each other without gaps, that each one moves the balance
+// by exactly its amount and that each starts from the balance the previous
+// one in the same currency left. The sequence is shared by all currencies of
+// a customer while every currency has its own balance. Transactions posted
+// before sequencing are ignored. The transactions may be given in any order.
+func VerifyContinuity(transactions []models.Transaction) error {
+	sequenced := sequencedTransactions(transactions)
+	previousIn := make(map[string]models.Transaction)
+	for i, t := range sequenced {
+		if !movesBalanceByAmount(t) {
+			return fmt.Errorf("%w: transaction %d (%s) does not move the balance by its amount", ErrBalanceDiscontinuity, t.Sequence, t.TransactionID)
+		}
+		if i > 0 && t.Sequence != sequenced[i-1].Sequence+1 {
+			return fmt.Errorf("%w: %d follows %d", ErrSequenceGap, t.Sequence, sequenced[i-1].Sequence)
+		}
+		previous, ok := previousIn[t.CurrencyCode()]
+		if ok && !t.BalanceBefore.Equal(*previous.BalanceAfter) {
+			return fmt.Errorf("%w: transaction %d starts at %s but %d ended at %s", ErrBalanceDiscontinuity, t.Sequence, t.BalanceBefore, previous.Sequence, previous.BalanceAfter)
+		}
+		previousIn[t.CurrencyCode()] = t
+	}
+	return nil
+}
+
+// VerifyHistory checks the complete transaction history of customer: it must
+// be continuous, start at sequence 1 if any transaction is sequenced, and end
+// at the customer's latest sequence number and, in every currency, at its
+// current balance.
+func VerifyHistory(customer models.Customer, transactions []models.Transaction) error {
+	if err := VerifyContinuity(transactions); err != nil {
+		return err
+	}
+	sequenced := sequencedTransactions(transactions)
+	if len(sequenced) == 0 {
+		if customer.LastSequence != 0 {
+			return fmt.Errorf("%w: no transactions up to %d", ErrSequenceGap, customer.LastSequence)
+		}
+		return nil
+	}
+
+	first, last := sequenced[0], sequenced[len(sequenced)-1]
+	if first.Sequence != 1 {
+		return fmt.Errorf("%w: history starts at %d", ErrSequenceGap, first.Sequence)
+	}
+	if last.Sequence != customer.LastSequence {
+		return fmt.Errorf("%w: history ends at %d but the customer is at %d", ErrSequenceGap, last.Sequence, customer.LastSequence)
+	}
+
+	lastIn := make(map[string]models.Transaction)
+	for _, t := range sequenced {
+		lastIn[t.CurrencyCode()] = t
+	}
+	for currency, t := range lastIn {
+		if balance := customer.BalanceIn(currency).Balance; !t.BalanceAfter.Equal(balance) {
+			return fmt.Errorf("%w: %s history ends at %s but the balance is %s", ErrBalanceDiscontinuity, currency, t.BalanceAfter, balance)
+		}
+	}
+	return nil
+}
+
+// sequencedTransactions returns the transactions that carry a sequence number, in sequence order
+func sequencedTransactions(transactions []models.Transaction) []models.Transaction {
+	sequenced := make([]models.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if t.Sequence > 0 {
+			sequenced = append(sequenced, t)
+		}
+	}
+	sort.Slice(sequenced, func(i, j int) bool { return sequenced[i].Sequence < sequenced[j].Sequence })
+	return sequenced
+}
+
+// movesBalanceByAmount reports whether the balance snapshots of t differ by exactly its amount
+func movesBalanceByAmount(t models.Transaction) bool {
+	if t.BalanceBefore == nil || t.BalanceAfter == nil {
+		return false
+	}
+	after, err := t.CalculateNewBalance(*t.BalanceBefore)
+	return err == nil && after.Equal(*t.BalanceAfter)
+}
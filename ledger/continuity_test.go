@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"testing"
+	"time"
+)
+
+func TestPostingsAreSequenced(t *testing.T) {
+	s := setupTestStore(t)
+	postings := []models.Transaction{
+		{TransactionID: "t1", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("5"), Timestamp: time.Now()},
+		{TransactionID: "t2", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("20"), Timestamp: time.Now()},
+	}
+	for _, p := range postings {
+		if err := post(t, s, p); err != nil {
+			t.Fatalf("Posting %s failed: %v", p.TransactionID, err)
+		}
+	}
+	transfer := models.Transfer{TransferID: "tr1", FromCustomerID: "alice", ToCustomerID: "bob", Amount: models.MustParseMoney("30"), Timestamp: time.Now()}
+	if _, _, _, err := ExecuteTransfer(context.Background(), s, transfer, DefaultPolicy); err != nil {
+		t.Fatalf("ExecuteTransfer() error = %v", err)
+	}
+
+	tests := []struct {
+		customerID string
+		want       []string // balance_before -> balance_after per sequence number
+	}{
+		{"alice", []string{"100.00->105.00", "105.00->85.00", "85.00->55.00"}},
+		{"bob", []string{"10.00->40.00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.customerID, func(t *testing.T) {
+			customer, err := s.GetCustomer(context.Background(), tt.customerID)
+			if err != nil {
+				t.Fatalf("Failed to load customer: %v", err)
+			}
+			history, err := s.GetTransactionHistory(context.Background(), tt.customerID)
+			if err != nil {
+				t.Fatalf("GetTransactionHistory() error = %v", err)
+			}
+			if len(history) != len(tt.want) {
+				t.Fatalf("history has %d transactions, want %d", len(history), len(tt.want))
+			}
+			for i, tx := range history {
+				got := tx.BalanceBefore.String() + "->" + tx.BalanceAfter.String()
+				if tx.Sequence != int64(i+1) || got != tt.want[i] {
+					t.Errorf("transaction %s = sequence %d, %s; want sequence %d, %s", tx.TransactionID, tx.Sequence, got, i+1, tt.want[i])
+				}
+			}
+			if customer.LastSequence != int64(len(tt.want)) {
+				t.Errorf("LastSequence = %d, want %d", customer.LastSequence, len(tt.want))
+			}
+			if err := VerifyHistory(customer, history); err != nil {
+				t.Errorf("VerifyHistory() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyHistory(t *testing.T) {
+	money := func(s string) *models.Money {
+		m := models.MustParseMoney(s)
+		return &m
+	}
+	credit := func(seq int64, before, amount, after string) models.Transaction {
+		return models.Transaction{
+			TransactionID: "t" + money(before).String(),
+			Type:          "credit",
+			Amount:        models.MustParseMoney(amount),
+			Sequence:      seq,
+			BalanceBefore: money(before),
+			BalanceAfter:  money(after),
+		}
+	}
+	legacy := models.Transaction{TransactionID: "old", Type: "credit", Amount: models.MustParseMoney("1")}
+	customer := models.Customer{CustomerID: "alice", Balance: models.MustParseMoney("30"), LastSequence: 3}
+
+	tests := []struct {
+		name         string
+		customer     models.Customer
+		transactions []models.Transaction
+		wantErr      error
+	}{
+		{"continuous", customer, []models.Transaction{credit(3, "20", "10", "30"), credit(1, "0", "10", "10"), credit(2, "10", "10", "20")}, nil},
+		{"legacy transactions ignored", customer, []models.Transaction{legacy, credit(1, "0", "10", "10"), credit(2, "10", "10", "20"), credit(3, "20", "10", "30")}, nil},
+		{"only legacy transactions", models.Customer{Balance: models.MustParseMoney("1")}, []models.Transaction{legacy}, nil},
+		{"missing sequence number", customer, []models.Transaction{credit(1, "0", "10", "10"), credit(3, "20", "10", "30")}, ErrSequenceGap},
+		{"repeated sequence number", customer, []models.Transaction{credit(1, "0", "10", "10"), credit(1, "0", "10", "10"), credit(2, "10", "10", "20"), credit(3, "20", "10", "30")}, ErrSequenceGap},
+		{"history does not start at 1", customer, []models.Transaction{credit(2, "10", "10", "20"), credit(3, "20", "10", "30")}, ErrSequenceGap},
+		{"latest transaction missing", customer, []models.Transaction{credit(1, "0", "10", "10"), credit(2, "10", "10", "20")}, ErrSequenceGap},
+		{"balance jumps between transactions", customer, []models.Transaction{credit(1, "0", "10", "10"), credit(2, "15", "10", "25"), credit(3, "25", "5", "30")}, ErrBalanceDiscontinuity},
+		{"amount does not match snapshot", customer, []models.Transaction{credit(1, "0", "10", "10"), credit(2, "10", "10", "25"), credit(3, "25", "5", "30")}, ErrBalanceDiscontinuity},
+		{"history does not end at the balance", models.Customer{Balance: models.MustParseMoney("31"), LastSequence: 3}, []models.Transaction{credit(1, "0", "10", "10"), credit(2, "10", "10", "20"), credit(3, "20", "10", "30")}, ErrBalanceDiscontinuity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyHistory(tt.customer, tt.transactions); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyHistory() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+)
+
+// ErrInvalidOverdraftLimit is returned for a negative overdraft limit
+var ErrInvalidOverdraftLimit = errors.New("overdraft limit must not be negative")
+
+// CustomerChanges lists the customer fields to change; nil fields are kept
+type CustomerChanges struct {
+	Name *string
+	// OverdraftLimit may be lowered below an overdraft already taken; the
+	// customer then cannot debit until the balance is back above the limit
+	OverdraftLimit *models.Money
+}
+
+// UpdateCustomer applies changes to a customer if it is still at
+// expectedVersion, so a stale client cannot overwrite changes made since it
+// read the customer. It returns the updated customer.
+func UpdateCustomer(ctx context.Context, ledgerStore store.LedgerStore, customerID string, expectedVersion int64, changes CustomerChanges) (models.Customer, error) {
+	if changes.OverdraftLimit != nil && changes.OverdraftLimit.IsNegative() {
+		return models.Customer{}, ErrInvalidOverdraftLimit
+	}
+
+	var updated models.Customer
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		customer, err := tx.GetCustomer(customerID)
+		if err != nil {
+			return err
+		}
+		before := customer
+		if changes.Name != nil {
+			customer.Name = *changes.Name
+		}
+		if changes.OverdraftLimit != nil {
+			customer.OverdraftLimit = *changes.OverdraftLimit
+		}
+		customer.Version = expectedVersion
+		if err := tx.UpdateCustomer(customer); err != nil {
+			return err
+		}
+		if updated, err = tx.GetCustomer(customerID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditCustomerUpdate, models.AuditTargetCustomer, customerID, before, updated)
+	})
+	return updated, err
+}
+
+// ChangeAccountStatus moves a customer account to status and records who
+// changed it and why in the customer's status history. Transitions the state
+// machine does not allow fail with models.ErrInvalidStatusTransition. Closing
+// is a soft close: the customer and its history are kept, and accounts with a
+// non-zero balance in any currency or active holds fail with models.ErrAccountNotEmpty.
+func ChangeAccountStatus(ctx context.Context, ledgerStore store.LedgerStore, customerID, status, reason, actor string) (models.Customer, error) {
+	var changed models.Customer
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		customer, err := tx.GetCustomer(customerID)
+		if err != nil {
+			return err
+		}
+		from := customer.AccountStatus()
+		if !models.CanTransition(from, status) {
+			return fmt.Errorf("%w: %s to %s", models.ErrInvalidStatusTransition, from, status)
+		}
+		if status == models.CustomerStatusClosed && !customer.IsEmpty() {
+			return models.ErrAccountNotEmpty
+		}
+		before := customer
+
+		now := models.GenerateTimestamp()
+		customer.Status = status
+		customer.StatusReason = reason
+		customer.StatusChangedBy = actor
+		customer.StatusChangedAt = &now
+		if status == models.CustomerStatusClosed {
+			customer.ClosedAt = &now
+		}
+		if err := tx.UpdateCustomer(customer); err != nil {
+			return err
+		}
+		if err := tx.InsertStatusChange(models.AccountStatusChange{
+			ChangeID:   models.GenerateStatusChangeID(),
+			CustomerID: customerID,
+			From:       from,
+			To:         status,
+			Reason:     reason,
+			Actor:      actor,
+			Timestamp:  now,
+		}); err != nil {
+			return err
+		}
+		if changed, err = tx.GetCustomer(customerID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditCustomerStatusChange, models.AuditTargetCustomer, customerID, before, changed)
+	})
+	return changed, err
+}
@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, s store.LedgerStore, tx models.Transaction) error {
+	t.Helper()
+	return s.WithTransaction(context.Background(), func(storeTx store.Tx) error {
+		_, err := ApplyTransaction(storeTx, tx, DefaultPolicy)
+		return err
+	})
+}
+
+func TestUpdateCustomer(t *testing.T) {
+	s := setupTestStore(t)
+	alice, err := s.GetCustomer(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Failed to load alice: %v", err)
+	}
+
+	// A posting made after the client read the customer bumps its version
+	if err := post(t, s, models.Transaction{TransactionID: "t1", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("5"), Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Posting failed: %v", err)
+	}
+
+	name := "Alice"
+	if _, err := UpdateCustomer(context.Background(), s, "alice", alice.Version, CustomerChanges{Name: &name}); !errors.Is(err, store.ErrVersionConflict) {
+		t.Fatalf("UpdateCustomer() with stale version error = %v, want %v", err, store.ErrVersionConflict)
+	}
+
+	renamed, err := UpdateCustomer(context.Background(), s, "alice", alice.Version+1, CustomerChanges{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateCustomer() error = %v", err)
+	}
+	if renamed.Name != "Alice" || renamed.Version != alice.Version+2 || !renamed.Balance.Equal(models.MustParseMoney("105")) {
+		t.Errorf("UpdateCustomer() = %+v, want name Alice, version %d and balance 105", renamed, alice.Version+2)
+	}
+
+	limit := models.MustParseMoney("50")
+	updated, err := UpdateCustomer(context.Background(), s, "alice", renamed.Version, CustomerChanges{OverdraftLimit: &limit})
+	if err != nil {
+		t.Fatalf("UpdateCustomer() of overdraft limit error = %v", err)
+	}
+	if updated.Name != "Alice" || !updated.OverdraftLimit.Equal(limit) {
+		t.Errorf("UpdateCustomer() = %+v, want name Alice and overdraft limit 50", updated)
+	}
+
+	negative := models.MustParseMoney("-1")
+	if _, err := UpdateCustomer(context.Background(), s, "alice", updated.Version, CustomerChanges{OverdraftLimit: &negative}); !errors.Is(err, ErrInvalidOverdraftLimit) {
+		t.Errorf("UpdateCustomer() with negative limit error = %v, want %v", err, ErrInvalidOverdraftLimit)
+	}
+
+	if _, err := UpdateCustomer(context.Background(), s, "missing", 0, CustomerChanges{Name: &name}); !errors.Is(err, store.ErrCustomerNotFound) {
+		t.Errorf("UpdateCustomer() of missing customer error = %v, want %v", err, store.ErrCustomerNotFound)
+	}
+}
+
+func TestOverdraftLimit(t *testing.T) {
+	s := setupTestStore(t)
+	bob, err := s.GetCustomer(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("Failed to load bob: %v", err)
+	}
+	limit := models.MustParseMoney("25")
+	if _, err := UpdateCustomer(context.Background(), s, "bob", bob.Version, CustomerChanges{OverdraftLimit: &limit}); err != nil {
+		t.Fatalf("Setting the overdraft limit failed: %v", err)
+	}
+	debit := func(id, amount string) error {
+		return post(t, s, models.Transaction{TransactionID: id, CustomerID: "bob", Type: "debit", Amount: models.MustParseMoney(amount), Timestamp: time.Now()})
+	}
+
+	// bob has 10.00 and may go 25.00 below zero
+	if err := debit("t1", "30"); err != nil {
+		t.Fatalf("Debit within the overdraft limit error = %v", err)
+	}
+	if got := balanceOf(t, s, "bob"); !got.Equal(models.MustParseMoney("-20")) {
+		t.Errorf("Balance = %s, want -20", got)
+	}
+	if err := debit("t2", "5.01"); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("Debit beyond the overdraft limit error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+	if _, err := PlaceHold(context.Background(), s, models.Hold{HoldID: "h1", CustomerID: "bob", Amount: models.MustParseMoney("5.01")}, DefaultPolicy); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("Hold beyond the overdraft limit error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+	if _, err := PlaceHold(context.Background(), s, models.Hold{HoldID: "h2", CustomerID: "bob", Amount: models.MustParseMoney("2")}, DefaultPolicy); err != nil {
+		t.Fatalf("Hold within the overdraft limit error = %v", err)
+	}
+
+	customer, err := s.GetCustomer(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("Failed to load bob: %v", err)
+	}
+	if got := customer.AvailableCredit(); !got.Equal(models.MustParseMoney("3")) {
+		t.Errorf("AvailableCredit() = %s, want 3", got)
+	}
+	if err := debit("t3", "3"); err != nil {
+		t.Errorf("Debit of the remaining credit error = %v", err)
+	}
+}
+
+func TestChangeAccountStatus(t *testing.T) {
+	s := setupTestStore(t)
+	change := func(status string) error {
+		_, err := ChangeAccountStatus(context.Background(), s, "bob", status, "test", "tester")
+		return err
+	}
+	posting := func(id, transactionType string) models.Transaction {
+		return models.Transaction{TransactionID: id, CustomerID: "bob", Type: transactionType, Amount: models.MustParseMoney("1"), Timestamp: time.Now()}
+	}
+
+	if err := change(models.CustomerStatusFrozen); err != nil {
+		t.Fatalf("Freezing failed: %v", err)
+	}
+	if err := post(t, s, posting("t1", "debit")); !errors.Is(err, models.ErrAccountFrozen) {
+		t.Errorf("Debit of frozen account error = %v, want %v", err, models.ErrAccountFrozen)
+	}
+	if err := post(t, s, posting("t2", "credit")); err != nil {
+		t.Errorf("Credit of frozen account error = %v, want none", err)
+	}
+	err := s.WithTransaction(context.Background(), func(tx store.Tx) error {
+		_, err := ApplyTransaction(tx, posting("t3", "credit"), Policy{FrozenAcceptsCredits: false})
+		return err
+	})
+	if !errors.Is(err, models.ErrAccountFrozen) {
+		t.Errorf("Credit of frozen account with credits disabled error = %v, want %v", err, models.ErrAccountFrozen)
+	}
+
+	if err := change(models.CustomerStatusClosed); !errors.Is(err, models.ErrAccountNotEmpty) {
+		t.Fatalf("Closing with balance error = %v, want %v", err, models.ErrAccountNotEmpty)
+	}
+	if err := change(models.CustomerStatusActive); err != nil {
+		t.Fatalf("Unfreezing failed: %v", err)
+	}
+	if err := post(t, s, posting("t4", "debit")); err != nil {
+		t.Fatalf("Debit of active account error = %v", err)
+	}
+	if err := s.WithTransaction(context.Background(), func(tx store.Tx) error {
+		_, err := ApplyTransaction(tx, models.Transaction{TransactionID: "t5", CustomerID: "bob", Type: "debit", Amount: models.MustParseMoney("10"), Timestamp: time.Now()}, DefaultPolicy)
+		return err
+	}); err != nil {
+		t.Fatalf("Emptying the account failed: %v", err)
+	}
+
+	if err := change(models.CustomerStatusClosed); err != nil {
+		t.Fatalf("Closing failed: %v", err)
+	}
+	closed, _ := s.GetCustomer(context.Background(), "bob")
+	if !closed.IsClosed() || closed.ClosedAt == nil || closed.StatusChangedBy != "tester" {
+		t.Errorf("Closed customer = %+v, want closed by tester", closed)
+	}
+	if err := change(models.CustomerStatusActive); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("Reopening error = %v, want %v", err, models.ErrInvalidStatusTransition)
+	}
+	if err := post(t, s, posting("t6", "credit")); !errors.Is(err, models.ErrAccountClosed) {
+		t.Errorf("Credit of closed account error = %v, want %v", err, models.ErrAccountClosed)
+	}
+
+	history, err := s.GetStatusHistory(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("GetStatusHistory() error = %v", err)
+	}
+	var transitions []string
+	for _, change := range history {
+		transitions = append(transitions, change.From+">"+change.To)
+	}
+	if got, want := strings.Join(transitions, ","), "active>frozen,frozen>active,active>closed"; got != want {
+		t.Errorf("Status history = %s, want %s", got, want)
+	}
+
+	// The history of a closed account is kept
+	transactions, err := s.GetTransactionHistory(context.Background(), "bob")
+	if err != nil || len(transactions) != 3 {
+		t.Errorf("Transactions after close = %v (%v), want the three posted transactions", transactions, err)
+	}
+}
@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultQuoteTTL is how long a quote locks its rate when no lifetime is configured
+const DefaultQuoteTTL = 30 * time.Second
+
+// ErrSameCurrency is returned when converting a currency into itself
+var ErrSameCurrency = errors.New("source and target currencies must differ")
+
+// ErrAmountTooSmall is returned when an amount converts to less than one minor unit of the target currency
+var ErrAmountTooSmall = errors.New("amount converts to less than one minor unit of the target currency")
+
+// AddExchangeRate adds rate to the rate table, replacing the rate of the same
+// currency pair that takes effect at the same moment. Currency codes may be
+// given in any case.
+func AddExchangeRate(ctx context.Context, fxStore store.FXStore, rate models.ExchangeRate) (models.ExchangeRate, error) {
+	rate.BaseCurrency = strings.ToUpper(rate.BaseCurrency)
+	rate.QuoteCurrency = strings.ToUpper(rate.QuoteCurrency)
+	if err := rate.Validate(); err != nil {
+		return models.ExchangeRate{}, err
+	}
+	rate.EffectiveFrom = rate.EffectiveFrom.UTC()
+	rate.RateID = models.ExchangeRateID(rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveFrom)
+	rate.CreatedAt = models.GenerateTimestamp()
+	if err := fxStore.SaveExchangeRate(ctx, rate); err != nil {
+		return models.ExchangeRate{}, err
+	}
+	return rate, nil
+}
+
+// LoadExchangeRates adds the rates of a JSON file holding an array of rate
+// table entries and returns how many it added. Every entry is checked before
+// any is added, so a file with an invalid entry changes nothing. Entries
+// without a source are attributed to the file.
+func LoadExchangeRates(ctx context.Context, fxStore store.FXStore, path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var rates []models.ExchangeRate
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for i := range rates {
+		rates[i].BaseCurrency = strings.ToUpper(rates[i].BaseCurrency)
+		rates[i].QuoteCurrency = strings.ToUpper(rates[i].QuoteCurrency)
+		if err := rates[i].Validate(); err != nil {
+			return 0, fmt.Errorf("rate %d of %s: %w", i+1, path, err)
+		}
+		if rates[i].Source == "" {
+			rates[i].Source = filepath.Base(path)
+		}
+	}
+	for i, rate := range rates {
+		if _, err := AddExchangeRate(ctx, fxStore, rate); err != nil {
+			return i, err
+		}
+	}
+	return len(rates), nil
+}
+
+// QuoteConversion prices converting amount of a customer's balance in from
+// into to at the rate in effect now, less its spread, and stores the quote,
+// which locks the rate for ttl. The target amount is truncated to the minor
+// units of to. Pairs without a rate in effect fail with
+// store.ErrExchangeRateNotFound. Funds are only checked when the quote is
+// executed.
+func QuoteConversion(ctx context.Context, ledgerStore store.LedgerStore, fxStore store.FXStore, customerID, from, to string, amount models.Money, ttl time.Duration) (models.FXQuote, error) {
+	if !amount.IsPositive() {
+		return models.FXQuote{}, ErrInvalidAmount
+	}
+	source, err := models.LookupCurrency(from)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	target, err := models.LookupCurrency(to)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	if source.Code == target.Code {
+		return models.FXQuote{}, ErrSameCurrency
+	}
+	if amount, err = amount.InCurrency(source); err != nil {
+		return models.FXQuote{}, err
+	}
+	if _, err := ledgerStore.GetCustomer(ctx, customerID); err != nil {
+		return models.FXQuote{}, err
+	}
+
+	now := models.GenerateTimestamp()
+	rate, err := fxStore.GetEffectiveRate(ctx, source.Code, target.Code, now)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	customerRate, err := rate.CustomerRate()
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	converted, err := amount.MulTruncated(customerRate, target.MinorUnits)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	if !converted.IsPositive() {
+		return models.FXQuote{}, ErrAmountTooSmall
+	}
+	atRate, err := amount.MulTruncated(rate.Rate, target.MinorUnits)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+
+	quote := models.FXQuote{
+		QuoteID:      models.GenerateQuoteID(),
+		CustomerID:   customerID,
+		FromCurrency: source.Code,
+		ToCurrency:   target.Code,
+		SourceAmount: amount,
+		TargetAmount: converted,
+		RateID:       rate.RateID,
+		Rate:         rate.Rate,
+		Spread:       rate.Spread,
+		CustomerRate: customerRate,
+		SpreadAmount: atRate.Sub(converted),
+		Status:       models.QuoteStatusOpen,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if err := fxStore.InsertQuote(ctx, quote); err != nil {
+		return models.FXQuote{}, err
+	}
+	return quote, nil
+}
+
+// ExecuteConversion executes the open quote quoteID: it debits the source
+// amount from the customer's balance in the source currency, credits the
+// target amount to the balance in the target currency, marks the quote
+// executed and records the conversion with the rate and spread used, all in
+// one store transaction. Both legs are journaled against the FX position
+// account of their currency. Expired quotes fail with models.ErrQuoteExpired
+// and executed ones with models.ErrQuoteUsed. The debit is posted like any
+// other, so policy and the available balance apply to it. It returns the
+// conversion and the customer's new balances in the source and target
+// currencies.
+func ExecuteConversion(ctx context.Context, ledgerStore store.LedgerStore, fxStore store.FXStore, quoteID string, policy Policy) (models.Conversion, models.Money, models.Money, error) {
+	quote, err := fxStore.GetQuote(ctx, quoteID)
+	if err != nil {
+		return models.Conversion{}, models.Money{}, models.Money{}, err
+	}
+	now := models.GenerateTimestamp()
+	switch {
+	case quote.Status != models.QuoteStatusOpen:
+		return models.Conversion{}, models.Money{}, models.Money{}, models.ErrQuoteUsed
+	case quote.ExpiredAt(now):
+		return models.Conversion{}, models.Money{}, models.Money{}, models.ErrQuoteExpired
+	}
+
+	conversion := models.NewConversion(quote, now)
+	debit, credit := conversion.Legs()
+	var sourceBalance, targetBalance models.Money
+	err = ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		// Claiming the quote first makes concurrent executions of it conflict
+		if err := tx.ExecuteQuote(quote.QuoteID, conversion.ConversionID); err != nil {
+			return err
+		}
+		var err error
+		if sourceBalance, err = ApplyTransaction(tx, debit, policy); err != nil {
+			return err
+		}
+		if targetBalance, err = ApplyTransaction(tx, credit, policy); err != nil {
+			return err
+		}
+		if err := tx.InsertConversion(conversion); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditConversionExecute, models.AuditTargetConversion, conversion.ConversionID, nil, conversion)
+	})
+	if err != nil {
+		return models.Conversion{}, models.Money{}, models.Money{}, err
+	}
+	return conversion, sourceBalance, targetBalance, nil
+}
@@ -0,0 +1,171 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func addRate(t *testing.T, s store.FXStore, base, quote, rate, spread string, effectiveFrom time.Time) {
+	t.Helper()
+	_, err := AddExchangeRate(context.Background(), s, models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          models.MustParseMoney(rate),
+		Spread:        models.MustParseMoney(spread),
+		EffectiveFrom: effectiveFrom,
+	})
+	if err != nil {
+		t.Fatalf("AddExchangeRate(%s/%s) error = %v", base, quote, err)
+	}
+}
+
+func TestExecuteConversion(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	addRate(t, s, "USD", "EUR", "0.5", "0", now.Add(-48*time.Hour))
+	addRate(t, s, "USD", "EUR", "0.9", "0.01", now.Add(-time.Hour))
+	addRate(t, s, "USD", "EUR", "2", "0", now.Add(time.Hour))
+
+	quote, err := QuoteConversion(ctx, s, s, "alice", "usd", "EUR", models.MustParseMoney("50"), time.Minute)
+	if err != nil {
+		t.Fatalf("QuoteConversion() error = %v", err)
+	}
+	// 50 * 0.9 * (1 - 0.01) = 44.55, against 45.00 without the spread
+	if !quote.TargetAmount.Equal(models.MustParseMoney("44.55")) || !quote.SpreadAmount.Equal(models.MustParseMoney("0.45")) ||
+		!quote.Rate.Equal(models.MustParseMoney("0.9")) || quote.FromCurrency != "USD" {
+		t.Fatalf("Quote = %+v, want 44.55 EUR at the rate in effect with 0.45 spread", quote)
+	}
+
+	conversion, fromBalance, toBalance, err := ExecuteConversion(ctx, s, s, quote.QuoteID, DefaultPolicy)
+	if err != nil {
+		t.Fatalf("ExecuteConversion() error = %v", err)
+	}
+	if !fromBalance.Equal(models.MustParseMoney("50")) || !toBalance.Equal(models.MustParseMoney("44.55")) {
+		t.Errorf("Balances after conversion = %s USD and %s EUR, want 50 and 44.55", fromBalance, toBalance)
+	}
+	if conversion.QuoteID != quote.QuoteID || conversion.RateID != quote.RateID || !conversion.CustomerRate.Equal(models.MustParseMoney("0.891")) {
+		t.Errorf("Conversion = %+v, want the rate and spread of the quote", conversion)
+	}
+	if stored, err := s.GetQuote(ctx, quote.QuoteID); err != nil || stored.Status != models.QuoteStatusExecuted || stored.ConversionID != conversion.ConversionID {
+		t.Errorf("Quote after execution = %+v, %v, want executed by %s", stored, err, conversion.ConversionID)
+	}
+	if _, _, _, err := ExecuteConversion(ctx, s, s, quote.QuoteID, DefaultPolicy); !errors.Is(err, models.ErrQuoteUsed) {
+		t.Errorf("Executing a quote twice error = %v, want %v", err, models.ErrQuoteUsed)
+	}
+
+	history, _ := s.GetTransactionHistory(ctx, "alice")
+	if len(history) != 2 || history[0].ConversionID != conversion.ConversionID || history[1].CurrencyCode() != "EUR" {
+		t.Errorf("History = %+v, want the two legs of the conversion", history)
+	}
+	customer, _ := s.GetCustomer(ctx, "alice")
+	if err := VerifyHistory(customer, history); err != nil {
+		t.Errorf("VerifyHistory() error = %v", err)
+	}
+	position, _ := s.GetAccountBalance(ctx, models.AccountInCurrency(models.SystemAccountFXPosition, "EUR"))
+	if !position.Equal(models.MustParseMoney("44.55")) {
+		t.Errorf("EUR position = %s, want 44.55", position)
+	}
+	accounts, _ := s.GetTrialBalance(ctx)
+	var total models.Money
+	for _, account := range accounts {
+		total = total.Add(account.Balance)
+	}
+	if !total.IsZero() {
+		t.Errorf("Trial balance total = %s, want 0", total)
+	}
+}
+
+func TestExecuteConversionFails(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	addRate(t, s, "USD", "EUR", "0.9", "0.01", time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name     string
+		customer string
+		from, to string
+		amount   string
+		wantErr  error
+	}{
+		{name: "no rate in effect", customer: "alice", from: "EUR", to: "GBP", amount: "1", wantErr: store.ErrExchangeRateNotFound},
+		{name: "same currency", customer: "alice", from: "USD", to: "USD", amount: "1", wantErr: ErrSameCurrency},
+		{name: "unsupported currency", customer: "alice", from: "USD", to: "XYZ", amount: "1", wantErr: models.ErrUnsupportedCurrency},
+		{name: "below one minor unit", customer: "alice", from: "USD", to: "EUR", amount: "0.01", wantErr: ErrAmountTooSmall},
+		{name: "too many decimals", customer: "alice", from: "USD", to: "EUR", amount: "1.005", wantErr: models.ErrExcessPrecision},
+		{name: "unknown customer", customer: "carol", from: "USD", to: "EUR", amount: "1", wantErr: store.ErrCustomerNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := QuoteConversion(ctx, s, s, tt.customer, tt.from, tt.to, models.MustParseMoney(tt.amount), time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("QuoteConversion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	expired, err := QuoteConversion(ctx, s, s, "alice", "USD", "EUR", models.MustParseMoney("1"), 0)
+	if err != nil {
+		t.Fatalf("QuoteConversion() error = %v", err)
+	}
+	if _, _, _, err := ExecuteConversion(ctx, s, s, expired.QuoteID, DefaultPolicy); !errors.Is(err, models.ErrQuoteExpired) {
+		t.Errorf("Executing an expired quote error = %v, want %v", err, models.ErrQuoteExpired)
+	}
+
+	// bob has 10.00, so the debit fails and nothing of the conversion remains
+	quote, err := QuoteConversion(ctx, s, s, "bob", "USD", "EUR", models.MustParseMoney("20"), time.Minute)
+	if err != nil {
+		t.Fatalf("QuoteConversion() error = %v", err)
+	}
+	if _, _, _, err := ExecuteConversion(ctx, s, s, quote.QuoteID, DefaultPolicy); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("ExecuteConversion() error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+	if stored, _ := s.GetQuote(ctx, quote.QuoteID); stored.Status != models.QuoteStatusOpen {
+		t.Errorf("Quote status after a failed conversion = %s, want open", stored.Status)
+	}
+	if history, _ := s.GetTransactionHistory(ctx, "bob"); len(history) != 0 {
+		t.Errorf("History after a failed conversion = %+v, want empty", history)
+	}
+}
+
+func TestLoadExchangeRates(t *testing.T) {
+	s := store.NewMemoryStore()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	invalid := write("invalid.json", `[
+		{"base_currency": "EUR", "quote_currency": "USD", "rate": 1.08, "effective_from": "2025-04-01T00:00:00Z"},
+		{"base_currency": "EUR", "quote_currency": "USD", "rate": -1, "effective_from": "2025-04-02T00:00:00Z"}
+	]`)
+	if _, err := LoadExchangeRates(context.Background(), s, invalid); !errors.Is(err, models.ErrInvalidExchangeRate) {
+		t.Errorf("LoadExchangeRates() error = %v, want %v", err, models.ErrInvalidExchangeRate)
+	}
+	if rates, _ := s.ListExchangeRates(context.Background(), "", ""); len(rates) != 0 {
+		t.Errorf("Rates after loading an invalid file = %+v, want none", rates)
+	}
+
+	valid := write("rates.json", `[
+		{"base_currency": "eur", "quote_currency": "usd", "rate": 1.08, "spread": 0.002, "effective_from": "2025-04-01T00:00:00Z"},
+		{"base_currency": "EUR", "quote_currency": "USD", "rate": 1.09, "effective_from": "2025-04-02T00:00:00Z"}
+	]`)
+	loaded, err := LoadExchangeRates(context.Background(), s, valid)
+	if err != nil || loaded != 2 {
+		t.Fatalf("LoadExchangeRates() = %d, %v, want 2 rates", loaded, err)
+	}
+	rate, err := s.GetEffectiveRate(context.Background(), "EUR", "USD", time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil || !rate.Rate.Equal(models.MustParseMoney("1.08")) || rate.Source != "rates.json" {
+		t.Errorf("Rate in effect on 2025-04-01 = %+v, %v, want 1.08 from rates.json", rate, err)
+	}
+}
@@ -0,0 +1,288 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultHoldTTL is how long a hold stays active when no expiry time is given
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+// DefaultHoldExpiryInterval is how often a HoldExpirer releases expired holds
+const DefaultHoldExpiryInterval = time.Minute
+
+// expiryBatchSize caps the number of holds ExpireHolds loads at a time
+const expiryBatchSize = 100
+
+// PlaceHold reserves hold.Amount of the customer's available balance in the
+// hold's currency until hold.ExpiresAt. The account must accept debits under
+// policy, and holds larger than the available balance plus the overdraft
+// limit fail with models.ErrInsufficientFunds.
+func PlaceHold(ctx context.Context, ledgerStore store.LedgerStore, hold models.Hold, policy Policy) (models.Hold, error) {
+	if !hold.Amount.IsPositive() {
+		return models.Hold{}, ErrInvalidAmount
+	}
+	currency, err := models.LookupCurrency(hold.CurrencyCode())
+	if err != nil {
+		return models.Hold{}, err
+	}
+	if hold.Amount, err = hold.Amount.InCurrency(currency); err != nil {
+		return models.Hold{}, err
+	}
+	hold.Currency = currency.Code
+	hold.Status = models.HoldStatusActive
+	hold.CapturedAmount = models.Money{}
+
+	err = ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		customer, err := tx.GetCustomer(hold.CustomerID)
+		if err != nil {
+			return err
+		}
+		if err := policy.CheckAccountStatus(customer, "debit"); err != nil {
+			return err
+		}
+		if !customer.CanDebit(hold.Currency, hold.Amount) {
+			return models.ErrInsufficientFunds
+		}
+		held := customer.BalanceIn(hold.Currency).HeldBalance
+		newHeld, err := held.CheckedAdd(hold.Amount)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateHeldBalance(hold.CustomerID, hold.Currency, newHeld); err != nil {
+			return err
+		}
+		if err := tx.InsertHold(hold); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditHoldPlace, models.AuditTargetHold, hold.HoldID, nil, hold)
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// CaptureHold releases an active hold and debits the captured amount in one
+// store transaction. A nil amount captures the whole hold; a smaller amount
+// captures part of it and releases the rest. A non-empty currency must be the
+// hold's currency or the capture fails with models.ErrCurrencyMismatch. It
+// returns the captured hold, the debit and the customer's new balance. Holds
+// past their expiry time fail with models.ErrHoldExpired even before they are
+// swept.
+func CaptureHold(ctx context.Context, ledgerStore store.LedgerStore, holdID string, amount *models.Money, currency string, policy Policy) (models.Hold, models.Transaction, models.Money, error) {
+	if amount != nil && !amount.IsPositive() {
+		return models.Hold{}, models.Transaction{}, models.Money{}, ErrInvalidAmount
+	}
+
+	var (
+		captured models.Hold
+		debit    models.Transaction
+		balance  models.Money
+	)
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		hold, err := tx.GetHold(holdID)
+		if err != nil {
+			return err
+		}
+		now := models.GenerateTimestamp()
+		switch {
+		case !hold.IsActive():
+			return models.ErrHoldNotActive
+		case hold.ExpiredAt(now):
+			return models.ErrHoldExpired
+		case currency != "" && currency != hold.CurrencyCode():
+			return models.ErrCurrencyMismatch
+		}
+		capture := hold.Amount
+		if amount != nil {
+			if capture, err = inCurrencyOf(*amount, hold.CurrencyCode()); err != nil {
+				return err
+			}
+			if capture.Cmp(hold.Amount) > 0 {
+				return models.ErrCaptureExceedsHold
+			}
+		}
+
+		before := hold
+		if err := releaseHold(tx, hold); err != nil {
+			return err
+		}
+		debit = models.Transaction{
+			TransactionID: models.GenerateTransactionID(),
+			CustomerID:    hold.CustomerID,
+			Type:          "debit",
+			Amount:        capture,
+			Currency:      hold.Currency,
+			Timestamp:     now,
+			HoldID:        hold.HoldID,
+		}
+		if balance, err = ApplyTransaction(tx, debit, policy); err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldStatusCaptured
+		hold.CapturedAmount = capture
+		hold.ResolvedAt = &now
+		hold.CaptureTransactionID = debit.TransactionID
+		captured = hold
+		if err := tx.ResolveHold(hold); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditHoldCapture, models.AuditTargetHold, hold.HoldID, before, captured)
+	})
+	if err != nil {
+		return models.Hold{}, models.Transaction{}, models.Money{}, err
+	}
+	return captured, debit, balance, nil
+}
+
+// VoidHold releases an active hold without debiting anything
+func VoidHold(ctx context.Context, ledgerStore store.LedgerStore, holdID string) (models.Hold, error) {
+	var voided models.Hold
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		hold, err := tx.GetHold(holdID)
+		if err != nil {
+			return err
+		}
+		if !hold.IsActive() {
+			return models.ErrHoldNotActive
+		}
+		if voided, err = resolveHold(tx, hold, models.HoldStatusVoided, models.GenerateTimestamp()); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditHoldVoid, models.AuditTargetHold, hold.HoldID, hold, voided)
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return voided, nil
+}
+
+// ExpireHolds releases every active hold whose expiry time is not after now
+// and returns how many it expired. Each hold is expired in its own store
+// transaction, so a hold captured or voided concurrently is left alone.
+func ExpireHolds(ctx context.Context, ledgerStore store.LedgerStore, now time.Time) (int, error) {
+	expired := 0
+	for {
+		due, err := ledgerStore.ListExpiredHolds(ctx, now, expiryBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, candidate := range due {
+			err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+				hold, err := tx.GetHold(candidate.HoldID)
+				if err != nil {
+					return err
+				}
+				if !hold.ExpiredAt(now) {
+					return models.ErrHoldNotActive
+				}
+				expiredHold, err := resolveHold(tx, hold, models.HoldStatusExpired, now)
+				if err != nil {
+					return err
+				}
+				return audit.Record(ctx, tx, models.AuditHoldExpire, models.AuditTargetHold, hold.HoldID, hold, expiredHold)
+			})
+			switch {
+			case err == nil:
+				expired++
+			case !errors.Is(err, models.ErrHoldNotActive):
+				return expired, err
+			}
+		}
+		if len(due) < expiryBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// releaseHold returns the funds reserved by hold to the customer's available balance
+func releaseHold(tx store.Tx, hold models.Hold) error {
+	customer, err := tx.GetCustomer(hold.CustomerID)
+	if err != nil {
+		return err
+	}
+	held := customer.BalanceIn(hold.Currency).HeldBalance
+	return tx.UpdateHeldBalance(hold.CustomerID, hold.Currency, held.Sub(hold.Amount))
+}
+
+// inCurrencyOf returns amount rescaled to the currency with the given code,
+// failing with models.ErrExcessPrecision if it has more decimal places than
+// the currency allows
+func inCurrencyOf(amount models.Money, code string) (models.Money, error) {
+	currency, err := models.LookupCurrency(code)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return amount.InCurrency(currency)
+}
+
+// resolveHold releases hold and moves it to status
+func resolveHold(tx store.Tx, hold models.Hold, status string, now time.Time) (models.Hold, error) {
+	if err := releaseHold(tx, hold); err != nil {
+		return models.Hold{}, err
+	}
+	hold.Status = status
+	hold.ResolvedAt = &now
+	if err := tx.ResolveHold(hold); err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// HoldExpirer periodically releases expired holds in the background
+type HoldExpirer struct {
+	store    store.LedgerStore
+	interval time.Duration
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHoldExpirer creates an expirer that sweeps ledgerStore every interval
+func NewHoldExpirer(ledgerStore store.LedgerStore, interval time.Duration) *HoldExpirer {
+	if interval <= 0 {
+		interval = DefaultHoldExpiryInterval
+	}
+	return &HoldExpirer{
+		store:    ledgerStore,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins sweeping for expired holds
+func (e *HoldExpirer) Start() {
+	go e.run()
+}
+
+// Stop stops sweeping
+func (e *HoldExpirer) Stop() {
+	e.stopOnce.Do(func() { close(e.stopChan) })
+}
+
+func (e *HoldExpirer) run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopChan:
+			return
+		case now := <-ticker.C:
+			expired, err := ExpireHolds(context.Background(), e.store, now)
+			if err != nil {
+				log.Printf("failed to expire holds: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("expired %d holds", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"testing"
+	"time"
+)
+
+func placeHold(t *testing.T, s store.LedgerStore, customerID, amount string, expiresAt time.Time) models.Hold {
+	t.Helper()
+	hold, err := PlaceHold(context.Background(), s, models.Hold{
+		HoldID:     models.GenerateHoldID(),
+		CustomerID: customerID,
+		Amount:     models.MustParseMoney(amount),
+		CreatedAt:  time.Now(),
+		ExpiresAt:  expiresAt,
+	}, DefaultPolicy)
+	if err != nil {
+		t.Fatalf("PlaceHold() error = %v", err)
+	}
+	return hold
+}
+
+func heldOf(t *testing.T, s store.LedgerStore, customerID string) (ledgerBalance, available string) {
+	t.Helper()
+	customer, err := s.GetCustomer(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("Failed to load customer %s: %v", customerID, err)
+	}
+	return customer.Balance.String(), customer.AvailableBalance().String()
+}
+
+func TestHoldLifecycle(t *testing.T) {
+	s := setupTestStore(t)
+	later := time.Now().Add(time.Hour)
+
+	hold := placeHold(t, s, "alice", "60", later)
+	if ledgerBalance, available := heldOf(t, s, "alice"); ledgerBalance != "100.00" || available != "40.00" {
+		t.Fatalf("after placing a hold balances = %s ledger, %s available; want 100.00 and 40.00", ledgerBalance, available)
+	}
+
+	// Debits and further holds are checked against the available balance
+	if err := post(t, s, models.Transaction{TransactionID: "t1", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("50"), Timestamp: time.Now()}); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("debit beyond the available balance error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+	_, err := PlaceHold(context.Background(), s, models.Hold{HoldID: "h2", CustomerID: "alice", Amount: models.MustParseMoney("41"), ExpiresAt: later}, DefaultPolicy)
+	if !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("hold beyond the available balance error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+
+	// Closing needs every hold resolved
+	if _, err := ChangeAccountStatus(context.Background(), s, "alice", models.CustomerStatusClosed, "", "test"); !errors.Is(err, models.ErrAccountNotEmpty) {
+		t.Errorf("closing with an active hold error = %v, want %v", err, models.ErrAccountNotEmpty)
+	}
+
+	over := models.MustParseMoney("61")
+	if _, _, _, err := CaptureHold(context.Background(), s, hold.HoldID, &over, "", DefaultPolicy); !errors.Is(err, models.ErrCaptureExceedsHold) {
+		t.Errorf("capturing more than the hold error = %v, want %v", err, models.ErrCaptureExceedsHold)
+	}
+
+	partial := models.MustParseMoney("45")
+	captured, debit, balance, err := CaptureHold(context.Background(), s, hold.HoldID, &partial, "", DefaultPolicy)
+	if err != nil {
+		t.Fatalf("CaptureHold() error = %v", err)
+	}
+	if captured.Status != models.HoldStatusCaptured || !captured.CapturedAmount.Equal(partial) || captured.CaptureTransactionID != debit.TransactionID {
+		t.Errorf("captured hold = %+v, want captured for 45 by %s", captured, debit.TransactionID)
+	}
+	if debit.HoldID != hold.HoldID || !balance.Equal(models.MustParseMoney("55")) {
+		t.Errorf("capture debit = %+v with balance %s, want a debit of hold %s leaving 55", debit, balance, hold.HoldID)
+	}
+	if ledgerBalance, available := heldOf(t, s, "alice"); ledgerBalance != "55.00" || available != "55.00" {
+		t.Errorf("after a partial capture balances = %s ledger, %s available; want the remainder released", ledgerBalance, available)
+	}
+
+	if _, _, _, err := CaptureHold(context.Background(), s, hold.HoldID, nil, "", DefaultPolicy); !errors.Is(err, models.ErrHoldNotActive) {
+		t.Errorf("capturing twice error = %v, want %v", err, models.ErrHoldNotActive)
+	}
+	if _, err := VoidHold(context.Background(), s, hold.HoldID); !errors.Is(err, models.ErrHoldNotActive) {
+		t.Errorf("voiding a captured hold error = %v, want %v", err, models.ErrHoldNotActive)
+	}
+
+	voided, err := VoidHold(context.Background(), s, placeHold(t, s, "alice", "20", later).HoldID)
+	if err != nil || voided.Status != models.HoldStatusVoided {
+		t.Fatalf("VoidHold() = %+v, %v; want a voided hold", voided, err)
+	}
+	if ledgerBalance, available := heldOf(t, s, "alice"); ledgerBalance != "55.00" || available != "55.00" {
+		t.Errorf("after a void balances = %s ledger, %s available; want nothing debited and nothing held", ledgerBalance, available)
+	}
+
+	if _, err := VoidHold(context.Background(), s, "missing"); !errors.Is(err, store.ErrHoldNotFound) {
+		t.Errorf("voiding a missing hold error = %v, want %v", err, store.ErrHoldNotFound)
+	}
+}
+
+func TestExpireHolds(t *testing.T) {
+	s := setupTestStore(t)
+	now := time.Now()
+	due := placeHold(t, s, "bob", "10", now.Add(time.Millisecond))
+	pending := placeHold(t, s, "alice", "20", now.Add(time.Hour))
+	time.Sleep(2 * time.Millisecond)
+
+	// An expired hold cannot be captured even before it is swept
+	if _, _, _, err := CaptureHold(context.Background(), s, due.HoldID, nil, "", DefaultPolicy); !errors.Is(err, models.ErrHoldExpired) {
+		t.Errorf("capturing an expired hold error = %v, want %v", err, models.ErrHoldExpired)
+	}
+
+	expired, err := ExpireHolds(context.Background(), s, now.Add(time.Minute))
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireHolds() = %d, %v; want 1 hold expired", expired, err)
+	}
+	if hold, _ := s.GetHold(context.Background(), due.HoldID); hold.Status != models.HoldStatusExpired || hold.ResolvedAt == nil {
+		t.Errorf("swept hold = %+v, want expired", hold)
+	}
+	if hold, _ := s.GetHold(context.Background(), pending.HoldID); hold.Status != models.HoldStatusActive {
+		t.Errorf("hold that is not due = %+v, want active", hold)
+	}
+	if ledgerBalance, available := heldOf(t, s, "bob"); ledgerBalance != "10.00" || available != "10.00" {
+		t.Errorf("after expiry balances = %s ledger, %s available; want the hold released", ledgerBalance, available)
+	}
+
+	if expired, err := ExpireHolds(context.Background(), s, now.Add(time.Minute)); err != nil || expired != 0 {
+		t.Errorf("second ExpireHolds() = %d, %v; want nothing left to expire", expired, err)
+	}
+}
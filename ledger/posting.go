@@ -0,0 +1,171 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+)
+
+// ErrInvalidAmount is returned for a hold, capture or reversal amount that is not positive
+var ErrInvalidAmount = errors.New("amount must be positive")
+
+// Policy configures which postings accounts accept depending on their state
+type Policy struct {
+	// FrozenAcceptsCredits lets frozen accounts receive credits; debits are always rejected
+	FrozenAcceptsCredits bool
+}
+
+// DefaultPolicy lets frozen accounts receive credits
+var DefaultPolicy = Policy{FrozenAcceptsCredits: true}
+
+// CheckAccountStatus reports whether customer's account accepts a posting of
+// the given type. Closed accounts reject everything with
+// models.ErrAccountClosed; frozen accounts reject debits, and credits unless
+// the policy allows them, with models.ErrAccountFrozen.
+func (p Policy) CheckAccountStatus(customer models.Customer, transactionType string) error {
+	switch {
+	case customer.IsClosed():
+		return models.ErrAccountClosed
+	case customer.IsFrozen() && (transactionType != "credit" || !p.FrozenAcceptsCredits):
+		return models.ErrAccountFrozen
+	}
+	return nil
+}
+
+// ApplyTransaction posts a single credit or debit inside tx and returns the
+// customer's new balance in the transaction's currency. Postings the account
+// state does not allow fail as described by Policy.CheckAccountStatus, and
+// debits larger than the available balance in that currency, which excludes
+// funds reserved by holds, plus the customer's overdraft limit in the default
+// currency fail with models.ErrInsufficientFunds.
+func ApplyTransaction(tx store.Tx, t models.Transaction, policy Policy) (models.Money, error) {
+	// Get current customer
+	customer, err := tx.GetCustomer(t.CustomerID)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	if err := policy.CheckAccountStatus(customer, t.Type); err != nil {
+		return models.Money{}, err
+	}
+
+	// Check for insufficient funds before updating balance
+	currency := t.CurrencyCode()
+	if t.Type == "debit" && !customer.CanDebit(currency, t.Amount) {
+		return models.Money{}, models.ErrInsufficientFunds
+	}
+
+	// A transaction posted after a later-timestamped one of the customer, as
+	// when it was retried or replayed, is restamped with the time it is posted
+	// at, so that timestamps follow the sequence and as-of balances and
+	// statements agree with the balance snapshots
+	if last := customer.LastPostedAt; last != nil && t.Timestamp.Before(*last) {
+		t.Timestamp = models.GenerateTimestamp()
+		if t.Timestamp.Before(*last) {
+			t.Timestamp = *last
+		}
+	}
+
+	// Update the balance in the transaction's currency and take the
+	// customer's next sequence number, which is shared by all currencies
+	balanceBefore := customer.BalanceIn(currency).Balance
+	balanceAfter, err := t.CalculateNewBalance(balanceBefore)
+	if err != nil {
+		return models.Money{}, err
+	}
+	customer.LastSequence++
+	if err := tx.UpdateBalance(t.CustomerID, currency, balanceAfter, customer.LastSequence, t.Timestamp); err != nil {
+		return models.Money{}, err
+	}
+
+	// Insert transaction together with its balance snapshot
+	t.Sequence = customer.LastSequence
+	t.BalanceBefore = &balanceBefore
+	t.BalanceAfter = &balanceAfter
+	if err := tx.InsertTransaction(t); err != nil {
+		return models.Money{}, err
+	}
+
+	// Record the matching journal entry
+	counterAccount := models.SystemAccountCash
+	switch {
+	case t.TransferID != "":
+		counterAccount = models.SystemAccountTransferClearing
+	case t.ConversionID != "":
+		counterAccount = models.SystemAccountFXPosition
+	}
+	if err := tx.InsertJournalEntry(models.NewPostingEntry(t, counterAccount)); err != nil {
+		return models.Money{}, err
+	}
+	return balanceAfter, nil
+}
+
+// OpenAccount stores a new customer and journals its opening balances, if
+// any, against the opening balance system account of each currency
+func OpenAccount(ctx context.Context, ledgerStore store.LedgerStore, customer models.Customer) error {
+	return ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		if err := tx.InsertCustomer(customer); err != nil {
+			return err
+		}
+		for _, currency := range customer.Currencies() {
+			opening := customer.BalanceIn(currency).Balance
+			if opening.IsZero() {
+				continue
+			}
+			err := tx.InsertJournalEntry(models.JournalEntry{
+				EntryID:     models.GenerateEntryID(),
+				Description: "opening balance",
+				Lines: []models.JournalLine{
+					{AccountID: models.AccountInCurrency(models.CustomerAccountID(customer.CustomerID), currency), Amount: opening.Neg()},
+					{AccountID: models.AccountInCurrency(models.SystemAccountOpeningBalance, currency), Amount: opening},
+				},
+				Timestamp: models.GenerateTimestamp(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return audit.Record(ctx, tx, models.AuditCustomerCreate, models.AuditTargetCustomer, customer.CustomerID, nil, customer)
+	})
+}
+
+// JournalBalance returns a customer's balance in currency as derived from the
+// journal. Customer accounts are liabilities, so their balance is the negated
+// net of their journal lines.
+func JournalBalance(ctx context.Context, journalStore store.JournalStore, customerID, currency string) (models.Money, error) {
+	net, err := journalStore.GetAccountBalance(ctx, models.AccountInCurrency(models.CustomerAccountID(customerID), currency))
+	if err != nil {
+		return models.Money{}, err
+	}
+	return net.Neg(), nil
+}
+
+// ExecuteTransfer debits the source and credits the destination of transfer
+// in one store transaction. Either both legs are posted or neither is. It
+// returns the two legs and the source customer's new balance in the
+// transfer's currency.
+func ExecuteTransfer(ctx context.Context, ledgerStore store.LedgerStore, transfer models.Transfer, policy Policy) (models.Transaction, models.Transaction, models.Money, error) {
+	if err := transfer.Validate(); err != nil {
+		return models.Transaction{}, models.Transaction{}, models.Money{}, err
+	}
+
+	debit, credit := transfer.Legs()
+	var sourceBalance models.Money
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		var err error
+		sourceBalance, err = ApplyTransaction(tx, debit, policy)
+		if err != nil {
+			return err
+		}
+		if _, err = ApplyTransaction(tx, credit, policy); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditTransferCreate, models.AuditTargetTransfer, transfer.TransferID, nil, transfer)
+	})
+	if err != nil {
+		return models.Transaction{}, models.Transaction{}, models.Money{}, err
+	}
+	return debit, credit, sourceBalance, nil
+}
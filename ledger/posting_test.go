@@ -0,0 +1,275 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+	"testing"
+	"time"
+)
+
+func setupTestStore(t *testing.T) *store.MemoryStore {
+	t.Helper()
+	s := store.NewMemoryStore()
+	for id, balance := range map[string]string{"alice": "100.00", "bob": "10.00"} {
+		if err := OpenAccount(context.Background(), s, models.Customer{CustomerID: id, Balance: models.MustParseMoney(balance)}); err != nil {
+			t.Fatalf("Failed to create customer %s: %v", id, err)
+		}
+	}
+	return s
+}
+
+func balanceOf(t *testing.T, s store.LedgerStore, customerID string) models.Money {
+	t.Helper()
+	customer, err := s.GetCustomer(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("Failed to load customer %s: %v", customerID, err)
+	}
+	return customer.Balance
+}
+
+func TestExecuteTransfer(t *testing.T) {
+	s := setupTestStore(t)
+	transfer := models.Transfer{
+		TransferID:     "tr1",
+		FromCustomerID: "alice",
+		ToCustomerID:   "bob",
+		Amount:         models.MustParseMoney("40.00"),
+		Timestamp:      time.Now(),
+	}
+
+	debit, credit, balance, err := ExecuteTransfer(context.Background(), s, transfer, DefaultPolicy)
+	if err != nil {
+		t.Fatalf("ExecuteTransfer() error = %v", err)
+	}
+	if !balance.Equal(models.MustParseMoney("60")) {
+		t.Errorf("source balance = %s, want 60", balance)
+	}
+	if got := balanceOf(t, s, "bob"); !got.Equal(models.MustParseMoney("50")) {
+		t.Errorf("destination balance = %s, want 50", got)
+	}
+	if debit.TransferID != "tr1" || credit.TransferID != "tr1" {
+		t.Errorf("legs carry transfer IDs %q and %q, want tr1", debit.TransferID, credit.TransferID)
+	}
+
+	for _, id := range []string{"alice", "bob"} {
+		history, _ := s.GetTransactionHistory(context.Background(), id)
+		if len(history) != 1 || history[0].TransferID != "tr1" {
+			t.Errorf("history of %s = %+v, want one leg of tr1", id, history)
+		}
+	}
+}
+
+func TestExecuteTransferRollsBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		to      string
+		amount  string
+		wantErr error
+	}{
+		{name: "insufficient funds", to: "bob", amount: "100.01", wantErr: models.ErrInsufficientFunds},
+		{name: "unknown destination", to: "carol", amount: "1.00", wantErr: store.ErrCustomerNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setupTestStore(t)
+			transfer := models.Transfer{
+				TransferID:     "tr1",
+				FromCustomerID: "alice",
+				ToCustomerID:   tt.to,
+				Amount:         models.MustParseMoney(tt.amount),
+				Timestamp:      time.Now(),
+			}
+
+			_, _, _, err := ExecuteTransfer(context.Background(), s, transfer, DefaultPolicy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExecuteTransfer() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := balanceOf(t, s, "alice"); !got.Equal(models.MustParseMoney("100")) {
+				t.Errorf("source balance = %s, want 100 after rollback", got)
+			}
+			if history, _ := s.GetTransactionHistory(context.Background(), "alice"); len(history) != 0 {
+				t.Errorf("source history = %+v, want empty after rollback", history)
+			}
+			if entries, _ := s.ListAuditEntries(context.Background(), store.AuditQuery{TargetID: "tr1"}); len(entries) != 0 {
+				t.Errorf("audit entries = %+v, want none after rollback", entries)
+			}
+		})
+	}
+}
+
+func TestExecuteTransferIsAudited(t *testing.T) {
+	s := setupTestStore(t)
+	origin := models.AuditOrigin{ActorType: models.ActorAPIKey, ActorID: "key1", RequestID: "req1", SourceIP: "203.0.113.7"}
+	ctx := audit.NewContext(context.Background(), origin)
+	transfer := models.Transfer{
+		TransferID:     "tr1",
+		FromCustomerID: "alice",
+		ToCustomerID:   "bob",
+		Amount:         models.MustParseMoney("40.00"),
+		Timestamp:      time.Now(),
+	}
+	if _, _, _, err := ExecuteTransfer(ctx, s, transfer, DefaultPolicy); err != nil {
+		t.Fatalf("ExecuteTransfer() error = %v", err)
+	}
+
+	entries, err := s.ListAuditEntries(context.Background(), store.AuditQuery{TargetID: "tr1"})
+	if err != nil {
+		t.Fatalf("ListAuditEntries() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("audit entries = %+v, want one", entries)
+	}
+	entry := entries[0]
+	if entry.Action != models.AuditTransferCreate || entry.TargetType != models.AuditTargetTransfer {
+		t.Errorf("entry is %s of a %s, want %s of a %s", entry.Action, entry.TargetType, models.AuditTransferCreate, models.AuditTargetTransfer)
+	}
+	if entry.AuditOrigin != origin {
+		t.Errorf("entry origin = %+v, want %+v", entry.AuditOrigin, origin)
+	}
+	if entry.Before != nil || entry.After == nil {
+		t.Errorf("entry snapshots before %s and after %s, want only after", entry.Before, entry.After)
+	}
+
+	// Accounts opened without an origin are audited as made by the service
+	opened, _ := s.ListAuditEntries(context.Background(), store.AuditQuery{TargetID: "alice", Action: models.AuditCustomerCreate})
+	if len(opened) != 1 || opened[0].ActorType != models.ActorSystem {
+		t.Errorf("audit entries of opening alice = %+v, want one by the system", opened)
+	}
+}
+
+func TestApplyTransactionOverflow(t *testing.T) {
+	s := setupTestStore(t)
+	credit := models.Transaction{TransactionID: "t1", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("92233720368547758"), Timestamp: time.Now()}
+	err := s.WithTransaction(context.Background(), func(tx store.Tx) error {
+		_, err := ApplyTransaction(tx, credit, DefaultPolicy)
+		return err
+	})
+	if !errors.Is(err, models.ErrMoneyOverflow) {
+		t.Fatalf("ApplyTransaction() error = %v, want %v", err, models.ErrMoneyOverflow)
+	}
+	if got := balanceOf(t, s, "alice"); !got.Equal(models.MustParseMoney("100")) {
+		t.Errorf("balance = %s, want 100 after the overflow", got)
+	}
+}
+
+func TestJournalStaysBalanced(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+
+	postings := []models.Transaction{
+		{TransactionID: "t1", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("25.50"), Timestamp: time.Now()},
+		{TransactionID: "t2", CustomerID: "bob", Type: "debit", Amount: models.MustParseMoney("4.25"), Timestamp: time.Now()},
+	}
+	for _, p := range postings {
+		err := s.WithTransaction(ctx, func(tx store.Tx) error {
+			_, err := ApplyTransaction(tx, p, DefaultPolicy)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("ApplyTransaction(%s) error = %v", p.TransactionID, err)
+		}
+	}
+	transfer := models.Transfer{TransferID: "tr1", FromCustomerID: "alice", ToCustomerID: "bob", Amount: models.MustParseMoney("30"), Timestamp: time.Now()}
+	if _, _, _, err := ExecuteTransfer(ctx, s, transfer, DefaultPolicy); err != nil {
+		t.Fatalf("ExecuteTransfer() error = %v", err)
+	}
+
+	accounts, err := s.GetTrialBalance(ctx)
+	if err != nil {
+		t.Fatalf("GetTrialBalance() error = %v", err)
+	}
+	var total models.Money
+	for _, account := range accounts {
+		total = total.Add(account.Balance)
+		if account.AccountID == models.SystemAccountTransferClearing && !account.Balance.IsZero() {
+			t.Errorf("transfer clearing balance = %s, want 0", account.Balance)
+		}
+	}
+	if !total.IsZero() {
+		t.Errorf("trial balance total = %s, want 0", total)
+	}
+
+	for _, id := range []string{"alice", "bob"} {
+		derived, err := JournalBalance(ctx, s, id, models.DefaultCurrency)
+		if err != nil {
+			t.Fatalf("JournalBalance(%s) error = %v", id, err)
+		}
+		if stored := balanceOf(t, s, id); !stored.Equal(derived) {
+			t.Errorf("%s stored balance %s does not match journal balance %s", id, stored, derived)
+		}
+	}
+}
+
+func TestMultiCurrencyBalances(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+	bob, err := s.GetCustomer(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Failed to load bob: %v", err)
+	}
+	limit := models.MustParseMoney("25")
+	if _, err := UpdateCustomer(ctx, s, "bob", bob.Version, CustomerChanges{OverdraftLimit: &limit}); err != nil {
+		t.Fatalf("Setting the overdraft limit failed: %v", err)
+	}
+	start := time.Now().Add(-time.Hour)
+	posting := func(id, transactionType, amount, currency string, offset time.Duration) models.Transaction {
+		return models.Transaction{TransactionID: id, CustomerID: "bob", Type: transactionType, Amount: models.MustParseMoney(amount), Currency: currency, Timestamp: start.Add(offset)}
+	}
+
+	if err := post(t, s, posting("t1", "credit", "50", "EUR", 0)); err != nil {
+		t.Fatalf("EUR credit error = %v", err)
+	}
+	if err := post(t, s, posting("t2", "debit", "20", "EUR", time.Minute)); err != nil {
+		t.Fatalf("EUR debit error = %v", err)
+	}
+	if err := post(t, s, posting("t3", "debit", "5", "USD", 2*time.Minute)); err != nil {
+		t.Fatalf("USD debit error = %v", err)
+	}
+	// The USD balance and overdraft do not cover a EUR shortfall
+	if err := post(t, s, posting("t4", "debit", "30.01", "EUR", 3*time.Minute)); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("EUR debit beyond the EUR balance error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+
+	customer, err := s.GetCustomer(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Failed to load bob: %v", err)
+	}
+	if got := customer.BalanceIn("USD").Balance; !got.Equal(models.MustParseMoney("5")) {
+		t.Errorf("USD balance = %s, want 5", got)
+	}
+	if got := customer.BalanceIn("EUR").Balance; !got.Equal(models.MustParseMoney("30")) {
+		t.Errorf("EUR balance = %s, want 30", got)
+	}
+	if got := customer.Currencies(); len(got) != 2 || got[0] != "USD" || got[1] != "EUR" {
+		t.Errorf("Currencies() = %v, want [USD EUR]", got)
+	}
+
+	history, err := s.GetTransactionHistory(ctx, "bob")
+	if err != nil {
+		t.Fatalf("GetTransactionHistory() error = %v", err)
+	}
+	if err := VerifyHistory(customer, history); err != nil {
+		t.Errorf("VerifyHistory() error = %v", err)
+	}
+	for currency, want := range map[string]string{"USD": "5", "EUR": "30"} {
+		derived, err := JournalBalance(ctx, s, "bob", currency)
+		if err != nil {
+			t.Fatalf("JournalBalance(%s) error = %v", currency, err)
+		}
+		if !derived.Equal(models.MustParseMoney(want)) {
+			t.Errorf("%s journal balance = %s, want %s", currency, derived, want)
+		}
+	}
+
+	asOf, err := BalanceAsOf(ctx, s, "bob", "EUR", start.Add(90*time.Second))
+	if err != nil {
+		t.Fatalf("BalanceAsOf() error = %v", err)
+	}
+	if !asOf.Balance.Equal(models.MustParseMoney("30")) || asOf.LastTransactionID != "t2" {
+		t.Errorf("EUR balance as of t2 = %s after %q, want 30 after t2", asOf.Balance, asOf.LastTransactionID)
+	}
+}
@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/audit"
+	"ledger-service/models"
+	"ledger-service/store"
+)
+
+// ReverseTransaction posts a compensating transaction for all, or with a
+// non-nil amount part, of the posted transaction originalID and records the
+// reversal on the original. The reversals of a transaction never add up to
+// more than its amount: reversing a fully reversed transaction fails with
+// models.ErrAlreadyReversed and reversing more than is left with
+// models.ErrReversalExceedsOriginal. Reversals and the legs of transfers and
+// conversions cannot be reversed. The compensating transaction is posted like
+// any other, so policy and the available balance apply to it, and it gets a
+// completed status record like a queued transaction. The reversal is in the original's
+// currency; a non-empty currency that differs from it fails with
+// models.ErrCurrencyMismatch. It returns the compensating transaction, the
+// updated original and the customer's new balance.
+func ReverseTransaction(ctx context.Context, ledgerStore store.LedgerStore, originalID string, amount *models.Money, currency, reason string, policy Policy) (models.Transaction, models.Transaction, models.Money, error) {
+	if amount != nil && !amount.IsPositive() {
+		return models.Transaction{}, models.Transaction{}, models.Money{}, ErrInvalidAmount
+	}
+
+	var (
+		reversal models.Transaction
+		original models.Transaction
+		balance  models.Money
+	)
+	err := ledgerStore.WithTransaction(ctx, func(tx store.Tx) error {
+		var err error
+		original, err = tx.GetTransaction(originalID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf != "" || original.TransferID != "" || original.ConversionID != "" {
+			return models.ErrNotReversible
+		}
+		if currency != "" && currency != original.CurrencyCode() {
+			return models.ErrCurrencyMismatch
+		}
+
+		remaining := original.RemainingReversible()
+		if !remaining.IsPositive() {
+			return models.ErrAlreadyReversed
+		}
+		reverse := remaining
+		if amount != nil {
+			if reverse, err = inCurrencyOf(*amount, original.CurrencyCode()); err != nil {
+				return err
+			}
+			if reverse.Cmp(remaining) > 0 {
+				return models.ErrReversalExceedsOriginal
+			}
+		}
+
+		reversal = models.Transaction{
+			TransactionID:  models.GenerateTransactionID(),
+			CustomerID:     original.CustomerID,
+			Type:           models.ReversedType(original.Type),
+			Amount:         reverse,
+			Currency:       original.Currency,
+			Timestamp:      models.GenerateTimestamp(),
+			ReversalOf:     original.TransactionID,
+			ReversalReason: reason,
+		}
+		if balance, err = ApplyTransaction(tx, reversal, policy); err != nil {
+			return err
+		}
+		if err := tx.SaveTransactionStatus(models.CompletedStatus(reversal, balance)); err != nil {
+			return err
+		}
+
+		before := original
+		reversed := reverse
+		if original.ReversedAmount != nil {
+			reversed = original.ReversedAmount.Add(reverse)
+		}
+		original.ReversedAmount = &reversed
+		original.ReversalStatus = models.ReversalStatusPartial
+		if reversed.Cmp(original.Amount) == 0 {
+			original.ReversalStatus = models.ReversalStatusFull
+		}
+		original.ReversalIDs = append(original.ReversalIDs, reversal.TransactionID)
+		if err := tx.UpdateReversalState(original); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditTransactionReverse, models.AuditTargetTransaction, original.TransactionID, before, original)
+	})
+	if err != nil {
+		return models.Transaction{}, models.Transaction{}, models.Money{}, err
+	}
+	return reversal, original, balance, nil
+}
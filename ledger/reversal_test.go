@@ -0,0 +1,103 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"testing"
+	"time"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	s := setupTestStore(t)
+	if err := post(t, s, models.Transaction{TransactionID: "charge", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("40"), Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Posting failed: %v", err)
+	}
+	transfer := models.Transfer{TransferID: "tr1", FromCustomerID: "alice", ToCustomerID: "bob", Amount: models.MustParseMoney("5"), Timestamp: time.Now()}
+	leg, _, _, err := ExecuteTransfer(context.Background(), s, transfer, DefaultPolicy)
+	if err != nil {
+		t.Fatalf("ExecuteTransfer() error = %v", err)
+	}
+	money := func(s string) *models.Money {
+		m := models.MustParseMoney(s)
+		return &m
+	}
+
+	tests := []struct {
+		name        string
+		originalID  string
+		amount      *models.Money
+		wantErr     error
+		wantBalance string
+		wantStatus  string
+	}{
+		{"partial", "charge", money("15"), nil, "70", models.ReversalStatusPartial},
+		{"more than is left", "charge", money("25.01"), models.ErrReversalExceedsOriginal, "", ""},
+		{"rest", "charge", nil, nil, "95", models.ReversalStatusFull},
+		{"fully reversed", "charge", money("1"), models.ErrAlreadyReversed, "", ""},
+		{"transfer leg", leg.TransactionID, nil, models.ErrNotReversible, "", ""},
+		{"missing", "missing", nil, store.ErrTransactionNotFound, "", ""},
+		{"non-positive amount", "charge", money("0"), ErrInvalidAmount, "", ""},
+	}
+	var reversalIDs []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reversal, original, balance, err := ReverseTransaction(context.Background(), s, tt.originalID, tt.amount, "", "duplicate charge", DefaultPolicy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReverseTransaction() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			reversalIDs = append(reversalIDs, reversal.TransactionID)
+			if reversal.Type != "credit" || reversal.ReversalOf != "charge" || reversal.ReversalReason != "duplicate charge" {
+				t.Errorf("reversal = %+v, want a credit reversing charge", reversal)
+			}
+			if !balance.Equal(models.MustParseMoney(tt.wantBalance)) || original.ReversalStatus != tt.wantStatus {
+				t.Errorf("balance %s and original status %q, want %s and %q", balance, original.ReversalStatus, tt.wantBalance, tt.wantStatus)
+			}
+		})
+	}
+
+	history, _ := s.GetTransactionHistory(context.Background(), "alice")
+	var original models.Transaction
+	reversals := 0
+	for _, tx := range history {
+		switch {
+		case tx.TransactionID == "charge":
+			original = tx
+		case tx.ReversalOf == "charge":
+			reversals++
+		}
+	}
+	if reversals != 2 || original.ReversedAmount == nil || !original.ReversedAmount.Equal(models.MustParseMoney("40")) || len(original.ReversalIDs) != 2 {
+		t.Errorf("stored original = %+v with %d reversals, want 40 reversed by %v", original, reversals, reversalIDs)
+	}
+	if record, err := s.GetTransactionStatus(context.Background(), reversalIDs[0]); err != nil || record.Status != models.TransactionStatusCompleted {
+		t.Errorf("reversal status record = %+v, %v; want completed", record, err)
+	}
+
+	// Reversals cannot be reversed themselves
+	if _, _, _, err := ReverseTransaction(context.Background(), s, reversalIDs[0], nil, "", "", DefaultPolicy); !errors.Is(err, models.ErrNotReversible) {
+		t.Errorf("reversing a reversal error = %v, want %v", err, models.ErrNotReversible)
+	}
+}
+
+func TestReverseCreditNeedsFunds(t *testing.T) {
+	s := setupTestStore(t)
+	if err := post(t, s, models.Transaction{TransactionID: "deposit", CustomerID: "bob", Type: "credit", Amount: models.MustParseMoney("50"), Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Posting failed: %v", err)
+	}
+	if err := post(t, s, models.Transaction{TransactionID: "spend", CustomerID: "bob", Type: "debit", Amount: models.MustParseMoney("45"), Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Posting failed: %v", err)
+	}
+
+	if _, _, _, err := ReverseTransaction(context.Background(), s, "deposit", nil, "", "", DefaultPolicy); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("reversing a spent credit error = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+	history, _ := s.GetTransactionHistory(context.Background(), "bob")
+	if len(history) != 2 || history[0].ReversalStatus != "" {
+		t.Errorf("history after a failed reversal = %+v, want it unchanged", history)
+	}
+}
@@ -0,0 +1,79 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"time"
+)
+
+// ErrInvalidPeriod is returned for a statement period that does not end after it starts
+var ErrInvalidPeriod = errors.New("statement period must end after it starts")
+
+// Statement summarises a customer's account in one currency over the period [From, To)
+type Statement struct {
+	CustomerID     string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance models.Money
+	ClosingBalance models.Money
+	TotalCredits   models.Money
+	TotalDebits    models.Money
+	Entries        []StatementEntry
+}
+
+// StatementEntry is a transaction on a statement with the balance after it
+type StatementEntry struct {
+	Transaction    models.Transaction
+	RunningBalance models.Money
+}
+
+// BuildStatement builds the statement of a customer for the transactions in
+// currency timestamped from from up to, but excluding, to. The opening balance is the
+// balance as of the start of the period and every entry's running balance is
+// carried forward from it, so transactions without a balance snapshot are
+// covered too. An empty currency means the default currency.
+func BuildStatement(ctx context.Context, ledgerStore store.LedgerStore, customerID, currency string, from, to time.Time) (Statement, error) {
+	currency = models.CurrencyOrDefault(currency)
+	if !to.After(from) {
+		return Statement{}, ErrInvalidPeriod
+	}
+
+	opening, err := BalanceAsOf(ctx, ledgerStore, customerID, currency, from.Add(-time.Nanosecond))
+	if err != nil {
+		return Statement{}, err
+	}
+	transactions, err := ledgerStore.QueryTransactions(ctx, store.TransactionQuery{CustomerID: customerID, Currency: currency, From: from, To: to})
+	if err != nil {
+		return Statement{}, err
+	}
+	sortBySequence(transactions)
+
+	statement := Statement{
+		CustomerID:     customerID,
+		Currency:       currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening.Balance,
+		Entries:        make([]StatementEntry, len(transactions)),
+	}
+	balance := opening.Balance
+	for i, t := range transactions {
+		if balance, err = t.CalculateNewBalance(balance); err != nil {
+			return Statement{}, err
+		}
+		if t.Type == "credit" {
+			statement.TotalCredits, err = statement.TotalCredits.CheckedAdd(t.Amount)
+		} else {
+			statement.TotalDebits, err = statement.TotalDebits.CheckedAdd(t.Amount)
+		}
+		if err != nil {
+			return Statement{}, err
+		}
+		statement.Entries[i] = StatementEntry{Transaction: t, RunningBalance: balance}
+	}
+	statement.ClosingBalance = balance
+	return statement, nil
+}
@@ -0,0 +1,61 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildStatement(t *testing.T) {
+	s := setupTestStore(t)
+	day := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []models.Transaction{
+		{TransactionID: "t1", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("50"), Timestamp: day.Add(-time.Hour)},
+		{TransactionID: "t2", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("30"), Timestamp: day},
+		{TransactionID: "t3", CustomerID: "alice", Type: "credit", Amount: models.MustParseMoney("5"), Timestamp: day.Add(12 * time.Hour)},
+		{TransactionID: "t4", CustomerID: "alice", Type: "debit", Amount: models.MustParseMoney("15"), Timestamp: day.AddDate(0, 0, 1)},
+	} {
+		if err := post(t, s, p); err != nil {
+			t.Fatalf("Posting %s failed: %v", p.TransactionID, err)
+		}
+	}
+
+	statement, err := BuildStatement(context.Background(), s, "alice", models.DefaultCurrency, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("BuildStatement() error = %v", err)
+	}
+
+	// The period includes its start and excludes its end
+	var got []string
+	for _, entry := range statement.Entries {
+		got = append(got, entry.Transaction.TransactionID+"="+entry.RunningBalance.String())
+	}
+	if got, want := strings.Join(got, " "), "t2=120.00 t3=125.00"; got != want {
+		t.Errorf("entries = %s, want %s", got, want)
+	}
+	for name, pair := range map[string][2]models.Money{
+		"opening balance": {statement.OpeningBalance, models.MustParseMoney("150")},
+		"total credits":   {statement.TotalCredits, models.MustParseMoney("5")},
+		"total debits":    {statement.TotalDebits, models.MustParseMoney("30")},
+		"closing balance": {statement.ClosingBalance, models.MustParseMoney("125")},
+	} {
+		if !pair[0].Equal(pair[1]) {
+			t.Errorf("%s = %s, want %s", name, pair[0], pair[1])
+		}
+	}
+
+	empty, err := BuildStatement(context.Background(), s, "alice", models.DefaultCurrency, day.AddDate(0, 1, 0), day.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatalf("BuildStatement() of a quiet period error = %v", err)
+	}
+	if len(empty.Entries) != 0 || !empty.OpeningBalance.Equal(models.MustParseMoney("110")) || !empty.ClosingBalance.Equal(empty.OpeningBalance) {
+		t.Errorf("quiet period statement = %+v, want no entries and 110 opening and closing", empty)
+	}
+
+	if _, err := BuildStatement(context.Background(), s, "alice", models.DefaultCurrency, day, day); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("BuildStatement() of empty period error = %v, want %v", err, ErrInvalidPeriod)
+	}
+}
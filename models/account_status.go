@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer account states
+const (
+	CustomerStatusActive = "active"
+	CustomerStatusFrozen = "frozen"
+	CustomerStatusClosed = "closed"
+)
+
+// ErrAccountClosed is returned when a transaction targets a closed account
+var ErrAccountClosed = errors.New("account is closed")
+
+// ErrAccountFrozen is returned when a frozen account does not accept a transaction
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// ErrAccountNotEmpty is returned when closing an account whose balance is not zero
+var ErrAccountNotEmpty = errors.New("account balance is not zero")
+
+// ErrInvalidStatusTransition is returned when an account cannot move to the requested state
+var ErrInvalidStatusTransition = errors.New("invalid account status transition")
+
+// statusTransitions lists the states each account state may move to.
+// Closed is final.
+var statusTransitions = map[string][]string{
+	CustomerStatusActive: {CustomerStatusFrozen, CustomerStatusClosed},
+	CustomerStatusFrozen: {CustomerStatusActive, CustomerStatusClosed},
+}
+
+// IsValidCustomerStatus reports whether status is a known account state
+func IsValidCustomerStatus(status string) bool {
+	switch status {
+	case CustomerStatusActive, CustomerStatusFrozen, CustomerStatusClosed:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether an account may move from one state to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountStatusChange is an audit record of an account state change
+// @Description AccountStatusChange records who changed the state of an account, when and why
+type AccountStatusChange struct {
+	ChangeID   string    `json:"change_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CustomerID string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	From       string    `json:"from" bson:"from" example:"active"`
+	To         string    `json:"to" bson:"to" example:"frozen"`
+	Reason     string    `json:"reason" bson:"reason" example:"fraud investigation"`
+	Actor      string    `json:"actor" bson:"actor" example:"ops@example.com"`
+	Timestamp  time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z"`
+}
+
+// GenerateStatusChangeID generates a new unique status change ID
+func GenerateStatusChangeID() string {
+	return uuid.New().String()
+}
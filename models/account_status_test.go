@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{CustomerStatusActive, CustomerStatusFrozen, true},
+		{CustomerStatusActive, CustomerStatusClosed, true},
+		{CustomerStatusFrozen, CustomerStatusActive, true},
+		{CustomerStatusFrozen, CustomerStatusClosed, true},
+		{CustomerStatusActive, CustomerStatusActive, false},
+		{CustomerStatusClosed, CustomerStatusActive, false},
+		{CustomerStatusClosed, CustomerStatusFrozen, false},
+		{CustomerStatusActive, "dormant", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomerAccountStatus(t *testing.T) {
+	legacy := Customer{CustomerID: "c1"}
+	if legacy.AccountStatus() != CustomerStatusActive || legacy.IsFrozen() || legacy.IsClosed() {
+		t.Errorf("Customer without status reports %q, want active", legacy.AccountStatus())
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actor types of audit entries
+const (
+	ActorAPIKey = "api_key"
+	ActorToken  = "token"
+	// ActorAnonymous made a request while authentication was disabled
+	ActorAnonymous = "anonymous"
+	// ActorSystem is the service itself, such as the hold expiry sweep
+	ActorSystem = "system"
+)
+
+// Audited actions
+const (
+	AuditCustomerCreate       = "customer.create"
+	AuditCustomerUpdate       = "customer.update"
+	AuditCustomerStatusChange = "customer.status_change"
+	AuditTransactionPost      = "transaction.post"
+	AuditTransactionReverse   = "transaction.reverse"
+	AuditTransferCreate       = "transfer.create"
+	AuditHoldPlace            = "hold.place"
+	AuditHoldCapture          = "hold.capture"
+	AuditHoldVoid             = "hold.void"
+	AuditHoldExpire           = "hold.expire"
+	AuditQuoteCreate          = "fx_quote.create"
+	AuditConversionExecute    = "conversion.execute"
+	AuditExchangeRateCreate   = "exchange_rate.create"
+	AuditAPIKeyCreate         = "api_key.create"
+	AuditAPIKeyRotate         = "api_key.rotate"
+	AuditAPIKeyRevoke         = "api_key.revoke"
+	AuditDeadLetterReplay     = "dead_letter.replay"
+	AuditDeadLetterDiscard    = "dead_letter.discard"
+)
+
+// Target types of audit entries
+const (
+	AuditTargetCustomer     = "customer"
+	AuditTargetTransaction  = "transaction"
+	AuditTargetTransfer     = "transfer"
+	AuditTargetHold         = "hold"
+	AuditTargetQuote        = "fx_quote"
+	AuditTargetConversion   = "conversion"
+	AuditTargetExchangeRate = "exchange_rate"
+	AuditTargetAPIKey       = "api_key"
+	AuditTargetDeadLetter   = "dead_letter"
+)
+
+// AuditOrigin is who made a change and from which request
+type AuditOrigin struct {
+	ActorType string `json:"actor_type" bson:"actor_type" example:"api_key" enums:"api_key,token,anonymous,system"`
+	// ActorID is the API key ID or the token subject
+	ActorID   string `json:"actor_id,omitempty" bson:"actor_id,omitempty" example:"9f8e7d6c-5b4a-4321-8fed-cba987654321"`
+	RequestID string `json:"request_id,omitempty" bson:"request_id,omitempty" example:"0b9a8c7d-6e5f-4a3b-9c2d-1e0f9a8b7c6d"`
+	SourceIP  string `json:"source_ip,omitempty" bson:"source_ip,omitempty" example:"203.0.113.7"`
+}
+
+// SystemOrigin is the origin of changes the service makes on its own
+var SystemOrigin = AuditOrigin{ActorType: ActorSystem}
+
+// AuditEntry is an immutable record of a state-changing operation
+// @Description AuditEntry records who changed what, when and from where, with the target before and after the change
+type AuditEntry struct {
+	AuditID     string    `json:"audit_id" bson:"_id" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	Timestamp   time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z"`
+	AuditOrigin `bson:",inline"`
+	Action      string `json:"action" bson:"action" example:"customer.update"`
+	TargetType  string `json:"target_type" bson:"target_type" example:"customer"`
+	TargetID    string `json:"target_id" bson:"target_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	// Before and After are JSON snapshots of the target, absent for targets
+	// that did not exist before or after the change
+	Before json.RawMessage `json:"before,omitempty" bson:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `json:"after,omitempty" bson:"after,omitempty" swaggertype:"object"`
+}
+
+// GenerateAuditID generates a unique audit entry ID. The IDs are UUIDv7, so
+// they sort in the order the entries were written.
+func GenerateAuditID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
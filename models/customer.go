@@ -1,9 +1,135 @@
 package models
 
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
 // Customer represents a financial account in the system
 // @Description Customer represents a financial account that can hold balance and perform transactions
 type Customer struct {
-	CustomerID string  `json:"customer_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The unique identifier for the customer"`
-	Name       string  `json:"name" bson:"name" example:"John Doe" description:"The name of the customer"`
-	Balance    float64 `json:"balance" bson:"balance" example:"1000.00" description:"The current balance of the customer"`
-} 
\ No newline at end of file
+	CustomerID      string                     `json:"customer_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The unique identifier for the customer"`
+	Name            string                     `json:"name" bson:"name" example:"John Doe" description:"The name of the customer"`
+	Balance         Money                      `json:"balance" bson:"balance" swaggertype:"number" example:"1000.00" description:"The ledger balance of the customer in the default currency: every posted transaction, ignoring holds"`
+	HeldBalance     Money                      `json:"held_balance" bson:"held_balance" swaggertype:"number" example:"40.00" description:"The total reserved by active holds in the default currency"`
+	Balances        map[string]CurrencyBalance `json:"balances,omitempty" bson:"balances,omitempty" description:"The balances in currencies other than the default currency, keyed by ISO 4217 code"`
+	OverdraftLimit  Money                      `json:"overdraft_limit" bson:"overdraft_limit" swaggertype:"number" example:"500.00" description:"How far the available balance in the default currency may go below zero"`
+	Status          string                     `json:"status" bson:"status" example:"active" enums:"active,frozen,closed" description:"The account state"`
+	StatusReason    string                     `json:"status_reason,omitempty" bson:"status_reason,omitempty" example:"fraud investigation" description:"Why the account state last changed"`
+	StatusChangedBy string                     `json:"status_changed_by,omitempty" bson:"status_changed_by,omitempty" example:"ops@example.com" description:"Who last changed the account state"`
+	StatusChangedAt *time.Time                 `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty" example:"2025-04-06T10:45:00Z" description:"When the account state last changed"`
+	ClosedAt        *time.Time                 `json:"closed_at,omitempty" bson:"closed_at,omitempty" example:"2025-04-06T10:45:00Z" description:"When the account was closed"`
+	Version         int64                      `json:"version" bson:"version" example:"3" description:"Incremented on every change; send it back when updating the customer"`
+	LastSequence    int64                      `json:"last_sequence" bson:"last_sequence" example:"42" description:"The sequence number of the customer's latest transaction"`
+	LastPostedAt    *time.Time                 `json:"last_posted_at,omitempty" bson:"last_posted_at,omitempty" example:"2025-04-06T10:45:00Z" description:"The timestamp of the customer's latest transaction"`
+}
+
+// CurrencyBalance is a customer's ledger balance and held funds in one currency
+type CurrencyBalance struct {
+	Balance     Money `json:"balance" bson:"balance" swaggertype:"number" example:"250.00" description:"The ledger balance in this currency"`
+	HeldBalance Money `json:"held_balance" bson:"held_balance" swaggertype:"number" example:"0" description:"The total reserved by active holds in this currency"`
+}
+
+// Available returns the ledger balance less the funds reserved by active holds
+func (b CurrencyBalance) Available() Money {
+	return b.Balance.Sub(b.HeldBalance)
+}
+
+// AccountStatus returns the account state. Customers stored before account
+// states existed have no status and are active.
+func (c Customer) AccountStatus() string {
+	if c.Status == "" {
+		return CustomerStatusActive
+	}
+	return c.Status
+}
+
+// AvailableBalance returns the ledger balance in the default currency less
+// the funds reserved by active holds
+func (c Customer) AvailableBalance() Money {
+	return c.Balance.Sub(c.HeldBalance)
+}
+
+// BalanceIn returns the customer's balance in currency. The default currency
+// is kept in Balance and HeldBalance, so customers stored before other
+// currencies existed need no migration; an empty currency means the default.
+func (c Customer) BalanceIn(currency string) CurrencyBalance {
+	if currency = CurrencyOrDefault(currency); currency == DefaultCurrency {
+		return CurrencyBalance{Balance: c.Balance, HeldBalance: c.HeldBalance}
+	}
+	return c.Balances[currency]
+}
+
+// WithBalance returns a copy of the customer with its balance in currency
+// replaced. The copy does not share its Balances map with c.
+func (c Customer) WithBalance(currency string, balance CurrencyBalance) Customer {
+	if currency = CurrencyOrDefault(currency); currency == DefaultCurrency {
+		c.Balance = balance.Balance
+		c.HeldBalance = balance.HeldBalance
+		return c
+	}
+	balances := maps.Clone(c.Balances)
+	if balances == nil {
+		balances = make(map[string]CurrencyBalance)
+	}
+	balances[currency] = balance
+	c.Balances = balances
+	return c
+}
+
+// Currencies returns the currencies the customer has a balance in: the
+// default currency first and then the others in alphabetical order
+func (c Customer) Currencies() []string {
+	return append([]string{DefaultCurrency}, slices.Sorted(maps.Keys(c.Balances))...)
+}
+
+// IsEmpty reports whether every balance of the customer is zero and no funds are held
+func (c Customer) IsEmpty() bool {
+	for _, currency := range c.Currencies() {
+		balance := c.BalanceIn(currency)
+		if !balance.Balance.IsZero() || !balance.HeldBalance.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// CanDebit reports whether amount can be debited or held in currency without
+// taking the available balance below zero, or in the default currency further
+// below zero than the overdraft limit
+func (c Customer) CanDebit(currency string, amount Money) bool {
+	available := c.BalanceIn(currency).Available()
+	if CurrencyOrDefault(currency) == DefaultCurrency {
+		withOverdraft, err := available.CheckedAdd(c.OverdraftLimit)
+		if err != nil {
+			// Only a balance beyond any amount overflows with the overdraft added
+			return true
+		}
+		available = withOverdraft
+	}
+	return available.Cmp(amount) >= 0
+}
+
+// AvailableCredit returns the part of the overdraft limit that is not in use.
+// It is zero when the limit was lowered below the overdraft already taken.
+func (c Customer) AvailableCredit() Money {
+	credit := c.OverdraftLimit
+	if available := c.AvailableBalance(); available.IsNegative() {
+		credit = credit.Add(available)
+	}
+	if credit.IsNegative() {
+		return Money{}
+	}
+	return credit
+}
+
+// IsClosed reports whether the account was closed
+func (c Customer) IsClosed() bool {
+	return c.AccountStatus() == CustomerStatusClosed
+}
+
+// IsFrozen reports whether the account is frozen
+func (c Customer) IsFrozen() bool {
+	return c.AccountStatus() == CustomerStatusFrozen
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// DeadLetter is a transaction the workers gave up on after exhausting their retries
+// @Description DeadLetter holds a transaction that could not be posted, for operators to inspect, replay or discard
+type DeadLetter struct {
+	TransactionID string      `json:"transaction_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Transaction   Transaction `json:"transaction" bson:"transaction"`
+	Attempts      int         `json:"attempts" bson:"attempts" example:"5"`
+	LastError     string      `json:"last_error" bson:"last_error" example:"server selection error: context deadline exceeded"`
+	FailedAt      time.Time   `json:"failed_at" bson:"failed_at" example:"2025-04-06T10:45:00Z"`
+}
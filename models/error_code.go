@@ -0,0 +1,134 @@
+package models
+
+// ErrorCode is a machine-readable reason why a request or transaction failed
+type ErrorCode string
+
+// Error code catalogue
+const (
+	// ErrorCodeValidationFailed means the request or transaction is malformed
+	ErrorCodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	// ErrorCodeUnsupportedCurrency means the currency code is not in the currency table
+	ErrorCodeUnsupportedCurrency ErrorCode = "UNSUPPORTED_CURRENCY"
+	// ErrorCodeCurrencyMismatch means an amount is in a different currency than the hold or transaction it refers to
+	ErrorCodeCurrencyMismatch ErrorCode = "CURRENCY_MISMATCH"
+	// ErrorCodeCustomerNotFound means the customer does not exist
+	ErrorCodeCustomerNotFound ErrorCode = "CUSTOMER_NOT_FOUND"
+	// ErrorCodeInsufficientFunds means a debit exceeds the customer's balance
+	ErrorCodeInsufficientFunds ErrorCode = "INSUFFICIENT_FUNDS"
+	// ErrorCodeAccountFrozen means the customer's account does not accept the transaction
+	ErrorCodeAccountFrozen ErrorCode = "ACCOUNT_FROZEN"
+	// ErrorCodeAccountClosed means the customer's account was closed
+	ErrorCodeAccountClosed ErrorCode = "ACCOUNT_CLOSED"
+	// ErrorCodeTransactionNotFound means no posted transaction has the given ID
+	ErrorCodeTransactionNotFound ErrorCode = "TRANSACTION_NOT_FOUND"
+	// ErrorCodeNotReversible means the transaction is fully reversed already or cannot be reversed on its own
+	ErrorCodeNotReversible ErrorCode = "NOT_REVERSIBLE"
+	// ErrorCodeReversalExceedsOriginal means a reversal would reverse more than the original amount in total
+	ErrorCodeReversalExceedsOriginal ErrorCode = "REVERSAL_EXCEEDS_ORIGINAL"
+	// ErrorCodeExchangeRateNotFound means no rate of the currency pair is in effect
+	ErrorCodeExchangeRateNotFound ErrorCode = "EXCHANGE_RATE_NOT_FOUND"
+	// ErrorCodeQuoteNotFound means no quote has the given ID
+	ErrorCodeQuoteNotFound ErrorCode = "QUOTE_NOT_FOUND"
+	// ErrorCodeQuoteExpired means the quote can no longer be executed
+	ErrorCodeQuoteExpired ErrorCode = "QUOTE_EXPIRED"
+	// ErrorCodeQuoteUsed means the quote was already executed
+	ErrorCodeQuoteUsed ErrorCode = "QUOTE_ALREADY_EXECUTED"
+	// ErrorCodeConversionNotFound means no conversion has the given ID
+	ErrorCodeConversionNotFound ErrorCode = "CONVERSION_NOT_FOUND"
+	// ErrorCodeAuthenticationRequired means the request carries no credentials
+	ErrorCodeAuthenticationRequired ErrorCode = "AUTHENTICATION_REQUIRED"
+	// ErrorCodeInvalidAPIKey means the API key is unknown or was revoked
+	ErrorCodeInvalidAPIKey ErrorCode = "INVALID_API_KEY"
+	// ErrorCodeInvalidToken means the bearer token is malformed, expired or not signed by a trusted key
+	ErrorCodeInvalidToken ErrorCode = "INVALID_TOKEN"
+	// ErrorCodeInvalidSignature means the request signature is missing, malformed or does not match
+	ErrorCodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	// ErrorCodeStaleTimestamp means the signed request's timestamp is outside the replay window
+	ErrorCodeStaleTimestamp ErrorCode = "STALE_TIMESTAMP"
+	// ErrorCodeReplayedNonce means the signed request's nonce was already used
+	ErrorCodeReplayedNonce ErrorCode = "REPLAYED_NONCE"
+	// ErrorCodeInsufficientScope means the credentials do not grant the scope the request needs
+	ErrorCodeInsufficientScope ErrorCode = "INSUFFICIENT_SCOPE"
+	// ErrorCodeCustomerAccessDenied means the credentials belong to a different customer
+	ErrorCodeCustomerAccessDenied ErrorCode = "CUSTOMER_ACCESS_DENIED"
+	// ErrorCodeAPIKeyNotFound means no API key has the given ID
+	ErrorCodeAPIKeyNotFound ErrorCode = "API_KEY_NOT_FOUND"
+	// ErrorCodeAPIKeyRevoked means the API key was already revoked
+	ErrorCodeAPIKeyRevoked ErrorCode = "API_KEY_REVOKED"
+	// ErrorCodeIdempotencyConflict means an Idempotency-Key was reused with a different request
+	ErrorCodeIdempotencyConflict ErrorCode = "IDEMPOTENCY_KEY_CONFLICT"
+	// ErrorCodeStorageUnavailable means the ledger store kept failing; the request may be retried later
+	ErrorCodeStorageUnavailable ErrorCode = "STORAGE_UNAVAILABLE"
+	// ErrorCodeQueueUnavailable means the transaction could not be queued; the request may be retried
+	ErrorCodeQueueUnavailable ErrorCode = "QUEUE_UNAVAILABLE"
+	// ErrorCodeTimeout means the outcome was not known before the request timed out
+	ErrorCodeTimeout ErrorCode = "PROCESSING_TIMEOUT"
+	// ErrorCodeInternal means an unexpected error
+	ErrorCodeInternal ErrorCode = "INTERNAL_ERROR"
+)
+
+// Message returns a generic human-readable description of the code
+func (c ErrorCode) Message() string {
+	switch c {
+	case ErrorCodeValidationFailed:
+		return "The transaction is invalid"
+	case ErrorCodeUnsupportedCurrency:
+		return "The currency is not supported"
+	case ErrorCodeCurrencyMismatch:
+		return "The currency does not match"
+	case ErrorCodeCustomerNotFound:
+		return "Customer not found"
+	case ErrorCodeInsufficientFunds:
+		return "Insufficient funds"
+	case ErrorCodeAccountFrozen:
+		return "The account is frozen"
+	case ErrorCodeAccountClosed:
+		return "The account is closed"
+	case ErrorCodeTransactionNotFound:
+		return "Transaction not found"
+	case ErrorCodeNotReversible:
+		return "The transaction cannot be reversed"
+	case ErrorCodeReversalExceedsOriginal:
+		return "The reversal exceeds the amount left to reverse"
+	case ErrorCodeExchangeRateNotFound:
+		return "No exchange rate is in effect for the currency pair"
+	case ErrorCodeQuoteNotFound:
+		return "Quote not found"
+	case ErrorCodeQuoteExpired:
+		return "The quote has expired"
+	case ErrorCodeQuoteUsed:
+		return "The quote was already executed"
+	case ErrorCodeConversionNotFound:
+		return "Conversion not found"
+	case ErrorCodeAuthenticationRequired:
+		return "Authentication is required"
+	case ErrorCodeInvalidAPIKey:
+		return "The API key is invalid or was revoked"
+	case ErrorCodeInvalidToken:
+		return "The bearer token is invalid or expired"
+	case ErrorCodeInvalidSignature:
+		return "The request signature is invalid"
+	case ErrorCodeStaleTimestamp:
+		return "The request timestamp is outside the replay window"
+	case ErrorCodeReplayedNonce:
+		return "The request nonce was already used"
+	case ErrorCodeInsufficientScope:
+		return "The credentials do not allow this request"
+	case ErrorCodeCustomerAccessDenied:
+		return "The credentials do not allow access to this customer"
+	case ErrorCodeAPIKeyNotFound:
+		return "API key not found"
+	case ErrorCodeAPIKeyRevoked:
+		return "The API key was revoked"
+	case ErrorCodeIdempotencyConflict:
+		return "Idempotency-Key was already used with a different request"
+	case ErrorCodeStorageUnavailable:
+		return "The ledger is temporarily unavailable"
+	case ErrorCodeQueueUnavailable:
+		return "Failed to queue transaction"
+	case ErrorCodeTimeout:
+		return "Transaction processing timed out"
+	default:
+		return "Internal server error"
+	}
+}
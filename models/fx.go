@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quote states. A quote is open until a conversion executes it; an open quote
+// past its expiry time can no longer be executed.
+const (
+	QuoteStatusOpen     = "open"
+	QuoteStatusExecuted = "executed"
+	QuoteStatusExpired  = "expired"
+)
+
+// SystemAccountFXPosition is the counterpart of conversion legs. Its balance
+// in each currency is the service's position in that currency.
+const SystemAccountFXPosition = "system:fx_position"
+
+// ErrInvalidExchangeRate is returned for a rate table entry that is incomplete, not positive or whose spread is not below one
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+// ErrQuoteExpired is returned when executing a quote after its expiry time
+var ErrQuoteExpired = errors.New("quote has expired")
+
+// ErrQuoteUsed is returned when executing a quote that was already executed
+var ErrQuoteUsed = errors.New("quote was already executed")
+
+// ExchangeRate is the price of one unit of a base currency in a quote
+// currency, in effect from a given moment until a later rate for the same
+// pair takes over
+// @Description ExchangeRate is an effective-dated entry of the rate table
+type ExchangeRate struct {
+	RateID        string    `json:"rate_id" bson:"_id" example:"EUR/USD@2025-04-01T00:00:00Z" description:"The identifier of the rate, derived from the pair and the effective time"`
+	BaseCurrency  string    `json:"base_currency" bson:"base_currency" example:"EUR" description:"The currency being sold"`
+	QuoteCurrency string    `json:"quote_currency" bson:"quote_currency" example:"USD" description:"The currency being bought"`
+	Rate          Money     `json:"rate" bson:"rate" swaggertype:"number" example:"1.0842" description:"Units of the quote currency per unit of the base currency, before the spread"`
+	Spread        Money     `json:"spread" bson:"spread" swaggertype:"number" example:"0.0025" description:"The fraction of the rate kept by the service"`
+	EffectiveFrom time.Time `json:"effective_from" bson:"effective_from" example:"2025-04-01T00:00:00Z" description:"When the rate takes effect"`
+	Source        string    `json:"source,omitempty" bson:"source,omitempty" example:"rates.json" description:"Where the rate came from"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at" example:"2025-03-31T18:00:00Z" description:"When the rate was added to the table"`
+}
+
+// ExchangeRateID returns the ID of the rate of a currency pair taking effect
+// at effectiveFrom. Adding a rate for the same pair and moment replaces it.
+func ExchangeRateID(base, quote string, effectiveFrom time.Time) string {
+	return base + "/" + quote + "@" + effectiveFrom.UTC().Format(time.RFC3339Nano)
+}
+
+// Validate checks that the rate converts between two supported currencies at
+// a positive price with a spread of at least zero and below one
+func (r *ExchangeRate) Validate() error {
+	if _, err := lookupCanonicalCurrency(r.BaseCurrency); err != nil {
+		return err
+	}
+	if _, err := lookupCanonicalCurrency(r.QuoteCurrency); err != nil {
+		return err
+	}
+	if r.BaseCurrency == r.QuoteCurrency {
+		return fmt.Errorf("%w: base and quote currencies must differ", ErrInvalidExchangeRate)
+	}
+	if r.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective_from is required", ErrInvalidExchangeRate)
+	}
+	if !r.Rate.IsPositive() {
+		return fmt.Errorf("%w: rate must be positive", ErrInvalidExchangeRate)
+	}
+	if r.Spread.IsNegative() || r.Spread.Cmp(NewMoney(1, 0)) >= 0 {
+		return fmt.Errorf("%w: spread must be at least 0 and below 1", ErrInvalidExchangeRate)
+	}
+	return nil
+}
+
+// CustomerRate returns the rate a customer converts at: the rate less the
+// spread, truncated to MaxMoneyScale decimal places
+func (r ExchangeRate) CustomerRate() (Money, error) {
+	return r.Rate.MulTruncated(NewMoney(1, 0).Sub(r.Spread), MaxMoneyScale)
+}
+
+// FXQuote locks an exchange rate for converting an amount of one of a
+// customer's currencies into another until it expires
+// @Description FXQuote prices a conversion and locks the rate until the quote expires or is executed
+type FXQuote struct {
+	QuoteID      string    `json:"quote_id" bson:"_id" example:"9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d" description:"The unique identifier for the quote"`
+	CustomerID   string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The customer the quote was made for"`
+	FromCurrency string    `json:"from_currency" bson:"from_currency" example:"EUR" description:"The currency debited"`
+	ToCurrency   string    `json:"to_currency" bson:"to_currency" example:"USD" description:"The currency credited"`
+	SourceAmount Money     `json:"source_amount" bson:"source_amount" swaggertype:"number" example:"100.00" description:"The amount debited in from_currency"`
+	TargetAmount Money     `json:"target_amount" bson:"target_amount" swaggertype:"number" example:"108.14" description:"The amount credited in to_currency"`
+	RateID       string    `json:"rate_id" bson:"rate_id" example:"EUR/USD@2025-04-01T00:00:00Z" description:"The rate table entry the quote was priced from"`
+	Rate         Money     `json:"rate" bson:"rate" swaggertype:"number" example:"1.0842" description:"The rate before the spread"`
+	Spread       Money     `json:"spread" bson:"spread" swaggertype:"number" example:"0.0025" description:"The fraction of the rate kept by the service"`
+	CustomerRate Money     `json:"customer_rate" bson:"customer_rate" swaggertype:"number" example:"1.08148950" description:"The rate after the spread that the amounts were converted at"`
+	SpreadAmount Money     `json:"spread_amount" bson:"spread_amount" swaggertype:"number" example:"0.28" description:"What the spread costs, in to_currency"`
+	Status       string    `json:"status" bson:"status" example:"open" enums:"open,executed,expired" description:"The state of the quote"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at" example:"2025-04-06T10:45:00Z" description:"When the quote was made"`
+	ExpiresAt    time.Time `json:"expires_at" bson:"expires_at" example:"2025-04-06T10:45:30Z" description:"When the quote can no longer be executed"`
+	ConversionID string    `json:"conversion_id,omitempty" bson:"conversion_id,omitempty" example:"4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a" description:"The conversion that executed the quote"`
+}
+
+// GenerateQuoteID generates a unique quote ID
+func GenerateQuoteID() string {
+	return uuid.New().String()
+}
+
+// ExpiredAt reports whether an open quote is past its expiry time at now
+func (q FXQuote) ExpiredAt(now time.Time) bool {
+	return q.Status == QuoteStatusOpen && !now.Before(q.ExpiresAt)
+}
+
+// StatusAt returns the state of the quote at now. Open quotes past their
+// expiry time are reported as expired; the stored state is not changed.
+func (q FXQuote) StatusAt(now time.Time) string {
+	if q.ExpiredAt(now) {
+		return QuoteStatusExpired
+	}
+	return q.Status
+}
+
+// Conversion records a conversion between two currency balances of a
+// customer with the rate and spread it was executed at
+// @Description Conversion debits one currency balance of a customer and credits another at a quoted rate
+type Conversion struct {
+	ConversionID        string    `json:"conversion_id" bson:"_id" example:"4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a" description:"The unique identifier for the conversion"`
+	CustomerID          string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The customer whose balances were converted"`
+	QuoteID             string    `json:"quote_id" bson:"quote_id" example:"9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d" description:"The quote the conversion executed"`
+	FromCurrency        string    `json:"from_currency" bson:"from_currency" example:"EUR" description:"The currency debited"`
+	ToCurrency          string    `json:"to_currency" bson:"to_currency" example:"USD" description:"The currency credited"`
+	SourceAmount        Money     `json:"source_amount" bson:"source_amount" swaggertype:"number" example:"100.00" description:"The amount debited in from_currency"`
+	TargetAmount        Money     `json:"target_amount" bson:"target_amount" swaggertype:"number" example:"108.14" description:"The amount credited in to_currency"`
+	RateID              string    `json:"rate_id" bson:"rate_id" example:"EUR/USD@2025-04-01T00:00:00Z" description:"The rate table entry the quote was priced from"`
+	Rate                Money     `json:"rate" bson:"rate" swaggertype:"number" example:"1.0842" description:"The rate before the spread"`
+	Spread              Money     `json:"spread" bson:"spread" swaggertype:"number" example:"0.0025" description:"The fraction of the rate kept by the service"`
+	CustomerRate        Money     `json:"customer_rate" bson:"customer_rate" swaggertype:"number" example:"1.08148950" description:"The rate after the spread that the amounts were converted at"`
+	SpreadAmount        Money     `json:"spread_amount" bson:"spread_amount" swaggertype:"number" example:"0.28" description:"What the spread cost, in to_currency"`
+	DebitTransactionID  string    `json:"debit_transaction_id" bson:"debit_transaction_id" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1" description:"The debit of from_currency"`
+	CreditTransactionID string    `json:"credit_transaction_id" bson:"credit_transaction_id" example:"6c2d1f0e-9d5e-4b64-8e1f-1e1d54f1d7b2" description:"The credit of to_currency"`
+	Timestamp           time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:12Z" description:"When the conversion was executed"`
+}
+
+// GenerateConversionID generates a unique conversion ID
+func GenerateConversionID() string {
+	return uuid.New().String()
+}
+
+// NewConversion returns the conversion executing quote at timestamp, with
+// new IDs for the conversion and its legs
+func NewConversion(quote FXQuote, timestamp time.Time) Conversion {
+	return Conversion{
+		ConversionID:        GenerateConversionID(),
+		CustomerID:          quote.CustomerID,
+		QuoteID:             quote.QuoteID,
+		FromCurrency:        quote.FromCurrency,
+		ToCurrency:          quote.ToCurrency,
+		SourceAmount:        quote.SourceAmount,
+		TargetAmount:        quote.TargetAmount,
+		RateID:              quote.RateID,
+		Rate:                quote.Rate,
+		Spread:              quote.Spread,
+		CustomerRate:        quote.CustomerRate,
+		SpreadAmount:        quote.SpreadAmount,
+		DebitTransactionID:  GenerateTransactionID(),
+		CreditTransactionID: GenerateTransactionID(),
+		Timestamp:           timestamp,
+	}
+}
+
+// Legs returns the debit of the source currency and the credit of the target
+// currency that make up the conversion
+func (c *Conversion) Legs() (debit Transaction, credit Transaction) {
+	debit = Transaction{
+		TransactionID: c.DebitTransactionID,
+		CustomerID:    c.CustomerID,
+		Type:          "debit",
+		Amount:        c.SourceAmount,
+		Currency:      c.FromCurrency,
+		Timestamp:     c.Timestamp,
+		ConversionID:  c.ConversionID,
+	}
+	credit = Transaction{
+		TransactionID: c.CreditTransactionID,
+		CustomerID:    c.CustomerID,
+		Type:          "credit",
+		Amount:        c.TargetAmount,
+		Currency:      c.ToCurrency,
+		Timestamp:     c.Timestamp,
+		ConversionID:  c.ConversionID,
+	}
+	return debit, credit
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Hold states. A hold is active until it is captured, voided or expires.
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+// ErrHoldNotActive is returned when capturing or voiding a hold that was already captured, voided or expired
+var ErrHoldNotActive = errors.New("hold is no longer active")
+
+// ErrHoldExpired is returned when capturing a hold after its expiry time
+var ErrHoldExpired = errors.New("hold has expired")
+
+// ErrCaptureExceedsHold is returned when capturing more than a hold reserves
+var ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+
+// Hold reserves funds of a customer for a later capture
+// @Description Hold reserves funds so they no longer count towards the available balance until the hold is captured, voided or expires
+type Hold struct {
+	HoldID               string     `json:"hold_id" bson:"_id" example:"3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7" description:"The unique identifier for the hold"`
+	CustomerID           string     `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The customer whose funds are held"`
+	Amount               Money      `json:"amount" bson:"amount" swaggertype:"number" example:"40.00" description:"The amount reserved"`
+	Currency             string     `json:"currency,omitempty" bson:"currency,omitempty" example:"USD" description:"The ISO 4217 currency of the amount; holds without one are in the default currency"`
+	CapturedAmount       Money      `json:"captured_amount" bson:"captured_amount" swaggertype:"number" example:"35.00" description:"The amount captured, if any"`
+	Description          string     `json:"description,omitempty" bson:"description,omitempty" example:"Card authorization 4821" description:"Free text describing the hold"`
+	Status               string     `json:"status" bson:"status" example:"active" enums:"active,captured,voided,expired" description:"The state of the hold"`
+	CreatedAt            time.Time  `json:"created_at" bson:"created_at" example:"2025-04-06T10:45:00Z" description:"When the hold was placed"`
+	ExpiresAt            time.Time  `json:"expires_at" bson:"expires_at" example:"2025-04-13T10:45:00Z" description:"When an active hold expires and releases its funds"`
+	ResolvedAt           *time.Time `json:"resolved_at,omitempty" bson:"resolved_at,omitempty" example:"2025-04-07T08:00:00Z" description:"When the hold was captured, voided or expired"`
+	CaptureTransactionID string     `json:"capture_transaction_id,omitempty" bson:"capture_transaction_id,omitempty" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1" description:"The debit posted by the capture"`
+}
+
+// GenerateHoldID generates a unique hold ID
+func GenerateHoldID() string {
+	return uuid.New().String()
+}
+
+// CurrencyCode returns the currency of the hold
+func (h Hold) CurrencyCode() string {
+	return CurrencyOrDefault(h.Currency)
+}
+
+// IsActive reports whether the hold still reserves funds
+func (h Hold) IsActive() bool {
+	return h.Status == HoldStatusActive
+}
+
+// ExpiredAt reports whether an active hold is past its expiry time at now
+func (h Hold) ExpiredAt(now time.Time) bool {
+	return h.IsActive() && !now.Before(h.ExpiresAt)
+}
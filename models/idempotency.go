@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Idempotency record states
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord remembers the outcome of a request sent with an Idempotency-Key header
+type IdempotencyRecord struct {
+	Key           string                     `json:"key" bson:"_id"`
+	Fingerprint   string                     `json:"fingerprint" bson:"fingerprint"`
+	Status        string                     `json:"status" bson:"status"`
+	TransactionID string                     `json:"transaction_id" bson:"transaction_id"`
+	StatusCode    int                        `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Response      *TransactionStatusResponse `json:"response,omitempty" bson:"response,omitempty"`
+	CreatedAt     time.Time                  `json:"created_at" bson:"created_at"`
+	ExpiresAt     time.Time                  `json:"expires_at" bson:"expires_at"`
+}
+
+// IsExpired reports whether the record may be discarded and its key reused
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// System accounts used as the counterpart of customer postings
+const (
+	SystemAccountCash             = "system:cash"
+	SystemAccountTransferClearing = "system:transfer_clearing"
+	SystemAccountOpeningBalance   = "system:opening_balance"
+)
+
+// ErrUnbalancedEntry is returned when the lines of a journal entry do not net to zero
+var ErrUnbalancedEntry = errors.New("journal entry lines do not net to zero")
+
+// JournalLine is one side of a journal entry. Amount is signed: debits are
+// positive and credits are negative, so the lines of an entry sum to zero.
+type JournalLine struct {
+	AccountID string `json:"account_id" bson:"account_id" example:"customer:123e4567-e89b-12d3-a456-426614174000"`
+	Amount    Money  `json:"amount" bson:"amount" swaggertype:"number" example:"-100.00"`
+}
+
+// JournalEntry is a balanced double-entry posting
+// @Description JournalEntry records a posting as two or more lines that net to zero
+type JournalEntry struct {
+	EntryID       string        `json:"entry_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	TransactionID string        `json:"transaction_id,omitempty" bson:"transaction_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Description   string        `json:"description" bson:"description" example:"credit"`
+	Lines         []JournalLine `json:"lines" bson:"lines"`
+	Timestamp     time.Time     `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z"`
+}
+
+// AccountBalance is the net of all journal lines posted to an account
+type AccountBalance struct {
+	AccountID string `json:"account_id" bson:"_id" example:"system:cash"`
+	Balance   Money  `json:"balance" bson:"balance" swaggertype:"number" example:"100.00"`
+}
+
+// CustomerAccountID returns the journal account holding a customer's funds
+func CustomerAccountID(customerID string) string {
+	return "customer:" + customerID
+}
+
+// AccountInCurrency returns the journal account holding the funds of
+// accountID in currency. Accounts in the default currency keep their plain
+// ID; accounts in other currencies carry the currency code as a suffix, so
+// each account only ever holds one currency.
+func AccountInCurrency(accountID, currency string) string {
+	if currency = CurrencyOrDefault(currency); currency == DefaultCurrency {
+		return accountID
+	}
+	return accountID + ":" + currency
+}
+
+// GenerateEntryID generates a unique journal entry ID
+func GenerateEntryID() string {
+	return uuid.New().String()
+}
+
+// Validate checks that the entry has at least two non-zero lines netting to zero
+func (e *JournalEntry) Validate() error {
+	if e.EntryID == "" {
+		return errors.New("entry ID is required")
+	}
+	if len(e.Lines) < 2 {
+		return errors.New("journal entry needs at least two lines")
+	}
+	var total Money
+	for _, line := range e.Lines {
+		if line.AccountID == "" {
+			return errors.New("journal line account ID is required")
+		}
+		if line.Amount.IsZero() {
+			return errors.New("journal line amount must not be zero")
+		}
+		total = total.Add(line.Amount)
+	}
+	if !total.IsZero() {
+		return ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// NewPostingEntry builds the journal entry for a customer credit or debit.
+// Credits increase the customer's liability account against counterAccount,
+// debits decrease it. Both lines are booked to the accounts of the
+// transaction's currency.
+func NewPostingEntry(t Transaction, counterAccount string) JournalEntry {
+	customerLine := t.Amount.Neg()
+	if t.Type == "debit" {
+		customerLine = t.Amount
+	}
+	return JournalEntry{
+		EntryID:       GenerateEntryID(),
+		TransactionID: t.TransactionID,
+		Description:   t.Type,
+		Lines: []JournalLine{
+			{AccountID: AccountInCurrency(CustomerAccountID(t.CustomerID), t.Currency), Amount: customerLine},
+			{AccountID: AccountInCurrency(counterAccount, t.Currency), Amount: customerLine.Neg()},
+		},
+		Timestamp: t.Timestamp,
+	}
+}
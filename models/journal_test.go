@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJournalEntryValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []JournalLine
+		wantErr bool
+	}{
+		{
+			name: "balanced entry",
+			lines: []JournalLine{
+				{AccountID: SystemAccountCash, Amount: MustParseMoney("10.00")},
+				{AccountID: CustomerAccountID("cust1"), Amount: MustParseMoney("-10.00")},
+			},
+		},
+		{
+			name: "balanced entry with three lines",
+			lines: []JournalLine{
+				{AccountID: SystemAccountCash, Amount: MustParseMoney("10.00")},
+				{AccountID: CustomerAccountID("cust1"), Amount: MustParseMoney("-7.50")},
+				{AccountID: CustomerAccountID("cust2"), Amount: MustParseMoney("-2.50")},
+			},
+		},
+		{
+			name: "unbalanced entry",
+			lines: []JournalLine{
+				{AccountID: SystemAccountCash, Amount: MustParseMoney("10.00")},
+				{AccountID: CustomerAccountID("cust1"), Amount: MustParseMoney("-9.99")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "single line",
+			lines: []JournalLine{
+				{AccountID: SystemAccountCash, Amount: MustParseMoney("10.00")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := JournalEntry{EntryID: "e1", Lines: tt.lines}
+			err := entry.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JournalEntry.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	unbalanced := JournalEntry{EntryID: "e2", Lines: []JournalLine{
+		{AccountID: SystemAccountCash, Amount: MustParseMoney("1")},
+		{AccountID: CustomerAccountID("cust1"), Amount: MustParseMoney("-2")},
+	}}
+	if err := unbalanced.Validate(); !errors.Is(err, ErrUnbalancedEntry) {
+		t.Errorf("JournalEntry.Validate() error = %v, want %v", err, ErrUnbalancedEntry)
+	}
+}
+
+func TestNewPostingEntry(t *testing.T) {
+	credit := NewPostingEntry(Transaction{TransactionID: "t1", CustomerID: "cust1", Type: "credit", Amount: MustParseMoney("5")}, SystemAccountCash)
+	if err := credit.Validate(); err != nil {
+		t.Fatalf("credit entry is invalid: %v", err)
+	}
+	if !credit.Lines[0].Amount.Equal(MustParseMoney("-5")) {
+		t.Errorf("credit posts %s to the customer account, want -5", credit.Lines[0].Amount)
+	}
+
+	debit := NewPostingEntry(Transaction{TransactionID: "t2", CustomerID: "cust1", Type: "debit", Amount: MustParseMoney("5")}, SystemAccountCash)
+	if !debit.Lines[0].Amount.Equal(MustParseMoney("5")) {
+		t.Errorf("debit posts %s to the customer account, want 5", debit.Lines[0].Amount)
+	}
+}
@@ -0,0 +1,508 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxMoneyScale is the largest number of decimal places a Money value can carry
+const MaxMoneyScale = 8
+
+// maxInt64Digits is the number of decimal digits of the largest int64
+const maxInt64Digits = 19
+
+// DefaultCurrency is the currency assumed when none is given
+const DefaultCurrency = "USD"
+
+// ErrInvalidMoney is returned when an amount cannot be parsed as a decimal number
+var ErrInvalidMoney = errors.New("invalid monetary amount")
+
+// ErrExcessPrecision is returned when an amount has more decimal places than allowed
+var ErrExcessPrecision = errors.New("amount has more decimal places than the currency allows")
+
+// ErrMoneyOverflow is returned when an amount does not fit into a Money value
+var ErrMoneyOverflow = errors.New("monetary amount out of range")
+
+// ErrUnsupportedCurrency is returned for currency codes that are not in the currency table
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// Currency describes an ISO 4217 currency and the number of minor units it uses
+type Currency struct {
+	Code       string
+	MinorUnits int32
+}
+
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", MinorUnits: 2},
+	"EUR": {Code: "EUR", MinorUnits: 2},
+	"GBP": {Code: "GBP", MinorUnits: 2},
+	"INR": {Code: "INR", MinorUnits: 2},
+	"AED": {Code: "AED", MinorUnits: 2},
+	"SGD": {Code: "SGD", MinorUnits: 2},
+	"CHF": {Code: "CHF", MinorUnits: 2},
+	"JPY": {Code: "JPY", MinorUnits: 0},
+	"KRW": {Code: "KRW", MinorUnits: 0},
+	"BHD": {Code: "BHD", MinorUnits: 3},
+	"KWD": {Code: "KWD", MinorUnits: 3},
+}
+
+// ErrCurrencyMismatch is returned when an amount is given in a different
+// currency than the hold or transaction it refers to
+var ErrCurrencyMismatch = errors.New("currency does not match")
+
+// LookupCurrency returns the currency with the given ISO 4217 code
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return c, nil
+}
+
+// lookupCanonicalCurrency returns the currency with the given ISO 4217 code,
+// which must be upper case as stored on records
+func lookupCanonicalCurrency(code string) (Currency, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return Currency{}, err
+	}
+	if currency.Code != code {
+		return Currency{}, fmt.Errorf("%w: %q is not an upper-case ISO 4217 code", ErrUnsupportedCurrency, code)
+	}
+	return currency, nil
+}
+
+// CurrencyOrDefault returns code, or DefaultCurrency if code is empty.
+// Records stored before currencies were tracked carry no currency code.
+func CurrencyOrDefault(code string) string {
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// Money is an exact decimal amount. Its value is units / 10^scale, so no
+// binary floating point rounding ever takes place.
+type Money struct {
+	units int64
+	scale int32
+}
+
+// NewMoney creates a Money value of units / 10^scale
+func NewMoney(units int64, scale int32) Money {
+	if scale < 0 || scale > MaxMoneyScale {
+		panic(fmt.Sprintf("models: money scale %d out of range", scale))
+	}
+	return Money{units: units, scale: scale}
+}
+
+// NewMoneyFromMinor creates a Money value from an amount in the currency's minor units
+func NewMoneyFromMinor(minor int64, currency Currency) Money {
+	return Money{units: minor, scale: currency.MinorUnits}
+}
+
+// ParseMoney parses a decimal string such as "100.50" or "-3" without any loss of precision
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Money{}, ErrInvalidMoney
+		}
+		mantissa, exponent = s[:i], exp
+	}
+
+	negative := false
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		negative = true
+		mantissa = mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, ErrInvalidMoney
+	}
+	digits := intPart + fracPart
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+
+	scale := int64(len(fracPart)) - exponent
+	// Drop trailing zeros that only exist because of the written precision
+	for scale > MaxMoneyScale && strings.HasSuffix(digits, "0") {
+		digits = digits[:len(digits)-1]
+		scale--
+	}
+	if scale > MaxMoneyScale {
+		return Money{}, ErrExcessPrecision
+	}
+	if scale < 0 {
+		// A large exponent is rejected before padding, as the zeros it asks
+		// for can run into the millions
+		significant := strings.TrimLeft(digits, "0")
+		if significant != "" && int64(len(significant))-scale > maxInt64Digits {
+			return Money{}, ErrMoneyOverflow
+		}
+		if significant != "" {
+			digits = significant + strings.Repeat("0", int(-scale))
+		}
+		scale = 0
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return Money{scale: int32(scale)}, nil
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrMoneyOverflow
+	}
+	if negative {
+		units = -units
+	}
+	return Money{units: units, scale: int32(scale)}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics if the amount cannot be parsed
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Scale returns the number of decimal places carried by m
+func (m Money) Scale() int32 {
+	return m.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m
+func (m Money) Sign() int {
+	switch {
+	case m.units < 0:
+		return -1
+	case m.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether m is zero
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+// IsPositive reports whether m is greater than zero
+func (m Money) IsPositive() bool {
+	return m.units > 0
+}
+
+// IsNegative reports whether m is less than zero
+func (m Money) IsNegative() bool {
+	return m.units < 0
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{units: -m.units, scale: m.scale}
+}
+
+// Abs returns the absolute value of m
+func (m Money) Abs() Money {
+	if m.units < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Add returns m + o. It does not check for overflow, so it is only for
+// amounts known to be in range; balances are changed with CheckedAdd.
+func (m Money) Add(o Money) Money {
+	a, b, scale, _ := align(m, o)
+	return Money{units: a + b, scale: scale}
+}
+
+// Sub returns m - o. It does not check for overflow, so it is only for
+// amounts known to be in range; balances are changed with CheckedSub.
+func (m Money) Sub(o Money) Money {
+	a, b, scale, _ := align(m, o)
+	return Money{units: a - b, scale: scale}
+}
+
+// CheckedAdd returns m + o, or ErrMoneyOverflow if the sum does not fit into a Money value
+func (m Money) CheckedAdd(o Money) (Money, error) {
+	a, b, scale, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{units: sum, scale: scale}, nil
+}
+
+// CheckedSub returns m - o, or ErrMoneyOverflow if the difference does not fit into a Money value
+func (m Money) CheckedSub(o Money) (Money, error) {
+	a, b, scale, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	difference := a - b
+	if (b > 0 && difference > a) || (b < 0 && difference < a) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{units: difference, scale: scale}, nil
+}
+
+// Cmp compares m and o and returns -1, 0 or +1
+func (m Money) Cmp(o Money) int {
+	a, b, scale, err := align(m, o)
+	if err != nil {
+		// One of them is too large to align, so compare them exactly
+		return bigUnits(m, scale).Cmp(bigUnits(o, scale))
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether m and o represent the same value, regardless of scale
+func (m Money) Equal(o Money) bool {
+	return m.Cmp(o) == 0
+}
+
+// Rescale returns m expressed with exactly scale decimal places. It fails with
+// ErrExcessPrecision if that would drop non-zero digits.
+func (m Money) Rescale(scale int32) (Money, error) {
+	if scale < 0 || scale > MaxMoneyScale {
+		return Money{}, ErrExcessPrecision
+	}
+	units := m.units
+	for s := m.scale; s > scale; s-- {
+		if units%10 != 0 {
+			return Money{}, ErrExcessPrecision
+		}
+		units /= 10
+	}
+	for s := m.scale; s < scale; s++ {
+		if units > math.MaxInt64/10 || units < math.MinInt64/10 {
+			return Money{}, ErrMoneyOverflow
+		}
+		units *= 10
+	}
+	return Money{units: units, scale: scale}, nil
+}
+
+// MulTruncated returns m * factor with exactly scale decimal places. Digits
+// beyond scale are dropped, rounding towards zero, so the result never
+// exceeds the exact product in magnitude.
+func (m Money) MulTruncated(factor Money, scale int32) (Money, error) {
+	if scale < 0 || scale > MaxMoneyScale {
+		return Money{}, ErrExcessPrecision
+	}
+	product := new(big.Int).Mul(big.NewInt(m.units), big.NewInt(factor.units))
+	exponent := int64(m.scale) + int64(factor.scale) - int64(scale)
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs64(exponent)), nil)
+	if exponent > 0 {
+		product.Quo(product, pow)
+	} else {
+		product.Mul(product, pow)
+	}
+	if !product.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{units: product.Int64(), scale: scale}, nil
+}
+
+// InCurrency returns m expressed in the minor units of the given currency,
+// rejecting amounts with more precision than the currency allows
+func (m Money) InCurrency(currency Currency) (Money, error) {
+	return m.Rescale(currency.MinorUnits)
+}
+
+// MinorUnits returns m as an integer number of the currency's minor units
+func (m Money) MinorUnits(currency Currency) (int64, error) {
+	r, err := m.InCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	return r.units, nil
+}
+
+// String formats m as a plain decimal string such as "-12.50"
+func (m Money) String() string {
+	units := m.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(units), 10)
+	if m.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(m.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	split := len(digits) - int(m.scale)
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// MarshalJSON encodes m as a JSON number with its exact decimal digits
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string without going through float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalBSONValue stores m as a Decimal128 so MongoDB keeps every digit
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	d, err := primitive.ParseDecimal128(m.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(d)
+}
+
+// UnmarshalBSONValue reads a Decimal128, or a legacy int or double value
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.Decimal128:
+		d, ok := raw.Decimal128OK()
+		if !ok {
+			return ErrInvalidMoney
+		}
+		return m.fromDecimal128(d)
+	case bsontype.Int32:
+		*m = Money{units: int64(raw.Int32())}
+	case bsontype.Int64:
+		*m = Money{units: raw.Int64()}
+	case bsontype.Double:
+		// Legacy doubles carry binary drift, such as 0.30000000000000004 for
+		// 0.1+0.2, so round them to MaxMoneyScale and drop trailing zeros
+		formatted := strconv.FormatFloat(raw.Double(), 'f', MaxMoneyScale, 64)
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+		parsed, err := ParseMoney(formatted)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case bsontype.Null:
+		*m = Money{}
+	default:
+		return fmt.Errorf("%w: cannot decode BSON %s", ErrInvalidMoney, t)
+	}
+	return nil
+}
+
+func (m *Money) fromDecimal128(d primitive.Decimal128) error {
+	coefficient, exponent, err := d.BigInt()
+	if err != nil {
+		return err
+	}
+	for exponent > 0 {
+		coefficient.Mul(coefficient, big.NewInt(10))
+		exponent--
+	}
+	for -exponent > MaxMoneyScale {
+		q, r := new(big.Int).QuoRem(coefficient, big.NewInt(10), new(big.Int))
+		if r.Sign() != 0 {
+			return ErrExcessPrecision
+		}
+		coefficient = q
+		exponent++
+	}
+	if !coefficient.IsInt64() {
+		return ErrMoneyOverflow
+	}
+	*m = Money{units: coefficient.Int64(), scale: int32(-exponent)}
+	return nil
+}
+
+// align returns the units of a and b expressed at their common scale. It
+// fails with ErrMoneyOverflow if either does not fit at that scale.
+func align(a, b Money) (int64, int64, int32, error) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	aUnits, err := scaleUp(a, scale)
+	if err != nil {
+		return 0, 0, scale, err
+	}
+	bUnits, err := scaleUp(b, scale)
+	if err != nil {
+		return 0, 0, scale, err
+	}
+	return aUnits, bUnits, scale, nil
+}
+
+func scaleUp(m Money, scale int32) (int64, error) {
+	units := m.units
+	for s := m.scale; s < scale; s++ {
+		if units > math.MaxInt64/10 || units < math.MinInt64/10 {
+			return 0, ErrMoneyOverflow
+		}
+		units *= 10
+	}
+	return units, nil
+}
+
+// bigUnits returns the units of m expressed at scale, which is at least m's scale
+func bigUnits(m Money, scale int32) *big.Int {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-m.scale)), nil)
+	return pow.Mul(pow, big.NewInt(m.units))
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
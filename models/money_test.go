@@ -0,0 +1,202 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{input: "100", want: "100"},
+		{input: "100.50", want: "100.50"},
+		{input: "-0.05", want: "-0.05"},
+		{input: "+7.1", want: "7.1"},
+		{input: ".5", want: "0.5"},
+		{input: "1e2", want: "100"},
+		{input: "1.5E-2", want: "0.015"},
+		{input: "0.123456789", wantErr: ErrExcessPrecision},
+		{input: "0.100000000", want: "0.10000000"},
+		{input: "abc", wantErr: ErrInvalidMoney},
+		{input: "", wantErr: ErrInvalidMoney},
+		{input: "1.2.3", wantErr: ErrInvalidMoney},
+		{input: "99999999999999999999", wantErr: ErrMoneyOverflow},
+		{input: "9e18", want: "9000000000000000000"},
+		{input: "1e19", wantErr: ErrMoneyOverflow},
+		{input: "1e1000000", wantErr: ErrMoneyOverflow},
+		{input: "0e1000000", want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseMoney(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.input, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseMoney(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := MustParseMoney("0.1")
+	b := MustParseMoney("0.2")
+
+	if got := a.Add(b); !got.Equal(MustParseMoney("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := a.Sub(b); got.String() != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, want -0.1", got)
+	}
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 || a.Cmp(MustParseMoney("0.10")) != 0 {
+		t.Error("Cmp() returned an unexpected ordering")
+	}
+}
+
+func TestMoneyCheckedArithmetic(t *testing.T) {
+	large := MustParseMoney("100000000000")
+	tiny := MustParseMoney("0.00000001")
+	max := NewMoney(math.MaxInt64, 0)
+
+	if _, err := large.CheckedAdd(tiny); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("%s + %s error = %v, want %v", large, tiny, err, ErrMoneyOverflow)
+	}
+	if _, err := max.CheckedAdd(NewMoney(1, 0)); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("max + 1 error = %v, want %v", err, ErrMoneyOverflow)
+	}
+	if _, err := max.Neg().CheckedSub(NewMoney(2, 0)); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("-max - 2 error = %v, want %v", err, ErrMoneyOverflow)
+	}
+	if got, err := MustParseMoney("0.1").CheckedSub(MustParseMoney("0.2")); err != nil || got.String() != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, %v, want -0.1", got, err)
+	}
+	if large.Cmp(tiny) != 1 || tiny.Cmp(large) != -1 || large.Neg().Cmp(tiny) != -1 {
+		t.Error("Cmp() of amounts too large to align returned an unexpected ordering")
+	}
+}
+
+func TestMoneyMulTruncated(t *testing.T) {
+	tests := []struct {
+		m, factor string
+		scale     int32
+		want      string
+	}{
+		{m: "100.00", factor: "1.0842", scale: 2, want: "108.42"},
+		{m: "10.00", factor: "0.33333333", scale: 2, want: "3.33"},
+		{m: "-10.00", factor: "0.33333333", scale: 2, want: "-3.33"},
+		{m: "12.5", factor: "151.23", scale: 0, want: "1890"},
+		{m: "3", factor: "2", scale: 8, want: "6.00000000"},
+	}
+	for _, tt := range tests {
+		got, err := MustParseMoney(tt.m).MulTruncated(MustParseMoney(tt.factor), tt.scale)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s * %s at scale %d = %s, %v, want %s", tt.m, tt.factor, tt.scale, got, err, tt.want)
+		}
+	}
+	if _, err := MustParseMoney("92233720368547758.07").MulTruncated(MustParseMoney("2"), 2); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("MulTruncated() overflow error = %v, want %v", err, ErrMoneyOverflow)
+	}
+}
+
+func TestMoneyInCurrency(t *testing.T) {
+	usd, _ := LookupCurrency("USD")
+	jpy, _ := LookupCurrency("jpy")
+
+	got, err := MustParseMoney("12.5").InCurrency(usd)
+	if err != nil || got.String() != "12.50" {
+		t.Errorf("InCurrency(USD) = %s, %v, want 12.50", got, err)
+	}
+	if _, err := MustParseMoney("12.345").InCurrency(usd); !errors.Is(err, ErrExcessPrecision) {
+		t.Errorf("InCurrency(USD) error = %v, want %v", err, ErrExcessPrecision)
+	}
+	if _, err := MustParseMoney("12.5").InCurrency(jpy); !errors.Is(err, ErrExcessPrecision) {
+		t.Errorf("InCurrency(JPY) error = %v, want %v", err, ErrExcessPrecision)
+	}
+	if minor, err := MustParseMoney("1.23").MinorUnits(usd); err != nil || minor != 123 {
+		t.Errorf("MinorUnits(USD) = %d, %v, want 123", minor, err)
+	}
+	if _, err := LookupCurrency("XXX"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("LookupCurrency(XXX) error = %v, want %v", err, ErrUnsupportedCurrency)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var req struct {
+		Amount Money `json:"amount"`
+		Quoted Money `json:"quoted"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 100.10, "quoted": "0.30"}`), &req); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if req.Amount.String() != "100.10" || req.Quoted.String() != "0.30" {
+		t.Errorf("decoded %s and %s, want 100.10 and 0.30", req.Amount, req.Quoted)
+	}
+
+	out, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(out) != `{"amount":100.10,"quoted":0.30}` {
+		t.Errorf("json.Marshal() = %s", out)
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type doc struct {
+		Balance Money `bson:"balance"`
+	}
+
+	in := doc{Balance: MustParseMoney("-1234.56")}
+	data, err := bson.Marshal(in)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	if got := bson.Raw(data).Lookup("balance").Type; got.String() != "128-bit decimal" {
+		t.Errorf("stored BSON type = %s, want Decimal128", got)
+	}
+
+	var out doc
+	if err := bson.Unmarshal(data, &out); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	if out.Balance.String() != "-1234.56" {
+		t.Errorf("round trip = %s, want -1234.56", out.Balance)
+	}
+
+	// Balances written before the Money type was introduced are doubles,
+	// often with binary drift from float arithmetic
+	legacyTests := []struct {
+		stored float64
+		want   string
+	}{
+		{100.1, "100.1"},
+		{0.30000000000000004, "0.3"},
+		{-19.99, "-19.99"},
+		{1e-9, "0"},
+		{250, "250"},
+	}
+	for _, tt := range legacyTests {
+		legacy, _ := bson.Marshal(bson.M{"balance": tt.stored})
+		if err := bson.Unmarshal(legacy, &out); err != nil {
+			t.Fatalf("bson.Unmarshal(%v) legacy error = %v", tt.stored, err)
+		}
+		if out.Balance.String() != tt.want {
+			t.Errorf("legacy double %v = %s, want %s", tt.stored, out.Balance, tt.want)
+		}
+	}
+}
@@ -2,30 +2,116 @@ package models
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error" example:"Error message"`
+	Error string    `json:"error" example:"Error message"`
+	Code  ErrorCode `json:"code,omitempty" example:"VALIDATION_FAILED"`
 }
 
 // BalanceResponse represents a balance response
 type BalanceResponse struct {
-	CustomerID string  `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Balance    float64 `json:"balance" example:"100.50"`
+	CustomerID        string                    `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Currency          string                    `json:"currency" example:"USD"`
+	Balance           Money                     `json:"balance" swaggertype:"number" example:"100.50"`
+	LedgerBalance     Money                     `json:"ledger_balance" swaggertype:"number" example:"100.50"`
+	AvailableBalance  *Money                    `json:"available_balance,omitempty" swaggertype:"number" example:"60.50"`
+	OverdraftLimit    *Money                    `json:"overdraft_limit,omitempty" swaggertype:"number" example:"500.00"`
+	AvailableCredit   *Money                    `json:"available_credit,omitempty" swaggertype:"number" example:"500.00"`
+	AsOf              string                    `json:"as_of,omitempty" example:"2025-04-06T23:59:59Z"`
+	LastTransactionID string                    `json:"last_transaction_id,omitempty" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"`
+	Balances          []CurrencyBalanceResponse `json:"balances,omitempty"`
+}
+
+// CurrencyBalanceResponse represents a customer's balance in one currency
+type CurrencyBalanceResponse struct {
+	Currency         string `json:"currency" example:"EUR"`
+	LedgerBalance    Money  `json:"ledger_balance" swaggertype:"number" example:"250.00"`
+	AvailableBalance Money  `json:"available_balance" swaggertype:"number" example:"250.00"`
 }
 
 // TransactionResponse represents a transaction response
 type TransactionResponse struct {
 	Message     string `json:"message" example:"Transaction queued successfully"`
 	Transaction struct {
-		TransactionID string  `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-		CustomerID    string  `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-		Type         string  `json:"type" example:"credit"`
-		Amount       float64 `json:"amount" example:"100.00"`
-		Timestamp    string  `json:"timestamp" example:"2025-04-27T11:03:15Z"`
+		TransactionID string `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+		CustomerID    string `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+		Type          string `json:"type" example:"credit"`
+		Amount        Money  `json:"amount" swaggertype:"number" example:"100.00"`
+		Timestamp     string `json:"timestamp" example:"2025-04-27T11:03:15Z"`
 	} `json:"transaction"`
 }
 
 // TransactionStatusResponse represents the status of a completed transaction
 type TransactionStatusResponse struct {
-	TransactionID string  `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Status       string  `json:"status" example:"completed"`
-	Balance      float64 `json:"balance" example:"100.50"`
-} 
\ No newline at end of file
+	TransactionID string    `json:"transaction_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Status        string    `json:"status" example:"completed"`
+	Balance       Money     `json:"balance" swaggertype:"number" example:"100.50"`
+	Currency      string    `json:"currency,omitempty" example:"USD"`
+	ErrorCode     ErrorCode `json:"error_code,omitempty" example:"INSUFFICIENT_FUNDS"`
+	FailureReason string    `json:"failure_reason,omitempty" example:"insufficient funds"`
+}
+
+// TransferResponse represents the result of a completed transfer
+type TransferResponse struct {
+	TransferID          string `json:"transfer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Status              string `json:"status" example:"completed"`
+	FromCustomerID      string `json:"from_customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ToCustomerID        string `json:"to_customer_id" example:"ef48ae68-182f-4f2f-bb62-8a0016a9ca94"`
+	Amount              Money  `json:"amount" swaggertype:"number" example:"25.00"`
+	Currency            string `json:"currency" example:"USD"`
+	DebitTransactionID  string `json:"debit_transaction_id" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"`
+	CreditTransactionID string `json:"credit_transaction_id" example:"9a7f6c1e-3d52-4d8b-8f3e-1c2b3a4d5e6f"`
+	Balance             Money  `json:"balance" swaggertype:"number" example:"75.00"`
+	Timestamp           string `json:"timestamp" example:"2025-04-27T11:03:15Z"`
+}
+
+// ReversalResponse represents the result of reversing a transaction
+type ReversalResponse struct {
+	TransactionID         string `json:"transaction_id" example:"9a7f6c1e-3d52-4d8b-8f3e-1c2b3a4d5e6f"`
+	OriginalTransactionID string `json:"original_transaction_id" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1"`
+	CustomerID            string `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Type                  string `json:"type" example:"credit"`
+	Amount                Money  `json:"amount" swaggertype:"number" example:"25.00"`
+	Currency              string `json:"currency" example:"USD"`
+	ReversedAmount        Money  `json:"reversed_amount" swaggertype:"number" example:"25.00"`
+	ReversalStatus        string `json:"reversal_status" example:"partially_reversed"`
+	Balance               Money  `json:"balance" swaggertype:"number" example:"125.00"`
+	Timestamp             string `json:"timestamp" example:"2025-04-27T11:03:15Z"`
+}
+
+// TrialBalanceResponse lists every journal account and proves the books balance
+type TrialBalanceResponse struct {
+	Accounts []AccountBalance `json:"accounts"`
+	Total    Money            `json:"total" swaggertype:"number" example:"0"`
+	Balanced bool             `json:"balanced" example:"true"`
+}
+
+// ReconciliationResponse compares a customer's stored balance with its journal account
+type ReconciliationResponse struct {
+	CustomerID        string `json:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Currency          string `json:"currency" example:"USD"`
+	StoredBalance     Money  `json:"stored_balance" swaggertype:"number" example:"100.50"`
+	JournalBalance    Money  `json:"journal_balance" swaggertype:"number" example:"100.50"`
+	InBalance         bool   `json:"in_balance" example:"true"`
+	LastSequence      int64  `json:"last_sequence" example:"42"`
+	HistoryContinuous bool   `json:"history_continuous" example:"true"`
+	HistoryIssue      string `json:"history_issue,omitempty" example:"transaction sequence has a gap: 7 follows 5"`
+}
+
+// DeadLetterListResponse represents the response for listing dead-lettered transactions
+// @Description DeadLetterListResponse lists transactions that could not be posted
+type DeadLetterListResponse struct {
+	DeadLetters []DeadLetter `json:"dead_letters"`
+}
+
+// CustomerListResponse represents a page of customers
+// @Description CustomerListResponse lists customers ordered by ID. Pass next_cursor as cursor to fetch the next page.
+type CustomerListResponse struct {
+	Customers  []Customer `json:"customers"`
+	NextCursor string     `json:"next_cursor,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// AuditListResponse represents a page of audit entries
+// @Description AuditListResponse lists audit entries newest first. Pass next_cursor as cursor to fetch the next page.
+type AuditListResponse struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+}
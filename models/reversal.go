@@ -0,0 +1,34 @@
+package models
+
+import "errors"
+
+// Reversal states recorded on a transaction that was reversed
+const (
+	ReversalStatusPartial = "partially_reversed"
+	ReversalStatusFull    = "reversed"
+)
+
+// ErrAlreadyReversed is returned when reversing a transaction whose whole amount was already reversed
+var ErrAlreadyReversed = errors.New("transaction is already fully reversed")
+
+// ErrReversalExceedsOriginal is returned when a reversal would take the reversed total beyond the original amount
+var ErrReversalExceedsOriginal = errors.New("reversal exceeds the amount left to reverse")
+
+// ErrNotReversible is returned for transactions that cannot be reversed on their own, such as reversals and the legs of transfers and conversions
+var ErrNotReversible = errors.New("transaction cannot be reversed")
+
+// ReversedType returns the type of the transaction that compensates one of transactionType
+func ReversedType(transactionType string) string {
+	if transactionType == "credit" {
+		return "debit"
+	}
+	return "credit"
+}
+
+// RemainingReversible returns how much of t has not been reversed yet
+func (t Transaction) RemainingReversible() Money {
+	if t.ReversedAmount == nil {
+		return t.Amount
+	}
+	return t.Amount.Sub(*t.ReversedAmount)
+}
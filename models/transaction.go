@@ -2,21 +2,37 @@ package models
 
 import (
 	"errors"
-	"time"
 	"github.com/google/uuid"
+	"time"
 )
 
-// ErrInsufficientFunds is returned when a debit transaction would result in a negative balance
+// ErrInsufficientFunds is returned when a debit transaction would exceed the available balance and overdraft limit
 var ErrInsufficientFunds = errors.New("insufficient funds")
 
 // Transaction represents a financial transaction in the system
 // @Description Transaction represents a credit or debit operation on a customer's account
 type Transaction struct {
-	TransactionID string    `json:"transaction_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The unique identifier for the transaction"`
-	CustomerID    string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The ID of the customer"`
-	Type          string    `json:"type" bson:"type" example:"credit" description:"The type of transaction (credit or debit)"`
-	Amount        float64   `json:"amount" bson:"amount" example:"100.00" description:"The amount of the transaction"`
-	Timestamp     time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z" description:"The timestamp of the transaction"`
+	TransactionID  string    `json:"transaction_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The unique identifier for the transaction"`
+	CustomerID     string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The ID of the customer"`
+	Type           string    `json:"type" bson:"type" example:"credit" description:"The type of transaction (credit or debit)"`
+	Amount         Money     `json:"amount" bson:"amount" swaggertype:"number" example:"100.00" description:"The amount of the transaction"`
+	Currency       string    `json:"currency,omitempty" bson:"currency,omitempty" example:"USD" description:"The ISO 4217 currency of the amount; transactions without one are in the default currency"`
+	Timestamp      time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z" description:"The timestamp of the transaction"`
+	TransferID     string    `json:"transfer_id,omitempty" bson:"transfer_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000" description:"The transfer this transaction is a leg of, if any"`
+	HoldID         string    `json:"hold_id,omitempty" bson:"hold_id,omitempty" example:"3f2a8c1e-6b7d-4e9f-a0b1-c2d3e4f5a6b7" description:"The hold this transaction captured, if any"`
+	ConversionID   string    `json:"conversion_id,omitempty" bson:"conversion_id,omitempty" example:"4d3c2b1a-0f9e-4d8c-7b6a-5f4e3d2c1b0a" description:"The currency conversion this transaction is a leg of, if any"`
+	ReversalOf     string    `json:"reversal_of,omitempty" bson:"reversal_of,omitempty" example:"5b1c0f0e-8c4d-4a53-9d0e-0d0c43f0c6a1" description:"The transaction this transaction reverses, if any"`
+	ReversalReason string    `json:"reversal_reason,omitempty" bson:"reversal_reason,omitempty" example:"duplicate charge" description:"Why the original transaction was reversed"`
+	ReversedAmount *Money    `json:"reversed_amount,omitempty" bson:"reversed_amount,omitempty" swaggertype:"number" example:"25.00" description:"How much of this transaction has been reversed"`
+	ReversalStatus string    `json:"reversal_status,omitempty" bson:"reversal_status,omitempty" example:"partially_reversed" enums:"partially_reversed,reversed" description:"Whether this transaction was partially or fully reversed"`
+	ReversalIDs    []string  `json:"reversal_ids,omitempty" bson:"reversal_ids,omitempty" description:"The transactions that reversed this transaction"`
+	Sequence       int64     `json:"sequence,omitempty" bson:"sequence,omitempty" example:"42" description:"The customer's transaction sequence number, increasing by one with every posting"`
+	BalanceBefore  *Money    `json:"balance_before,omitempty" bson:"balance_before,omitempty" swaggertype:"number" example:"100.00" description:"The customer's balance right before the transaction was posted"`
+	BalanceAfter   *Money    `json:"balance_after,omitempty" bson:"balance_after,omitempty" swaggertype:"number" example:"200.00" description:"The customer's balance right after the transaction was posted"`
+
+	// Origin is who submitted the transaction, for the audit log. It travels
+	// with the transaction through the queue but is not stored with it.
+	Origin *AuditOrigin `json:"-" bson:"-"`
 }
 
 // GenerateTransactionID generates a unique transaction ID
@@ -34,6 +50,11 @@ func GenerateCustomerID() string {
 	return uuid.New().String()
 }
 
+// CurrencyCode returns the currency of the transaction
+func (t Transaction) CurrencyCode() string {
+	return CurrencyOrDefault(t.Currency)
+}
+
 // Validate checks if the transaction is valid
 func (t *Transaction) Validate() error {
 	if t.TransactionID == "" {
@@ -45,16 +66,24 @@ func (t *Transaction) Validate() error {
 	if t.Type != "credit" && t.Type != "debit" {
 		return errors.New("invalid transaction type")
 	}
-	if t.Amount <= 0 {
+	if !t.Amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
+	currency, err := lookupCanonicalCurrency(t.CurrencyCode())
+	if err != nil {
+		return err
+	}
+	if _, err := t.Amount.InCurrency(currency); err != nil {
+		return err
+	}
 	return nil
 }
 
-// CalculateNewBalance calculates the new balance after applying the transaction
-func (t *Transaction) CalculateNewBalance(currentBalance float64) float64 {
+// CalculateNewBalance calculates the new balance after applying the
+// transaction. It fails with ErrMoneyOverflow if the balance would go out of range.
+func (t *Transaction) CalculateNewBalance(currentBalance Money) (Money, error) {
 	if t.Type == "credit" {
-		return currentBalance + t.Amount
+		return currentBalance.CheckedAdd(t.Amount)
 	}
-	return currentBalance - t.Amount
-} 
\ No newline at end of file
+	return currentBalance.CheckedSub(t.Amount)
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Transaction processing states
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusCompleted = "completed"
+	TransactionStatusFailed    = "failed"
+)
+
+// TransactionStatusRecord tracks a submitted transaction from acceptance until it is posted or rejected
+// @Description TransactionStatusRecord reports the processing state of a submitted transaction
+type TransactionStatusRecord struct {
+	TransactionID string    `json:"transaction_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CustomerID    string    `json:"customer_id" bson:"customer_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Type          string    `json:"type" bson:"type" example:"credit"`
+	Amount        Money     `json:"amount" bson:"amount" swaggertype:"number" example:"100.00"`
+	Currency      string    `json:"currency,omitempty" bson:"currency,omitempty" example:"USD"`
+	Status        string    `json:"status" bson:"status" example:"completed"`
+	ErrorCode     ErrorCode `json:"error_code,omitempty" bson:"error_code,omitempty" example:"INSUFFICIENT_FUNDS"`
+	FailureReason string    `json:"failure_reason,omitempty" bson:"failure_reason,omitempty" example:"insufficient funds"`
+	Balance       *Money    `json:"balance,omitempty" bson:"balance,omitempty" swaggertype:"number" example:"100.50"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at" example:"2025-04-06T10:45:00Z"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at" example:"2025-04-06T10:45:01Z"`
+}
+
+// PendingStatus returns the status record of a transaction that was accepted but not yet processed
+func PendingStatus(t Transaction) TransactionStatusRecord {
+	return TransactionStatusRecord{
+		TransactionID: t.TransactionID,
+		CustomerID:    t.CustomerID,
+		Type:          t.Type,
+		Amount:        t.Amount,
+		Currency:      t.Currency,
+		Status:        TransactionStatusPending,
+		CreatedAt:     t.Timestamp,
+		UpdatedAt:     t.Timestamp,
+	}
+}
+
+// CompletedStatus returns the status record of a posted transaction
+func CompletedStatus(t Transaction, balance Money) TransactionStatusRecord {
+	record := PendingStatus(t)
+	record.Status = TransactionStatusCompleted
+	record.Balance = &balance
+	record.UpdatedAt = GenerateTimestamp()
+	return record
+}
+
+// FailedStatus returns the status record of a rejected transaction
+func FailedStatus(t Transaction, code ErrorCode, reason string) TransactionStatusRecord {
+	record := PendingStatus(t)
+	record.Status = TransactionStatusFailed
+	record.ErrorCode = code
+	record.FailureReason = reason
+	record.UpdatedAt = GenerateTimestamp()
+	return record
+}
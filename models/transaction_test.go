@@ -16,9 +16,9 @@ func TestTransactionValidation(t *testing.T) {
 			tx: Transaction{
 				TransactionID: "test1",
 				CustomerID:    "cust1",
-				Type:         "credit",
-				Amount:       100,
-				Timestamp:    time.Now(),
+				Type:          "credit",
+				Amount:        MustParseMoney("100"),
+				Timestamp:     time.Now(),
 			},
 			wantErr: false,
 		},
@@ -27,9 +27,9 @@ func TestTransactionValidation(t *testing.T) {
 			tx: Transaction{
 				TransactionID: "test2",
 				CustomerID:    "cust1",
-				Type:         "debit",
-				Amount:       50,
-				Timestamp:    time.Now(),
+				Type:          "debit",
+				Amount:        MustParseMoney("50"),
+				Timestamp:     time.Now(),
 			},
 			wantErr: false,
 		},
@@ -38,9 +38,9 @@ func TestTransactionValidation(t *testing.T) {
 			tx: Transaction{
 				TransactionID: "test3",
 				CustomerID:    "cust1",
-				Type:         "invalid",
-				Amount:       100,
-				Timestamp:    time.Now(),
+				Type:          "invalid",
+				Amount:        MustParseMoney("100"),
+				Timestamp:     time.Now(),
 			},
 			wantErr: true,
 		},
@@ -49,9 +49,20 @@ func TestTransactionValidation(t *testing.T) {
 			tx: Transaction{
 				TransactionID: "test4",
 				CustomerID:    "cust1",
-				Type:         "credit",
-				Amount:       -100,
-				Timestamp:    time.Now(),
+				Type:          "credit",
+				Amount:        MustParseMoney("-100"),
+				Timestamp:     time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many decimal places",
+			tx: Transaction{
+				TransactionID: "test6",
+				CustomerID:    "cust1",
+				Type:          "credit",
+				Amount:        MustParseMoney("10.005"),
+				Timestamp:     time.Now(),
 			},
 			wantErr: true,
 		},
@@ -59,9 +70,9 @@ func TestTransactionValidation(t *testing.T) {
 			name: "missing transaction ID",
 			tx: Transaction{
 				CustomerID: "cust1",
-				Type:      "credit",
-				Amount:    100,
-				Timestamp: time.Now(),
+				Type:       "credit",
+				Amount:     MustParseMoney("100"),
+				Timestamp:  time.Now(),
 			},
 			wantErr: true,
 		},
@@ -69,9 +80,57 @@ func TestTransactionValidation(t *testing.T) {
 			name: "missing customer ID",
 			tx: Transaction{
 				TransactionID: "test5",
-				Type:         "credit",
-				Amount:       100,
-				Timestamp:    time.Now(),
+				Type:          "credit",
+				Amount:        MustParseMoney("100"),
+				Timestamp:     time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid transaction in another currency",
+			tx: Transaction{
+				TransactionID: "test6",
+				CustomerID:    "cust1",
+				Type:          "credit",
+				Amount:        MustParseMoney("100"),
+				Currency:      "EUR",
+				Timestamp:     time.Now(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported currency",
+			tx: Transaction{
+				TransactionID: "test7",
+				CustomerID:    "cust1",
+				Type:          "credit",
+				Amount:        MustParseMoney("100"),
+				Currency:      "XYZ",
+				Timestamp:     time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "currency in lower case",
+			tx: Transaction{
+				TransactionID: "test8",
+				CustomerID:    "cust1",
+				Type:          "credit",
+				Amount:        MustParseMoney("100"),
+				Currency:      "eur",
+				Timestamp:     time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount finer than the currency allows",
+			tx: Transaction{
+				TransactionID: "test9",
+				CustomerID:    "cust1",
+				Type:          "credit",
+				Amount:        MustParseMoney("100.5"),
+				Currency:      "JPY",
+				Timestamp:     time.Now(),
 			},
 			wantErr: true,
 		},
@@ -91,41 +150,50 @@ func TestCalculateNewBalance(t *testing.T) {
 	tests := []struct {
 		name           string
 		tx             Transaction
-		currentBalance float64
-		want           float64
+		currentBalance Money
+		want           Money
 	}{
 		{
 			name:           "credit transaction",
-			tx:             Transaction{Type: "credit", Amount: 100},
-			currentBalance: 500,
-			want:           600,
+			tx:             Transaction{Type: "credit", Amount: MustParseMoney("100")},
+			currentBalance: MustParseMoney("500"),
+			want:           MustParseMoney("600"),
 		},
 		{
 			name:           "debit transaction",
-			tx:             Transaction{Type: "debit", Amount: 100},
-			currentBalance: 500,
-			want:           400,
+			tx:             Transaction{Type: "debit", Amount: MustParseMoney("100")},
+			currentBalance: MustParseMoney("500"),
+			want:           MustParseMoney("400"),
 		},
 		{
 			name:           "zero balance credit",
-			tx:             Transaction{Type: "credit", Amount: 100},
-			currentBalance: 0,
-			want:           100,
+			tx:             Transaction{Type: "credit", Amount: MustParseMoney("100")},
+			currentBalance: MustParseMoney("0"),
+			want:           MustParseMoney("100"),
+		},
+		{
+			name:           "no binary rounding drift",
+			tx:             Transaction{Type: "credit", Amount: MustParseMoney("0.2")},
+			currentBalance: MustParseMoney("0.1"),
+			want:           MustParseMoney("0.30"),
 		},
 		{
 			name:           "zero balance debit",
-			tx:             Transaction{Type: "debit", Amount: 100},
-			currentBalance: 0,
-			want:           -100,
+			tx:             Transaction{Type: "debit", Amount: MustParseMoney("100")},
+			currentBalance: MustParseMoney("0"),
+			want:           MustParseMoney("-100"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.tx.CalculateNewBalance(tt.currentBalance)
-			if got != tt.want {
+			got, err := tt.tx.CalculateNewBalance(tt.currentBalance)
+			if err != nil {
+				t.Fatalf("Transaction.CalculateNewBalance() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
 				t.Errorf("Transaction.CalculateNewBalance() = %v, want %v", got, tt.want)
 			}
 		})
 	}
-} 
\ No newline at end of file
+}
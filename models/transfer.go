@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transfer moves funds from one customer to another as a single atomic operation
+// @Description Transfer debits the source customer and credits the destination customer atomically
+type Transfer struct {
+	TransferID     string    `json:"transfer_id" bson:"_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The unique identifier for the transfer"`
+	FromCustomerID string    `json:"from_customer_id" bson:"from_customer_id" example:"123e4567-e89b-12d3-a456-426614174000" description:"The customer being debited"`
+	ToCustomerID   string    `json:"to_customer_id" bson:"to_customer_id" example:"ef48ae68-182f-4f2f-bb62-8a0016a9ca94" description:"The customer being credited"`
+	Amount         Money     `json:"amount" bson:"amount" swaggertype:"number" example:"25.00" description:"The amount moved"`
+	Currency       string    `json:"currency,omitempty" bson:"currency,omitempty" example:"USD" description:"The ISO 4217 currency of the amount; defaults to the default currency"`
+	Timestamp      time.Time `json:"timestamp" bson:"timestamp" example:"2025-04-06T10:45:00Z" description:"The timestamp of the transfer"`
+}
+
+// GenerateTransferID generates a unique transfer ID
+func GenerateTransferID() string {
+	return uuid.New().String()
+}
+
+// Validate checks if the transfer is valid
+func (t *Transfer) Validate() error {
+	if t.TransferID == "" {
+		return errors.New("transfer ID is required")
+	}
+	if t.FromCustomerID == "" || t.ToCustomerID == "" {
+		return errors.New("source and destination customer IDs are required")
+	}
+	if t.FromCustomerID == t.ToCustomerID {
+		return errors.New("source and destination customers must differ")
+	}
+	if !t.Amount.IsPositive() {
+		return errors.New("amount must be positive")
+	}
+	currency, err := lookupCanonicalCurrency(CurrencyOrDefault(t.Currency))
+	if err != nil {
+		return err
+	}
+	if _, err := t.Amount.InCurrency(currency); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Legs returns the debit and credit transactions that make up the transfer
+func (t *Transfer) Legs() (debit Transaction, credit Transaction) {
+	debit = Transaction{
+		TransactionID: GenerateTransactionID(),
+		CustomerID:    t.FromCustomerID,
+		Type:          "debit",
+		Amount:        t.Amount,
+		Currency:      t.Currency,
+		Timestamp:     t.Timestamp,
+		TransferID:    t.TransferID,
+	}
+	credit = Transaction{
+		TransactionID: GenerateTransactionID(),
+		CustomerID:    t.ToCustomerID,
+		Type:          "credit",
+		Amount:        t.Amount,
+		Currency:      t.Currency,
+		Timestamp:     t.Timestamp,
+		TransferID:    t.TransferID,
+	}
+	return debit, credit
+}
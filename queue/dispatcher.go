@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTimeout is how long a customer worker may sit idle before it is reaped
+const DefaultIdleTimeout = time.Minute
+
+// ErrDispatcherStopped is returned for transactions submitted after the dispatcher was stopped
+var ErrDispatcherStopped = errors.New("dispatcher is stopped")
+
+// Dispatcher routes transactions to one long-lived worker per customer.
+// Transactions of a customer are processed strictly in submission order,
+// while different customers are processed in parallel. Submissions pass
+// through an intake queue and are acknowledged there once settled, so a
+// durable intake queue makes accepted transactions survive restarts.
+type Dispatcher struct {
+	store       store.LedgerStore
+	intake      Queue
+	idleTimeout time.Duration
+	retryPolicy RetryPolicy
+	policy      ledger.Policy
+	deadLetters store.DeadLetterStore
+	workers     map[string]*Worker
+	pending     map[string]chan models.TransactionStatusResponse
+	mu          sync.Mutex
+	pendingMu   sync.Mutex
+	stopChan    chan struct{}
+	stopped     bool
+}
+
+// NewDispatcher creates a new dispatcher reading from intake. Workers idle for
+// longer than idleTimeout are stopped; they are recreated on the next submission.
+func NewDispatcher(ledgerStore store.LedgerStore, intake Queue, idleTimeout time.Duration) *Dispatcher {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	return &Dispatcher{
+		store:       ledgerStore,
+		intake:      intake,
+		idleTimeout: idleTimeout,
+		retryPolicy: DefaultRetryPolicy,
+		policy:      ledger.DefaultPolicy,
+		workers:     make(map[string]*Worker),
+		pending:     make(map[string]chan models.TransactionStatusResponse),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// SetRetryPolicy sets the retry policy of workers created from now on.
+// It should be called before Start.
+func (d *Dispatcher) SetRetryPolicy(policy RetryPolicy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retryPolicy = policy
+}
+
+// SetPostingPolicy sets which postings accounts accept depending on their
+// state, for workers created from now on. It should be called before Start.
+func (d *Dispatcher) SetPostingPolicy(policy ledger.Policy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policy = policy
+}
+
+// SetDeadLetterStore sets where workers park transactions whose retries are
+// exhausted. Without one such transactions are only marked as failed.
+func (d *Dispatcher) SetDeadLetterStore(deadLetters store.DeadLetterStore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = deadLetters
+}
+
+// Start begins routing queued transactions, including any replayed by a
+// durable intake queue, and reaping idle workers
+func (d *Dispatcher) Start() {
+	go d.routeTransactions()
+	go d.reapIdleWorkers()
+}
+
+// Stop stops the reaper and every customer worker
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	d.stopped = true
+	close(d.stopChan)
+	// Transactions that were not processed yet stay unacknowledged in the
+	// intake queue, so a durable queue delivers them again after a restart
+	for customerID, worker := range d.workers {
+		worker.Stop()
+		delete(d.workers, customerID)
+	}
+}
+
+// Submit queues a transaction for its customer's worker and returns a
+// channel that receives the outcome of exactly that transaction. It fails
+// with ErrDispatcherStopped once the dispatcher is stopped, as nothing would
+// route the transaction any more.
+func (d *Dispatcher) Submit(t models.Transaction) (<-chan models.TransactionStatusResponse, error) {
+	// Holding mu keeps Stop from running between the check and the enqueue
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return nil, ErrDispatcherStopped
+	}
+
+	completion := make(chan models.TransactionStatusResponse, 1)
+
+	d.pendingMu.Lock()
+	d.pending[t.TransactionID] = completion
+	d.pendingMu.Unlock()
+
+	if err := d.intake.Enqueue(t); err != nil {
+		d.pendingMu.Lock()
+		delete(d.pending, t.TransactionID)
+		d.pendingMu.Unlock()
+		return nil, err
+	}
+	return completion, nil
+}
+
+// ActiveWorkers returns the number of customer workers currently running
+func (d *Dispatcher) ActiveWorkers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.workers)
+}
+
+// forwardCompletions hands each completion of a worker to the submitter of
+// the matching transaction until the worker has exited
+func (d *Dispatcher) forwardCompletions(worker *Worker) {
+	for {
+		select {
+		case status := <-worker.completionChan:
+			d.deliver(status)
+		case <-worker.done:
+			for {
+				select {
+				case status := <-worker.completionChan:
+					d.deliver(status)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// routeTransactions moves transactions from the intake queue to the queue of
+// their customer's worker, preserving their order
+func (d *Dispatcher) routeTransactions() {
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		default:
+		}
+
+		t, ok := d.intake.Dequeue()
+		if !ok {
+			select {
+			case <-d.stopChan:
+				return
+			case <-d.intake.Ready():
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+		d.route(t)
+	}
+}
+
+// route hands a transaction to the worker of its customer, starting one if
+// the customer has none. Transactions routed after Stop are left
+// unacknowledged in the intake queue.
+func (d *Dispatcher) route(t models.Transaction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+
+	worker, ok := d.workers[t.CustomerID]
+	if !ok {
+		worker = NewWorker(t.CustomerID, NewTransactionQueue(), d.store)
+		worker.retryPolicy = d.retryPolicy
+		worker.policy = d.policy
+		worker.deadLetters = d.deadLetters
+		worker.onSettled = d.settle
+		d.workers[t.CustomerID] = worker
+		worker.Start()
+		go d.forwardCompletions(worker)
+	}
+	worker.queue.Enqueue(t)
+}
+
+// settle acknowledges a committed, permanently rejected or dead-lettered transaction
+func (d *Dispatcher) settle(t models.Transaction) {
+	if err := d.intake.Ack(t.TransactionID); err != nil {
+		log.Printf("failed to acknowledge transaction %s: %v", t.TransactionID, err)
+	}
+}
+
+func (d *Dispatcher) deliver(status models.TransactionStatusResponse) {
+	d.pendingMu.Lock()
+	completion, ok := d.pending[status.TransactionID]
+	delete(d.pending, status.TransactionID)
+	d.pendingMu.Unlock()
+
+	if ok {
+		completion <- status
+	}
+}
+
+func (d *Dispatcher) reapIdleWorkers() {
+	ticker := time.NewTicker(d.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case now := <-ticker.C:
+			d.mu.Lock()
+			for customerID, worker := range d.workers {
+				if worker.idleFor(now) >= d.idleTimeout {
+					worker.Stop()
+					delete(d.workers, customerID)
+				}
+			}
+			d.mu.Unlock()
+		}
+	}
+}
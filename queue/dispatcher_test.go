@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"ledger-service/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherOrdersTransactionsPerCustomer(t *testing.T) {
+	ledgerStore := store.NewMemoryStore()
+	customers := []string{"customer_a", "customer_b", "customer_c"}
+	for _, id := range customers {
+		ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: id, Balance: models.MustParseMoney("0")})
+	}
+
+	dispatcher := NewDispatcher(ledgerStore, NewTransactionQueue(), DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	// A credit followed by a debit of the same amount only succeeds in order
+	const rounds = 20
+	var wg sync.WaitGroup
+	results := make(chan models.TransactionStatusResponse, len(customers)*rounds*2)
+	for _, id := range customers {
+		wg.Add(1)
+		go func(customerID string) {
+			defer wg.Done()
+			var completions []<-chan models.TransactionStatusResponse
+			for i := 0; i < rounds; i++ {
+				for _, txType := range []string{"credit", "debit"} {
+					completion, err := dispatcher.Submit(models.Transaction{
+						TransactionID: fmt.Sprintf("%s-%d-%s", customerID, i, txType),
+						CustomerID:    customerID,
+						Type:          txType,
+						Amount:        models.MustParseMoney("10"),
+						Timestamp:     time.Now(),
+					})
+					if err != nil {
+						t.Errorf("Submit() error = %v", err)
+						return
+					}
+					completions = append(completions, completion)
+				}
+			}
+			for _, completion := range completions {
+				select {
+				case status := <-completion:
+					results <- status
+				case <-time.After(5 * time.Second):
+					t.Error("Transaction processing timed out")
+					return
+				}
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(results)
+
+	for status := range results {
+		if status.Status != "completed" {
+			t.Errorf("Transaction %s %s, want completed", status.TransactionID, status.Status)
+		}
+	}
+
+	for _, id := range customers {
+		history, _ := ledgerStore.GetTransactionHistory(context.Background(), id)
+		if len(history) != rounds*2 {
+			t.Fatalf("%s has %d transactions, want %d", id, len(history), rounds*2)
+		}
+		for i, tx := range history {
+			want := fmt.Sprintf("%s-%d-%s", id, i/2, map[bool]string{true: "credit", false: "debit"}[i%2 == 0])
+			if tx.TransactionID != want {
+				t.Errorf("%s transaction %d = %s, want %s", id, i, tx.TransactionID, want)
+			}
+		}
+	}
+
+	if got := dispatcher.ActiveWorkers(); got != len(customers) {
+		t.Errorf("ActiveWorkers() = %d, want %d", got, len(customers))
+	}
+}
+
+func TestDispatcherRejectsSubmissionsAfterStop(t *testing.T) {
+	intake := NewTransactionQueue()
+	dispatcher := NewDispatcher(store.NewMemoryStore(), intake, DefaultIdleTimeout)
+	dispatcher.Start()
+	dispatcher.Stop()
+
+	_, err := dispatcher.Submit(models.Transaction{TransactionID: "late", CustomerID: "customer_a", Type: "credit", Amount: models.MustParseMoney("10"), Timestamp: time.Now()})
+	if !errors.Is(err, ErrDispatcherStopped) {
+		t.Errorf("Submit() after Stop error = %v, want %v", err, ErrDispatcherStopped)
+	}
+	if !intake.IsEmpty() {
+		t.Error("Submit() after Stop enqueued the transaction")
+	}
+}
+
+func TestDispatcherCorrelatesCompletions(t *testing.T) {
+	ledgerStore := store.NewMemoryStore()
+	ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "customer_a", Balance: models.MustParseMoney("5")})
+
+	dispatcher := NewDispatcher(ledgerStore, NewTransactionQueue(), DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	ok, _ := dispatcher.Submit(models.Transaction{TransactionID: "ok", CustomerID: "customer_a", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: time.Now()})
+	missing, _ := dispatcher.Submit(models.Transaction{TransactionID: "missing", CustomerID: "customer_x", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: time.Now()})
+
+	for name, completion := range map[string]<-chan models.TransactionStatusResponse{"ok": ok, "missing": missing} {
+		select {
+		case status := <-completion:
+			if status.TransactionID != name {
+				t.Errorf("Submitter of %s received the outcome of %s", name, status.TransactionID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Transaction %s timed out", name)
+		}
+	}
+}
+
+func TestDispatcherReapsIdleWorkers(t *testing.T) {
+	ledgerStore := store.NewMemoryStore()
+	ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "customer_a"})
+
+	dispatcher := NewDispatcher(ledgerStore, NewTransactionQueue(), 100*time.Millisecond)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	completion, _ := dispatcher.Submit(models.Transaction{TransactionID: "t1", CustomerID: "customer_a", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: time.Now()})
+	<-completion
+	if got := dispatcher.ActiveWorkers(); got != 1 {
+		t.Fatalf("ActiveWorkers() = %d, want 1", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for dispatcher.ActiveWorkers() != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := dispatcher.ActiveWorkers(); got != 0 {
+		t.Fatalf("ActiveWorkers() = %d after idle timeout, want 0", got)
+	}
+
+	// A reaped customer gets a fresh worker on the next submission
+	completion, _ = dispatcher.Submit(models.Transaction{TransactionID: "t2", CustomerID: "customer_a", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: time.Now()})
+	select {
+	case status := <-completion:
+		if status.Status != "completed" {
+			t.Errorf("Transaction after reaping %s, want completed", status.Status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Transaction after reaping timed out")
+	}
+}
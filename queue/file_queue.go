@@ -0,0 +1,293 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// compactAfterAcks is the number of acknowledgements after which the log is rewritten
+const compactAfterAcks = 1000
+
+// Log record operations
+const (
+	opEnqueue = "enqueue"
+	opAck     = "ack"
+)
+
+var _ Queue = (*FileQueue)(nil)
+
+// logRecord is one line of the append-only queue log
+type logRecord struct {
+	Op            string              `json:"op"`
+	TransactionID string              `json:"transaction_id"`
+	Transaction   *models.Transaction `json:"transaction,omitempty"`
+	// Origin is who submitted the transaction, kept so that it is audited
+	// against them even if it is only posted after a restart
+	Origin *models.AuditOrigin `json:"origin,omitempty"`
+}
+
+// queuedItem is a transaction that has not been acknowledged yet
+type queuedItem struct {
+	seq         uint64
+	transaction models.Transaction
+}
+
+// FileQueue is a durable queue backed by an append-only log file. Every
+// enqueue and acknowledgement is synced to disk before it returns, and any
+// transaction that was not acknowledged is delivered again when the log is
+// reopened, giving at-least-once delivery across crashes and restarts.
+type FileQueue struct {
+	path     string
+	file     *os.File
+	pending  []queuedItem
+	inFlight map[string]queuedItem
+	nextSeq  uint64
+	acks     int
+	mu       sync.Mutex
+	ready    chan struct{}
+}
+
+// OpenFileQueue opens or creates the queue log at path and replays every
+// transaction that was enqueued but never acknowledged
+func OpenFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{
+		path:     path,
+		inFlight: make(map[string]queuedItem),
+		ready:    make(chan struct{}, 1),
+	}
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+	if err := q.compact(); err != nil {
+		return nil, err
+	}
+	if len(q.pending) > 0 {
+		signal(q.ready)
+	}
+	return q, nil
+}
+
+// Enqueue durably appends a transaction to the queue
+func (q *FileQueue) Enqueue(t models.Transaction) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return os.ErrClosed
+	}
+	if err := q.append(logRecord{Op: opEnqueue, TransactionID: t.TransactionID, Transaction: &t, Origin: t.Origin}); err != nil {
+		return err
+	}
+	q.pending = append(q.pending, queuedItem{seq: q.nextSeq, transaction: t})
+	q.nextSeq++
+	signal(q.ready)
+	return nil
+}
+
+// Dequeue removes and returns the first transaction from the queue. It is
+// delivered again after a restart unless it is acknowledged.
+func (q *FileQueue) Dequeue() (models.Transaction, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return models.Transaction{}, false
+	}
+	item := q.pending[0]
+	q.pending = q.pending[1:]
+	q.inFlight[item.transaction.TransactionID] = item
+	return item.transaction, true
+}
+
+// Ack durably marks a dequeued transaction as processed
+func (q *FileQueue) Ack(transactionID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.inFlight[transactionID]; !ok {
+		return nil
+	}
+	if q.file == nil {
+		return os.ErrClosed
+	}
+	if err := q.append(logRecord{Op: opAck, TransactionID: transactionID}); err != nil {
+		return err
+	}
+	delete(q.inFlight, transactionID)
+
+	q.acks++
+	if q.acks >= compactAfterAcks {
+		return q.compact()
+	}
+	return nil
+}
+
+// IsEmpty checks if the queue has no transactions waiting to be dequeued
+func (q *FileQueue) IsEmpty() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) == 0
+}
+
+// Ready returns a channel that receives a value after transactions are enqueued
+func (q *FileQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Unacknowledged returns the number of transactions not acknowledged yet
+func (q *FileQueue) Unacknowledged() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) + len(q.inFlight)
+}
+
+// Close closes the log file. Unacknowledged transactions are replayed on the next open.
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return nil
+	}
+	err := q.file.Close()
+	q.file = nil
+	return err
+}
+
+// append writes record as one line of the log and syncs it. If either
+// fails, the log is truncated back to where the line began, so that later
+// records do not follow a torn line replay would reject; if that fails too,
+// the queue is closed for writing.
+func (q *FileQueue) append(record logRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	info, err := q.file.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = q.file.Write(append(line, '\n'))
+	if err == nil {
+		err = q.file.Sync()
+	}
+	if err != nil {
+		if q.file.Truncate(info.Size()) != nil || q.file.Sync() != nil {
+			q.file.Close()
+			q.file = nil
+		}
+		return err
+	}
+	return nil
+}
+
+// replay rebuilds the pending list from the log
+func (q *FileQueue) replay() error {
+	file, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	unacked := make(map[string]queuedItem)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lineNo int
+	var broken error
+	for scanner.Scan() {
+		lineNo++
+		if broken != nil {
+			// Only the very last line may be torn by a crash mid-write
+			return broken
+		}
+
+		var record logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			broken = fmt.Errorf("queue log %s line %d: %w", q.path, lineNo, err)
+			continue
+		}
+		switch record.Op {
+		case opEnqueue:
+			if record.Transaction == nil {
+				return fmt.Errorf("queue log %s line %d: enqueue without transaction", q.path, lineNo)
+			}
+			record.Transaction.Origin = record.Origin
+			unacked[record.TransactionID] = queuedItem{seq: q.nextSeq, transaction: *record.Transaction}
+			q.nextSeq++
+		case opAck:
+			delete(unacked, record.TransactionID)
+		default:
+			return fmt.Errorf("queue log %s line %d: unknown operation %q", q.path, lineNo, record.Op)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range unacked {
+		q.pending = append(q.pending, item)
+	}
+	sort.Slice(q.pending, func(i, j int) bool { return q.pending[i].seq < q.pending[j].seq })
+	return nil
+}
+
+// compact rewrites the log so it only holds unacknowledged transactions.
+// The new log is synced before it atomically replaces the old one.
+func (q *FileQueue) compact() error {
+	items := make([]queuedItem, 0, len(q.inFlight)+len(q.pending))
+	for _, item := range q.inFlight {
+		items = append(items, item)
+	}
+	items = append(items, q.pending...)
+	sort.Slice(items, func(i, j int) bool { return items[i].seq < items[j].seq })
+
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, item := range items {
+		t := item.transaction
+		line, err := json.Marshal(logRecord{Op: opEnqueue, TransactionID: t.TransactionID, Transaction: &t, Origin: t.Origin})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(q.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file, err = os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	q.acks = 0
+	return nil
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"ledger-service/ledger"
+	"ledger-service/models"
+	"ledger-service/store"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testTransaction(id string) models.Transaction {
+	return models.Transaction{
+		TransactionID: id,
+		CustomerID:    "test_customer",
+		Type:          "credit",
+		Amount:        models.MustParseMoney("10.00"),
+		Timestamp:     time.Now().UTC(),
+	}
+}
+
+func TestFileQueueReplaysUnacknowledged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := q.Enqueue(testTransaction(id)); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+
+	// t1 is acknowledged, t2 is in flight when the process dies, t3 is untouched
+	first, _ := q.Dequeue()
+	q.Ack(first.TransactionID)
+	q.Dequeue()
+	q.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() after restart error = %v", err)
+	}
+	defer reopened.Close()
+
+	var replayed []string
+	for !reopened.IsEmpty() {
+		tx, _ := reopened.Dequeue()
+		replayed = append(replayed, tx.TransactionID)
+		if !tx.Amount.Equal(models.MustParseMoney("10")) {
+			t.Errorf("Replayed amount = %s, want 10.00", tx.Amount)
+		}
+	}
+	if len(replayed) != 2 || replayed[0] != "t2" || replayed[1] != "t3" {
+		t.Errorf("Replayed %v, want [t2 t3]", replayed)
+	}
+}
+
+func TestFileQueueKeepsOrigin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	origin := models.AuditOrigin{ActorType: models.ActorToken, ActorID: "alice", RequestID: "req1"}
+	transaction := testTransaction("t1")
+	transaction.Origin = &origin
+	if err := q.Enqueue(transaction); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	q.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() after restart error = %v", err)
+	}
+	defer reopened.Close()
+	replayed, ok := reopened.Dequeue()
+	if !ok {
+		t.Fatal("Expected the transaction to be replayed")
+	}
+	if replayed.Origin == nil || *replayed.Origin != origin {
+		t.Errorf("Replayed origin = %+v, want %+v", replayed.Origin, origin)
+	}
+}
+
+func TestFileQueueToleratesTornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q, _ := OpenFileQueue(path)
+	q.Enqueue(testTransaction("t1"))
+	q.Close()
+
+	// Simulate a crash in the middle of writing the next record
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"op":"enqueue","transaction_id":"t2","transac`)
+	f.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	defer reopened.Close()
+	if got := reopened.Unacknowledged(); got != 1 {
+		t.Errorf("Unacknowledged() = %d, want 1", got)
+	}
+}
+
+func TestFileQueueStopsWritingAfterFailedAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+
+	q, _ := OpenFileQueue(path)
+	q.Enqueue(testTransaction("t1"))
+
+	// Simulate the log becoming unwritable; the failed record cannot be
+	// rolled back either, so the queue must refuse further records
+	readOnly, _ := os.Open(path)
+	q.file.Close()
+	q.file = readOnly
+	if err := q.Enqueue(testTransaction("t2")); err == nil {
+		t.Fatal("Enqueue() succeeded on an unwritable log")
+	}
+	if err := q.Enqueue(testTransaction("t3")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Enqueue() after failed append error = %v, want %v", err, os.ErrClosed)
+	}
+	q.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	defer reopened.Close()
+	if got := reopened.Unacknowledged(); got != 1 {
+		t.Errorf("Unacknowledged() = %d, want 1", got)
+	}
+}
+
+func TestDispatcherReplaysDurableQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	ledgerStore := store.NewMemoryStore()
+	ledgerStore.CreateCustomer(context.Background(), models.Customer{CustomerID: "test_customer", Balance: models.MustParseMoney("0")})
+
+	// t1 was posted but the process died before acknowledging it; t2 was
+	// accepted but never processed
+	q, _ := OpenFileQueue(path)
+	posted := testTransaction("t1")
+	q.Enqueue(posted)
+	q.Enqueue(testTransaction("t2"))
+	q.Close()
+	ledgerStore.WithTransaction(context.Background(), func(tx store.Tx) error {
+		balance, err := ledger.ApplyTransaction(tx, posted, ledger.DefaultPolicy)
+		if err != nil {
+			return err
+		}
+		return tx.SaveTransactionStatus(models.CompletedStatus(posted, balance))
+	})
+
+	intake, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	defer intake.Close()
+	dispatcher := NewDispatcher(ledgerStore, intake, DefaultIdleTimeout)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for intake.Unacknowledged() != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := intake.Unacknowledged(); got != 0 {
+		t.Fatalf("Unacknowledged() = %d after replay, want 0", got)
+	}
+
+	customer, _ := ledgerStore.GetCustomer(context.Background(), "test_customer")
+	if !customer.Balance.Equal(models.MustParseMoney("20")) {
+		t.Errorf("Balance = %s, want 20 with each transaction posted once", customer.Balance)
+	}
+	history, _ := ledgerStore.GetTransactionHistory(context.Background(), "test_customer")
+	if len(history) != 2 {
+		t.Errorf("History has %d transactions, want 2", len(history))
+	}
+}
@@ -5,22 +5,59 @@ import (
 	"sync"
 )
 
-// TransactionQueue represents a queue of transactions
+// Queue is a FIFO of transactions waiting to be processed. Dequeued
+// transactions stay owned by the queue until they are acknowledged, which
+// lets durable implementations redeliver them after a restart.
+type Queue interface {
+	// Enqueue adds a transaction to the queue
+	Enqueue(t models.Transaction) error
+
+	// Dequeue removes and returns the first transaction from the queue
+	Dequeue() (models.Transaction, bool)
+
+	// Ack marks a dequeued transaction as fully processed
+	Ack(transactionID string) error
+
+	// IsEmpty checks if the queue is empty
+	IsEmpty() bool
+
+	// Ready returns a channel that receives a value after transactions are enqueued
+	Ready() <-chan struct{}
+}
+
+var _ Queue = (*TransactionQueue)(nil)
+
+// TransactionQueue represents an in-memory queue of transactions
 type TransactionQueue struct {
 	transactions []models.Transaction
-	mu          sync.Mutex
+	mu           sync.Mutex
+	ready        chan struct{}
 }
 
 // NewTransactionQueue creates a new transaction queue
 func NewTransactionQueue() *TransactionQueue {
-	return &TransactionQueue{}
+	return &TransactionQueue{
+		ready: make(chan struct{}, 1),
+	}
 }
 
 // Enqueue adds a transaction to the queue
-func (q *TransactionQueue) Enqueue(t models.Transaction) {
+func (q *TransactionQueue) Enqueue(t models.Transaction) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	q.transactions = append(q.transactions, t)
+	signal(q.ready)
+	return nil
+}
+
+// Ack is a no-op because an in-memory queue cannot redeliver after a restart
+func (q *TransactionQueue) Ack(transactionID string) error {
+	return nil
+}
+
+// Ready returns a channel that receives a value after transactions are enqueued
+func (q *TransactionQueue) Ready() <-chan struct{} {
+	return q.ready
 }
 
 // Dequeue removes and returns the first transaction from the queue
@@ -42,4 +79,12 @@ func (q *TransactionQueue) IsEmpty() bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	return len(q.transactions) == 0
-} 
\ No newline at end of file
+}
+
+// signal wakes up a waiting consumer without blocking the producer
+func signal(ready chan struct{}) {
+	select {
+	case ready <- struct{}{}:
+	default:
+	}
+}
@@ -19,7 +19,7 @@ func TestTransactionQueue(t *testing.T) {
 		TransactionID: "test1",
 		CustomerID:    "test_customer",
 		Type:          "credit",
-		Amount:        100,
+		Amount:        models.MustParseMoney("100"),
 		Timestamp:     time.Now(),
 	}
 
@@ -52,7 +52,7 @@ func TestTransactionQueueConcurrent(t *testing.T) {
 				TransactionID: string(rune(i)),
 				CustomerID:    "test_customer",
 				Type:          "credit",
-				Amount:        10,
+				Amount:        models.MustParseMoney("10"),
 				Timestamp:     time.Now(),
 			}
 			queue.Enqueue(transaction)
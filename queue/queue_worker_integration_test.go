@@ -2,50 +2,165 @@ package queue
 
 import (
 	"context"
+	"ledger-service/models"
+	"ledger-service/store"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func setupTestDB(t *testing.T) (*mongo.Collection, *mongo.Collection) {
-	// Get the current directory and go back one level
+func setupTestStore(t *testing.T) store.LedgerStore {
+	t.Helper()
+	return createTestCustomer(t, store.NewMemoryStore())
+}
+
+// setupMongoStore returns a store on an emptied test database of the cluster
+// in MONGO_CLUSTER, taken from the environment or the .env file one level
+// up, holding the test customer. The test is skipped when no cluster is
+// configured.
+func setupMongoStore(t *testing.T) store.LedgerStore {
+	t.Helper()
+
 	currentDir, err := os.Getwd()
 	if err != nil {
 		t.Fatalf("Failed to get current directory: %v", err)
 	}
-
-	// Go back one level to find the .env file
-	envPath := filepath.Join(filepath.Dir(currentDir), ".env")
-
-	if err := godotenv.Load(envPath); err != nil {
-		t.Fatalf("Error loading .env file from %s: %v", envPath, err)
-	}
+	godotenv.Load(filepath.Join(filepath.Dir(currentDir), ".env"))
 
 	databaseURL := os.Getenv("MONGO_CLUSTER")
 	if databaseURL == "" {
-		t.Fatal("MONGO_CLUSTER environment variable is not set in .env file")
+		t.Skip("MONGO_CLUSTER is not set")
 	}
 
-	clientOptions := options.Client().ApplyURI(databaseURL)
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(databaseURL))
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
-
-	// Test the connection
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	if err := client.Ping(ctx, nil); err != nil {
 		t.Fatalf("Failed to ping MongoDB: %v", err)
 	}
 
 	// Use a separate test database
-	db := client.Database("kryptovate_test")
-	customers := db.Collection("customers")
-	transactions := db.Collection("transactions")
+	db := client.Database("kryptovate_test_queue")
+	if err := db.Drop(ctx); err != nil {
+		t.Fatalf("Failed to drop test database: %v", err)
+	}
+	mongoStore := store.NewMongoStore(db)
+	if err := mongoStore.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("Failed to create indexes: %v", err)
+	}
+	return createTestCustomer(t, mongoStore)
+}
+
+func createTestCustomer(t *testing.T, ledgerStore store.LedgerStore) store.LedgerStore {
+	t.Helper()
+
+	customer := models.Customer{
+		CustomerID: "test_customer",
+		Name:       "Test Customer",
+		Balance:    models.MustParseMoney("100"),
+	}
+	if err := ledgerStore.CreateCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Failed to create test customer: %v", err)
+	}
+	return ledgerStore
+}
+
+func TestWorkerProcessesAgainstStore(t *testing.T) {
+	testWorkerProcesses(t, setupTestStore(t))
+}
+
+func TestWorkerProcessesAgainstMongo(t *testing.T) {
+	testWorkerProcesses(t, setupMongoStore(t))
+}
+
+func testWorkerProcesses(t *testing.T, ledgerStore store.LedgerStore) {
+	queue := NewTransactionQueue()
+	worker := NewWorker("test_customer", queue, ledgerStore)
+	worker.Start()
+	defer worker.Stop()
+
+	tests := []struct {
+		name          string
+		tx            models.Transaction
+		wantStatus    string
+		wantCode      models.ErrorCode
+		wantBalance   models.Money
+		wantStoredBal models.Money
+	}{
+		{
+			name:          "credit",
+			tx:            models.Transaction{TransactionID: "it1", CustomerID: "test_customer", Type: "credit", Amount: models.MustParseMoney("50"), Timestamp: time.Now()},
+			wantStatus:    "completed",
+			wantBalance:   models.MustParseMoney("150"),
+			wantStoredBal: models.MustParseMoney("150"),
+		},
+		{
+			name:          "debit",
+			tx:            models.Transaction{TransactionID: "it2", CustomerID: "test_customer", Type: "debit", Amount: models.MustParseMoney("30"), Timestamp: time.Now()},
+			wantStatus:    "completed",
+			wantBalance:   models.MustParseMoney("120"),
+			wantStoredBal: models.MustParseMoney("120"),
+		},
+		{
+			name:          "insufficient funds",
+			tx:            models.Transaction{TransactionID: "it3", CustomerID: "test_customer", Type: "debit", Amount: models.MustParseMoney("500"), Timestamp: time.Now()},
+			wantStatus:    "failed",
+			wantCode:      models.ErrorCodeInsufficientFunds,
+			wantBalance:   models.MustParseMoney("0"),
+			wantStoredBal: models.MustParseMoney("120"),
+		},
+		{
+			name:          "unknown customer",
+			tx:            models.Transaction{TransactionID: "it4", CustomerID: "missing", Type: "credit", Amount: models.MustParseMoney("10"), Timestamp: time.Now()},
+			wantStatus:    "failed",
+			wantCode:      models.ErrorCodeCustomerNotFound,
+			wantBalance:   models.MustParseMoney("0"),
+			wantStoredBal: models.MustParseMoney("120"),
+		},
+	}
 
-	return customers, transactions
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue.Enqueue(tt.tx)
+
+			select {
+			case status := <-worker.GetCompletionChan():
+				if status.Status != tt.wantStatus {
+					t.Errorf("Expected status %s, got %s", tt.wantStatus, status.Status)
+				}
+				if status.ErrorCode != tt.wantCode {
+					t.Errorf("Expected error code %q, got %q", tt.wantCode, status.ErrorCode)
+				}
+				if !status.Balance.Equal(tt.wantBalance) {
+					t.Errorf("Expected balance %v, got %v", tt.wantBalance, status.Balance)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Transaction processing timed out")
+			}
+
+			customer, err := ledgerStore.GetCustomer(context.Background(), "test_customer")
+			if err != nil {
+				t.Fatalf("Failed to load customer: %v", err)
+			}
+			if !customer.Balance.Equal(tt.wantStoredBal) {
+				t.Errorf("Expected stored balance %v, got %v", tt.wantStoredBal, customer.Balance)
+			}
+		})
+	}
+
+	history, err := ledgerStore.GetTransactionHistory(context.Background(), "test_customer")
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected 2 posted transactions, got %d", len(history))
+	}
 }
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"errors"
+	"ledger-service/models"
+	"ledger-service/store"
+	"math/rand"
+	"time"
+)
+
+// ErrorClass tells the worker whether a failed posting is worth retrying
+type ErrorClass int
+
+const (
+	// ErrorTransient errors, such as a storage outage, may succeed on a later attempt
+	ErrorTransient ErrorClass = iota
+	// ErrorPermanent errors, such as insufficient funds, fail the same way every time
+	ErrorPermanent
+)
+
+// RetryPolicy controls how a worker retries postings that fail with transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the wait before the second attempt; it doubles for every attempt after that
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts
+	MaxDelay time.Duration
+	// Jitter is the fraction of each wait, between 0 and 1, that is randomized
+	Jitter float64
+	// Classify decides whether an error is transient or permanent
+	Classify func(err error) ErrorClass
+}
+
+// DefaultRetryPolicy is used by workers unless another policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Jitter:      0.5,
+	Classify:    ClassifyError,
+}
+
+// ClassifyError treats business rejections and invalid postings as permanent
+// and everything else, such as storage failures, as transient
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case errors.Is(err, models.ErrInsufficientFunds),
+		errors.Is(err, models.ErrAccountClosed),
+		errors.Is(err, models.ErrAccountFrozen),
+		errors.Is(err, store.ErrCustomerNotFound),
+		errors.Is(err, store.ErrDuplicateKey),
+		errors.Is(err, models.ErrUnbalancedEntry),
+		errors.Is(err, models.ErrExcessPrecision),
+		errors.Is(err, models.ErrMoneyOverflow),
+		errors.Is(err, models.ErrUnsupportedCurrency):
+		return ErrorPermanent
+	}
+	return ErrorTransient
+}
+
+// ErrorCodeOf maps a posting error to the code reported to clients
+func ErrorCodeOf(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return models.ErrorCodeInsufficientFunds
+	case errors.Is(err, store.ErrCustomerNotFound):
+		return models.ErrorCodeCustomerNotFound
+	case errors.Is(err, models.ErrAccountClosed):
+		return models.ErrorCodeAccountClosed
+	case errors.Is(err, models.ErrAccountFrozen):
+		return models.ErrorCodeAccountFrozen
+	case errors.Is(err, models.ErrUnsupportedCurrency):
+		return models.ErrorCodeUnsupportedCurrency
+	case errors.Is(err, models.ErrExcessPrecision),
+		errors.Is(err, models.ErrMoneyOverflow):
+		return models.ErrorCodeValidationFailed
+	case ClassifyError(err) == ErrorTransient:
+		return models.ErrorCodeStorageUnavailable
+	}
+	return models.ErrorCodeInternal
+}
+
+// IsPermanent reports whether err should fail the transaction without a retry
+func (p RetryPolicy) IsPermanent(err error) bool {
+	classify := p.Classify
+	if classify == nil {
+		classify = ClassifyError
+	}
+	return classify(err) == ErrorPermanent
+}
+
+// Backoff returns how long to wait after the given failed attempt (starting at 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"ledger-service/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errStorageDown = errors.New("storage unavailable")
+
+// flakyStore fails the first failures session transactions with errStorageDown
+type flakyStore struct {
+	*store.MemoryStore
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (s *flakyStore) WithTransaction(ctx context.Context, fn func(tx store.Tx) error) error {
+	s.mu.Lock()
+	s.attempts++
+	fail := s.attempts <= s.failures
+	s.mu.Unlock()
+	if fail {
+		return errStorageDown
+	}
+	return s.MemoryStore.WithTransaction(ctx, fn)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			if got := policy.Backoff(tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("Backoff(2) with jitter = %v, want between 100ms and 200ms", got)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"insufficient funds", models.ErrInsufficientFunds, ErrorPermanent},
+		{"customer not found", fmt.Errorf("posting: %w", store.ErrCustomerNotFound), ErrorPermanent},
+		{"unbalanced entry", models.ErrUnbalancedEntry, ErrorPermanent},
+		{"storage failure", errStorageDown, ErrorTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want models.ErrorCode
+	}{
+		{"insufficient funds", models.ErrInsufficientFunds, models.ErrorCodeInsufficientFunds},
+		{"customer not found", store.ErrCustomerNotFound, models.ErrorCodeCustomerNotFound},
+		{"overflow", models.ErrMoneyOverflow, models.ErrorCodeValidationFailed},
+		{"unbalanced entry", models.ErrUnbalancedEntry, models.ErrorCodeInternal},
+		{"storage failure", errStorageDown, models.ErrorCodeStorageUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCodeOf(tt.err); got != tt.want {
+				t.Errorf("ErrorCodeOf(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		wantStatus     string
+		wantCode       models.ErrorCode
+		wantDeadLetter bool
+	}{
+		{"recovers before the last attempt", 2, "completed", "", false},
+		{"dead-lettered when attempts are exhausted", 10, "failed", models.ErrorCodeStorageUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryStore := store.NewMemoryStore()
+			customer := models.Customer{CustomerID: "c1", Name: "Customer", Balance: models.MustParseMoney("100")}
+			if err := memoryStore.CreateCustomer(context.Background(), customer); err != nil {
+				t.Fatalf("Failed to create test customer: %v", err)
+			}
+			ledgerStore := &flakyStore{MemoryStore: memoryStore, failures: tt.failures}
+
+			intake := NewTransactionQueue()
+			dispatcher := NewDispatcher(ledgerStore, intake, DefaultIdleTimeout)
+			dispatcher.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+			dispatcher.SetDeadLetterStore(memoryStore)
+			dispatcher.Start()
+			defer dispatcher.Stop()
+
+			tx := models.Transaction{TransactionID: "t1", CustomerID: "c1", Type: "credit", Amount: models.MustParseMoney("5"), Timestamp: time.Now()}
+			completion, err := dispatcher.Submit(tx)
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+
+			select {
+			case status := <-completion:
+				if status.Status != tt.wantStatus || status.ErrorCode != tt.wantCode {
+					t.Errorf("status = %s %s (%s), want %s %s", status.Status, status.ErrorCode, status.FailureReason, tt.wantStatus, tt.wantCode)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for completion")
+			}
+
+			_, err = memoryStore.GetDeadLetter(context.Background(), "t1")
+			if gotDeadLetter := err == nil; gotDeadLetter != tt.wantDeadLetter {
+				t.Errorf("dead-lettered = %v, want %v", gotDeadLetter, tt.wantDeadLetter)
+			}
+			if tt.wantDeadLetter && ledgerStore.attempts != 3 {
+				t.Errorf("attempts = %d, want 3", ledgerStore.attempts)
+			}
+		})
+	}
+}
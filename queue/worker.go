@@ -2,40 +2,52 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"ledger-service/audit"
+	"ledger-service/ledger"
 	"ledger-service/models"
+	"ledger-service/store"
+	"log"
 	"sync"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Worker processes transactions for a specific customer
 type Worker struct {
-	customerID             string
-	queue                  *TransactionQueue
-	customersCollection    *mongo.Collection
-	transactionsCollection *mongo.Collection
-	stopChan               chan struct{}
-	completionChan         chan models.TransactionStatusResponse
-	mu                     sync.RWMutex
-	stopped                bool
+	customerID     string
+	queue          Queue
+	store          store.LedgerStore
+	retryPolicy    RetryPolicy
+	policy         ledger.Policy
+	deadLetters    store.DeadLetterStore
+	stopChan       chan struct{}
+	done           chan struct{}
+	completionChan chan models.TransactionStatusResponse
+	mu             sync.RWMutex
+	started        bool
+	stopped        bool
+	processing     bool
+	lastActive     time.Time
+	onSettled      func(t models.Transaction)
 }
 
 // NewWorker creates a new worker for a specific customer
 func NewWorker(
 	customerID string,
-	queue *TransactionQueue,
-	customersCollection *mongo.Collection,
-	transactionsCollection *mongo.Collection,
+	queue Queue,
+	ledgerStore store.LedgerStore,
 ) *Worker {
 	return &Worker{
-		customerID:             customerID,
-		queue:                  queue,
-		customersCollection:    customersCollection,
-		transactionsCollection: transactionsCollection,
-		stopChan:               make(chan struct{}),
-		completionChan:         make(chan models.TransactionStatusResponse, 100),
+		customerID:     customerID,
+		queue:          queue,
+		store:          ledgerStore,
+		retryPolicy:    DefaultRetryPolicy,
+		policy:         ledger.DefaultPolicy,
+		stopChan:       make(chan struct{}),
+		done:           make(chan struct{}),
+		completionChan: make(chan models.TransactionStatusResponse, 100),
+		lastActive:     time.Now(),
 	}
 }
 
@@ -43,7 +55,8 @@ func NewWorker(
 func (w *Worker) Start() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	if !w.stopped {
+	if !w.stopped && !w.started {
+		w.started = true
 		go w.processTransactions()
 	}
 }
@@ -63,127 +76,204 @@ func (w *Worker) GetCompletionChan() <-chan models.TransactionStatusResponse {
 	return w.completionChan
 }
 
+// idleFor returns how long the worker has had no queued or in-flight work.
+// It returns zero while the worker is busy.
+func (w *Worker) idleFor(now time.Time) time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.processing || !w.queue.IsEmpty() {
+		return 0
+	}
+	return now.Sub(w.lastActive)
+}
+
+// next dequeues the next transaction and marks the worker busy in one step,
+// so idleFor never sees an empty queue while a transaction is in hand
+func (w *Worker) next() (models.Transaction, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t, ok := w.queue.Dequeue()
+	if ok {
+		w.processing = true
+	}
+	return t, ok
+}
+
+func (w *Worker) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.processing = false
+	w.lastActive = time.Now()
+}
+
 func (w *Worker) processTransactions() {
+	defer close(w.done)
 	for {
 		select {
 		case <-w.stopChan:
 			return
 		default:
-			if w.queue.IsEmpty() {
-				time.Sleep(100 * time.Millisecond)
+			t, ok := w.next()
+			if !ok {
+				select {
+				case <-w.stopChan:
+					return
+				case <-w.queue.Ready():
+				case <-time.After(100 * time.Millisecond):
+				}
 				continue
 			}
 
-			if t, ok := w.queue.Dequeue(); ok {
-				w.processTransaction(t)
+			if w.processTransaction(t) {
+				w.queue.Ack(t.TransactionID)
+				if w.onSettled != nil {
+					w.onSettled(t)
+				}
 			}
+			w.finish()
 		}
 	}
 }
 
-func (w *Worker) processTransaction(t models.Transaction) {
-	// Check for nil collections
-	if w.customersCollection == nil || w.transactionsCollection == nil {
-		w.completionChan <- models.TransactionStatusResponse{
-			TransactionID: t.TransactionID,
-			Status:        "failed",
-			Balance:       0,
-		}
-		return
+// processTransaction posts t, retrying transient failures according to the
+// worker's retry policy, and reports whether it is settled, i.e. whether it was
+// committed, permanently rejected or dead-lettered
+func (w *Worker) processTransaction(t models.Transaction) bool {
+	// Check for missing store
+	if w.store == nil {
+		w.fail(t, models.ErrorCodeInternal, "ledger store is not configured")
+		return true
 	}
 
 	// Validate transaction
 	if t.Type != "credit" && t.Type != "debit" {
-		w.completionChan <- models.TransactionStatusResponse{
-			TransactionID: t.TransactionID,
-			Status:        "failed",
-			Balance:       0,
-		}
-		return
+		w.fail(t, models.ErrorCodeValidationFailed, "invalid transaction type")
+		return true
 	}
 
-	if t.Amount <= 0 {
-		w.completionChan <- models.TransactionStatusResponse{
-			TransactionID: t.TransactionID,
-			Status:        "failed",
-			Balance:       0,
-		}
-		return
+	if !t.Amount.IsPositive() {
+		w.fail(t, models.ErrorCodeValidationFailed, "amount must be positive")
+		return true
 	}
 
-	// Start MongoDB session
-	session, err := w.customersCollection.Database().Client().StartSession()
-	if err != nil {
-		w.completionChan <- models.TransactionStatusResponse{
-			TransactionID: t.TransactionID,
-			Status:        "failed",
-			Balance:       0,
-		}
-		return
+	if _, err := models.LookupCurrency(t.CurrencyCode()); err != nil {
+		w.fail(t, models.ErrorCodeUnsupportedCurrency, err.Error())
+		return true
 	}
-	defer session.EndSession(context.Background())
 
-	var updatedBalance float64
-	// Process transaction in a session
-	_, err = session.WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// Get current customer
-		var customer models.Customer
-		err := w.customersCollection.FindOne(sessCtx, bson.M{"_id": t.CustomerID}).Decode(&customer)
-		if err != nil {
-			return nil, err
+	for attempt := 1; ; attempt++ {
+		updatedBalance, err := w.post(t)
+		if err == nil {
+			w.completionChan <- models.TransactionStatusResponse{
+				TransactionID: t.TransactionID,
+				Status:        "completed",
+				Balance:       updatedBalance,
+				Currency:      t.CurrencyCode(),
+			}
+			return true
 		}
 
-		// Check for insufficient funds before updating balance
-		if t.Type == "debit" && customer.Balance < t.Amount {
-			return nil, models.ErrInsufficientFunds
+		if errors.Is(err, store.ErrDuplicateKey) {
+			// Redelivered after it was already posted, e.g. following a crash
+			// between the ledger commit and the queue acknowledgement
+			record, lookupErr := w.store.GetTransactionStatus(context.Background(), t.TransactionID)
+			if lookupErr == nil && record.Status == models.TransactionStatusCompleted && record.Balance != nil {
+				w.completionChan <- models.TransactionStatusResponse{
+					TransactionID: t.TransactionID,
+					Status:        "completed",
+					Balance:       *record.Balance,
+					Currency:      t.CurrencyCode(),
+				}
+				return true
+			}
 		}
 
-		// Update balance
-		if t.Type == "credit" {
-			customer.Balance += t.Amount
-		} else {
-			customer.Balance -= t.Amount
+		if w.retryPolicy.IsPermanent(err) {
+			w.fail(t, ErrorCodeOf(err), err.Error())
+			return true
 		}
 
-		// Update customer
-		_, err = w.customersCollection.UpdateOne(
-			sessCtx,
-			bson.M{"_id": t.CustomerID},
-			bson.M{"$set": bson.M{"balance": customer.Balance}},
-		)
-		if err != nil {
-			return nil, err
+		if attempt >= w.retryPolicy.MaxAttempts {
+			w.deadLetter(t, attempt, err)
+			return true
 		}
 
-		// Store the updated balance
-		updatedBalance = customer.Balance
+		log.Printf("attempt %d of transaction %s failed, retrying: %v", attempt, t.TransactionID, err)
+		if !w.wait(w.retryPolicy.Backoff(attempt)) {
+			// Stopped while backing off; the transaction stays unacknowledged
+			// in the intake queue, so a durable queue delivers it again
+			return false
+		}
+	}
+}
 
-		// Insert transaction
-		_, err = w.transactionsCollection.InsertOne(sessCtx, t)
-		return nil, err
+// post applies t atomically, together with its status record. The account
+// state is checked in the same session, so a concurrent freeze or close either
+// happens before the posting or after it.
+func (w *Worker) post(t models.Transaction) (models.Money, error) {
+	origin := models.SystemOrigin
+	if t.Origin != nil {
+		origin = *t.Origin
+	}
+	ctx := audit.NewContext(context.Background(), origin)
+	var updatedBalance models.Money
+	err := w.store.WithTransaction(ctx, func(tx store.Tx) error {
+		var err error
+		updatedBalance, err = ledger.ApplyTransaction(tx, t, w.policy)
+		if err != nil {
+			return err
+		}
+		status := models.CompletedStatus(t, updatedBalance)
+		if err := tx.SaveTransactionStatus(status); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, models.AuditTransactionPost, models.AuditTargetTransaction, t.TransactionID, nil, status)
 	})
+	return updatedBalance, err
+}
 
-	if err != nil {
-		if err == models.ErrInsufficientFunds {
-			w.completionChan <- models.TransactionStatusResponse{
-				TransactionID: t.TransactionID,
-				Status:        "failed",
-				Balance:       0,
-			}
-			return
-		}
-		w.queue.Enqueue(t)
-		w.completionChan <- models.TransactionStatusResponse{
+// wait sleeps for d and reports false if the worker was stopped meanwhile
+func (w *Worker) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-w.stopChan:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// deadLetter parks t for an operator after its retries are exhausted and
+// reports it as failed
+func (w *Worker) deadLetter(t models.Transaction, attempts int, err error) {
+	if w.deadLetters != nil {
+		deadLetter := models.DeadLetter{
 			TransactionID: t.TransactionID,
-			Status:        "failed",
-			Balance:       0,
+			Transaction:   t,
+			Attempts:      attempts,
+			LastError:     err.Error(),
+			FailedAt:      time.Now(),
+		}
+		if saveErr := w.deadLetters.SaveDeadLetter(context.Background(), deadLetter); saveErr != nil {
+			log.Printf("failed to dead-letter transaction %s: %v", t.TransactionID, saveErr)
 		}
-		return
 	}
+	w.fail(t, ErrorCodeOf(err), fmt.Sprintf("gave up after %d attempts: %v", attempts, err))
+}
 
+// fail records t as rejected with code and notifies the submitter
+func (w *Worker) fail(t models.Transaction, code models.ErrorCode, reason string) {
+	if w.store != nil {
+		if err := w.store.SaveTransactionStatus(context.Background(), models.FailedStatus(t, code, reason)); err != nil {
+			log.Printf("failed to record status of transaction %s: %v", t.TransactionID, err)
+		}
+	}
 	w.completionChan <- models.TransactionStatusResponse{
 		TransactionID: t.TransactionID,
-		Status:        "completed",
-		Balance:       updatedBalance,
+		Status:        "failed",
+		Balance:       models.Money{},
+		ErrorCode:     code,
+		FailureReason: reason,
 	}
 }
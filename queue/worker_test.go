@@ -93,7 +93,7 @@ func (m *mockCollection) Database() *mongo.Database {
 
 func TestWorkerQueueOperations(t *testing.T) {
 	queue := NewTransactionQueue()
-	worker := NewWorker("test_customer", queue, nil, nil)
+	worker := NewWorker("test_customer", queue, nil)
 	worker.Start()
 	defer worker.Stop()
 
@@ -102,7 +102,7 @@ func TestWorkerQueueOperations(t *testing.T) {
 		TransactionID: "test1",
 		CustomerID:    "test_customer",
 		Type:          "credit",
-		Amount:        100,
+		Amount:        models.MustParseMoney("100"),
 		Timestamp:     time.Now(),
 	}
 
@@ -111,7 +111,7 @@ func TestWorkerQueueOperations(t *testing.T) {
 	select {
 	case status := <-worker.GetCompletionChan():
 		if status.Status != "failed" {
-			t.Error("Transaction should fail without database connection")
+			t.Error("Transaction should fail without a ledger store")
 		}
 	case <-time.After(1 * time.Second):
 		t.Error("Transaction processing timed out")
@@ -120,7 +120,7 @@ func TestWorkerQueueOperations(t *testing.T) {
 
 func TestWorkerLifecycle(t *testing.T) {
 	queue := NewTransactionQueue()
-	worker := NewWorker("test_customer", queue, nil, nil)
+	worker := NewWorker("test_customer", queue, nil)
 	worker.Start()
 	defer worker.Stop()
 
@@ -133,7 +133,7 @@ func TestWorkerLifecycle(t *testing.T) {
 		TransactionID: "test1",
 		CustomerID:    "test_customer",
 		Type:          "credit",
-		Amount:        100,
+		Amount:        models.MustParseMoney("100"),
 		Timestamp:     time.Now(),
 	}
 
@@ -148,7 +148,7 @@ func TestWorkerLifecycle(t *testing.T) {
 
 func TestWorkerTransactionValidation(t *testing.T) {
 	queue := NewTransactionQueue()
-	worker := NewWorker("test_customer", queue, nil, nil)
+	worker := NewWorker("test_customer", queue, nil)
 	worker.Start()
 	defer worker.Stop()
 
@@ -157,7 +157,7 @@ func TestWorkerTransactionValidation(t *testing.T) {
 		TransactionID: "test1",
 		CustomerID:    "test_customer",
 		Type:          "invalid",
-		Amount:        100,
+		Amount:        models.MustParseMoney("100"),
 		Timestamp:     time.Now(),
 	}
 
@@ -177,7 +177,7 @@ func TestWorkerTransactionValidation(t *testing.T) {
 		TransactionID: "test2",
 		CustomerID:    "test_customer",
 		Type:          "credit",
-		Amount:        -100,
+		Amount:        models.MustParseMoney("-100"),
 		Timestamp:     time.Now(),
 	}
 
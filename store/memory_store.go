@@ -0,0 +1,799 @@
+package store
+
+import (
+	"context"
+	"ledger-service/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a LedgerStore that keeps all data in process memory.
+// Transactions are serialized and rolled back through an undo log, so it
+// offers the same all-or-nothing semantics as the MongoDB implementation.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	customers    map[string]models.Customer
+	transactions map[string]models.Transaction
+	order        []string
+	idempotency  map[string]models.IdempotencyRecord
+	journal      []models.JournalEntry
+	statuses     map[string]models.TransactionStatusRecord
+	deadLetters  map[string]models.DeadLetter
+	statusLog    []models.AccountStatusChange
+	holds        map[string]models.Hold
+	rates        map[string]models.ExchangeRate
+	quotes       map[string]models.FXQuote
+	conversions  map[string]models.Conversion
+	apiKeys      map[string]models.APIKey
+	nonces       map[string]time.Time
+	auditLog     []models.AuditEntry
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a new empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		customers:    make(map[string]models.Customer),
+		transactions: make(map[string]models.Transaction),
+		idempotency:  make(map[string]models.IdempotencyRecord),
+		statuses:     make(map[string]models.TransactionStatusRecord),
+		deadLetters:  make(map[string]models.DeadLetter),
+		holds:        make(map[string]models.Hold),
+		rates:        make(map[string]models.ExchangeRate),
+		quotes:       make(map[string]models.FXQuote),
+		conversions:  make(map[string]models.Conversion),
+		apiKeys:      make(map[string]models.APIKey),
+		nonces:       make(map[string]time.Time),
+	}
+}
+
+// CreateCustomer stores a new customer
+func (s *MemoryStore) CreateCustomer(ctx context.Context, customer models.Customer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.customers[customer.CustomerID]; exists {
+		return ErrDuplicateKey
+	}
+	s.customers[customer.CustomerID] = customer
+	return nil
+}
+
+// GetCustomer returns the customer with the given ID
+func (s *MemoryStore) GetCustomer(ctx context.Context, customerID string) (models.Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	customer, ok := s.customers[customerID]
+	if !ok {
+		return models.Customer{}, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+// ListCustomers returns the customers matching query ordered by customer ID
+func (s *MemoryStore) ListCustomers(ctx context.Context, query CustomerQuery) ([]models.Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	nameContains := strings.ToLower(query.NameContains)
+	customers := []models.Customer{}
+	for _, customer := range s.customers {
+		if customer.CustomerID <= query.AfterID {
+			continue
+		}
+		if nameContains != "" && !strings.Contains(strings.ToLower(customer.Name), nameContains) {
+			continue
+		}
+		customers = append(customers, customer)
+	}
+	sort.Slice(customers, func(i, j int) bool { return customers[i].CustomerID < customers[j].CustomerID })
+	if query.Limit > 0 && len(customers) > query.Limit {
+		customers = customers[:query.Limit]
+	}
+	return customers, nil
+}
+
+// GetStatusHistory returns the account state changes of a customer in insertion order
+func (s *MemoryStore) GetStatusHistory(ctx context.Context, customerID string) ([]models.AccountStatusChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	changes := []models.AccountStatusChange{}
+	for _, change := range s.statusLog {
+		if change.CustomerID == customerID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// GetTransactionHistory returns every transaction posted for a customer in insertion order
+func (s *MemoryStore) GetTransactionHistory(ctx context.Context, customerID string) ([]models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	transactions := []models.Transaction{}
+	for _, id := range s.order {
+		if t := s.transactions[id]; t.CustomerID == customerID {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions, nil
+}
+
+// QueryTransactions returns a page of a customer's transactions matching query
+func (s *MemoryStore) QueryTransactions(ctx context.Context, query TransactionQuery) ([]models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	transactions := []models.Transaction{}
+	for _, id := range s.order {
+		if t := s.transactions[id]; query.Matches(t) && (query.After == nil || sortsAfter(t, *query.After, query.Descending)) {
+			transactions = append(transactions, t)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return sortsAfter(transactions[j], positionOf(transactions[i]), query.Descending)
+	})
+	if query.Limit > 0 && len(transactions) > query.Limit {
+		transactions = transactions[:query.Limit]
+	}
+	return transactions, nil
+}
+
+func positionOf(t models.Transaction) TransactionCursor {
+	return TransactionCursor{Timestamp: t.Timestamp, TransactionID: t.TransactionID}
+}
+
+// sortsAfter reports whether t comes after position in timestamp and ID order,
+// or before it when descending
+func sortsAfter(t models.Transaction, position TransactionCursor, descending bool) bool {
+	cmp := t.Timestamp.Compare(position.Timestamp)
+	if cmp == 0 {
+		cmp = strings.Compare(t.TransactionID, position.TransactionID)
+	}
+	if descending {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// SaveTransactionStatus creates or replaces the status record of a transaction
+func (s *MemoryStore) SaveTransactionStatus(ctx context.Context, record models.TransactionStatusRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[record.TransactionID] = record
+	return nil
+}
+
+// GetTransactionStatus returns the status record of a transaction
+func (s *MemoryStore) GetTransactionStatus(ctx context.Context, transactionID string) (models.TransactionStatusRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.statuses[transactionID]
+	if !ok {
+		return models.TransactionStatusRecord{}, ErrTransactionNotFound
+	}
+	return record, nil
+}
+
+// GetHold returns the hold with the given ID
+func (s *MemoryStore) GetHold(ctx context.Context, holdID string) (models.Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hold, ok := s.holds[holdID]
+	if !ok {
+		return models.Hold{}, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// ListHolds returns the holds of a customer, oldest first
+func (s *MemoryStore) ListHolds(ctx context.Context, customerID, status string) ([]models.Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	holds := []models.Hold{}
+	for _, hold := range s.holds {
+		if hold.CustomerID == customerID && (status == "" || hold.Status == status) {
+			holds = append(holds, hold)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		if !holds[i].CreatedAt.Equal(holds[j].CreatedAt) {
+			return holds[i].CreatedAt.Before(holds[j].CreatedAt)
+		}
+		return holds[i].HoldID < holds[j].HoldID
+	})
+	return holds, nil
+}
+
+// ListExpiredHolds returns up to limit active holds that expired by now
+func (s *MemoryStore) ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]models.Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	holds := []models.Hold{}
+	for _, hold := range s.holds {
+		if hold.ExpiredAt(now) {
+			holds = append(holds, hold)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].ExpiresAt.Before(holds[j].ExpiresAt) })
+	if limit > 0 && len(holds) > limit {
+		holds = holds[:limit]
+	}
+	return holds, nil
+}
+
+// SaveDeadLetter creates or replaces the dead letter of a transaction
+func (s *MemoryStore) SaveDeadLetter(ctx context.Context, deadLetter models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters[deadLetter.TransactionID] = deadLetter
+	return nil
+}
+
+// ListDeadLetters returns up to limit dead letters, oldest first
+func (s *MemoryStore) ListDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	deadLetters := make([]models.DeadLetter, 0, len(s.deadLetters))
+	for _, deadLetter := range s.deadLetters {
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool { return deadLetters[i].FailedAt.Before(deadLetters[j].FailedAt) })
+	if limit > 0 && len(deadLetters) > limit {
+		deadLetters = deadLetters[:limit]
+	}
+	return deadLetters, nil
+}
+
+// GetDeadLetter returns the dead letter of a transaction
+func (s *MemoryStore) GetDeadLetter(ctx context.Context, transactionID string) (models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	deadLetter, ok := s.deadLetters[transactionID]
+	if !ok {
+		return models.DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return deadLetter, nil
+}
+
+// DeleteDeadLetter removes the dead letter of a transaction
+func (s *MemoryStore) DeleteDeadLetter(ctx context.Context, transactionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deadLetters[transactionID]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(s.deadLetters, transactionID)
+	return nil
+}
+
+// SaveExchangeRate creates or replaces the rate with the same ID
+func (s *MemoryStore) SaveExchangeRate(ctx context.Context, rate models.ExchangeRate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[rate.RateID] = rate
+	return nil
+}
+
+// ListExchangeRates returns the rates matching the currency pair, ordered by
+// pair and then effective time
+func (s *MemoryStore) ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rates := []models.ExchangeRate{}
+	for _, rate := range s.rates {
+		if (base == "" || rate.BaseCurrency == base) && (quote == "" || rate.QuoteCurrency == quote) {
+			rates = append(rates, rate)
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].BaseCurrency != rates[j].BaseCurrency {
+			return rates[i].BaseCurrency < rates[j].BaseCurrency
+		}
+		if rates[i].QuoteCurrency != rates[j].QuoteCurrency {
+			return rates[i].QuoteCurrency < rates[j].QuoteCurrency
+		}
+		return rates[i].EffectiveFrom.Before(rates[j].EffectiveFrom)
+	})
+	return rates, nil
+}
+
+// GetEffectiveRate returns the rate of base in quote in effect at the given moment
+func (s *MemoryStore) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (models.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var effective *models.ExchangeRate
+	for _, rate := range s.rates {
+		if rate.BaseCurrency != base || rate.QuoteCurrency != quote || rate.EffectiveFrom.After(at) {
+			continue
+		}
+		if effective == nil || rate.EffectiveFrom.After(effective.EffectiveFrom) {
+			effective = &rate
+		}
+	}
+	if effective == nil {
+		return models.ExchangeRate{}, ErrExchangeRateNotFound
+	}
+	return *effective, nil
+}
+
+// InsertQuote stores a new quote
+func (s *MemoryStore) InsertQuote(ctx context.Context, quote models.FXQuote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.quotes[quote.QuoteID]; exists {
+		return ErrDuplicateKey
+	}
+	s.quotes[quote.QuoteID] = quote
+	return nil
+}
+
+// GetQuote returns the quote with the given ID
+func (s *MemoryStore) GetQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	quote, ok := s.quotes[quoteID]
+	if !ok {
+		return models.FXQuote{}, ErrQuoteNotFound
+	}
+	return quote, nil
+}
+
+// GetConversion returns the conversion with the given ID
+func (s *MemoryStore) GetConversion(ctx context.Context, conversionID string) (models.Conversion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conversion, ok := s.conversions[conversionID]
+	if !ok {
+		return models.Conversion{}, ErrConversionNotFound
+	}
+	return conversion, nil
+}
+
+// ListConversions returns the conversions of a customer, oldest first
+func (s *MemoryStore) ListConversions(ctx context.Context, customerID string) ([]models.Conversion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conversions := []models.Conversion{}
+	for _, conversion := range s.conversions {
+		if conversion.CustomerID == customerID {
+			conversions = append(conversions, conversion)
+		}
+	}
+	sort.Slice(conversions, func(i, j int) bool {
+		if !conversions[i].Timestamp.Equal(conversions[j].Timestamp) {
+			return conversions[i].Timestamp.Before(conversions[j].Timestamp)
+		}
+		return conversions[i].ConversionID < conversions[j].ConversionID
+	})
+	return conversions, nil
+}
+
+// InsertAPIKey stores a new API key
+func (s *MemoryStore) InsertAPIKey(ctx context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.apiKeys {
+		if existing.KeyID == key.KeyID || existing.Hash == key.Hash {
+			return ErrDuplicateKey
+		}
+	}
+	s.apiKeys[key.KeyID] = key
+	return nil
+}
+
+// GetAPIKey returns the key with the given ID
+func (s *MemoryStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// FindAPIKeyByHash returns the key whose secret has the given hash
+func (s *MemoryStore) FindAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.apiKeys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+// ListAPIKeys returns every key, oldest first
+func (s *MemoryStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]models.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].KeyID < keys[j].KeyID
+	})
+	return keys, nil
+}
+
+// UpdateAPIKey replaces an existing key
+func (s *MemoryStore) UpdateAPIKey(ctx context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.apiKeys[key.KeyID]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	s.apiKeys[key.KeyID] = key
+	return nil
+}
+
+// TouchAPIKey records that a key was used at the given moment
+func (s *MemoryStore) TouchAPIKey(ctx context.Context, keyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(at) {
+		key.LastUsedAt = &at
+		s.apiKeys[keyID] = key
+	}
+	return nil
+}
+
+// InsertAuditEntry appends an entry to the audit log
+func (s *MemoryStore) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditLog = append(s.auditLog, entry)
+	return nil
+}
+
+// ListAuditEntries returns the entries matching query, newest first
+func (s *MemoryStore) ListAuditEntries(ctx context.Context, query AuditQuery) ([]models.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := []models.AuditEntry{}
+	for i := len(s.auditLog) - 1; i >= 0; i-- {
+		entry := s.auditLog[i]
+		if query.BeforeID != "" && entry.AuditID >= query.BeforeID {
+			continue
+		}
+		if !query.Matches(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// ClaimNonce records a client's nonce unless an unexpired use of it exists.
+// Expired nonces are dropped on the way, which keeps the map to the nonces of
+// the replay window.
+func (s *MemoryStore) ClaimNonce(ctx context.Context, clientID, nonce string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, expiry := range s.nonces {
+		if !expiry.After(now) {
+			delete(s.nonces, key)
+		}
+	}
+	key := clientID + "\x00" + nonce
+	if _, ok := s.nonces[key]; ok {
+		return false, nil
+	}
+	s.nonces[key] = expiresAt
+	return true, nil
+}
+
+// WithTransaction runs fn while holding the store lock and undoes every write
+// made through tx if fn returns an error
+func (s *MemoryStore) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryTx{store: s}
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// ClaimIdempotencyKey stores record unless an unexpired record with the same key exists
+func (s *MemoryStore) ClaimIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.idempotency[record.Key]; ok && !existing.IsExpired(time.Now()) {
+		return existing, false, nil
+	}
+	s.idempotency[record.Key] = record
+	return record, true, nil
+}
+
+// CompleteIdempotencyKey stores the final response for a claimed key
+func (s *MemoryStore) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, response models.TransactionStatusResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.idempotency[key]
+	if !ok {
+		return ErrIdempotencyKeyNotFound
+	}
+	record.Status = models.IdempotencyStatusCompleted
+	record.StatusCode = statusCode
+	record.Response = &response
+	s.idempotency[key] = record
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes the record for a key so it can be claimed again
+func (s *MemoryStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.idempotency, key)
+	return nil
+}
+
+// GetIdempotencyRecord returns the record for a key
+func (s *MemoryStore) GetIdempotencyRecord(ctx context.Context, key string) (models.IdempotencyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.idempotency[key]
+	if !ok {
+		return models.IdempotencyRecord{}, ErrIdempotencyKeyNotFound
+	}
+	return record, nil
+}
+
+// GetTrialBalance returns the net balance of every account with journal lines, ordered by account ID
+func (s *MemoryStore) GetTrialBalance(ctx context.Context) ([]models.AccountBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	totals := make(map[string]models.Money)
+	for _, entry := range s.journal {
+		for _, line := range entry.Lines {
+			totals[line.AccountID] = totals[line.AccountID].Add(line.Amount)
+		}
+	}
+	balances := make([]models.AccountBalance, 0, len(totals))
+	for accountID, balance := range totals {
+		balances = append(balances, models.AccountBalance{AccountID: accountID, Balance: balance})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].AccountID < balances[j].AccountID })
+	return balances, nil
+}
+
+// GetAccountBalance returns the net of all journal lines posted to an account
+func (s *MemoryStore) GetAccountBalance(ctx context.Context, accountID string) (models.Money, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var balance models.Money
+	for _, entry := range s.journal {
+		for _, line := range entry.Lines {
+			if line.AccountID == accountID {
+				balance = balance.Add(line.Amount)
+			}
+		}
+	}
+	return balance, nil
+}
+
+// memoryTx implements Tx on top of a locked MemoryStore
+type memoryTx struct {
+	store *MemoryStore
+	undo  []func()
+}
+
+func (tx *memoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
+
+func (tx *memoryTx) InsertCustomer(customer models.Customer) error {
+	if _, exists := tx.store.customers[customer.CustomerID]; exists {
+		return ErrDuplicateKey
+	}
+	tx.store.customers[customer.CustomerID] = customer
+	tx.undo = append(tx.undo, func() { delete(tx.store.customers, customer.CustomerID) })
+	return nil
+}
+
+func (tx *memoryTx) GetCustomer(customerID string) (models.Customer, error) {
+	customer, ok := tx.store.customers[customerID]
+	if !ok {
+		return models.Customer{}, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+func (tx *memoryTx) UpdateBalance(customerID, currency string, balance models.Money, sequence int64, postedAt time.Time) error {
+	customer, ok := tx.store.customers[customerID]
+	if !ok {
+		return ErrCustomerNotFound
+	}
+	previous := customer
+	updated := customer.BalanceIn(currency)
+	updated.Balance = balance
+	customer = customer.WithBalance(currency, updated)
+	customer.LastSequence = sequence
+	customer.LastPostedAt = &postedAt
+	customer.Version++
+	tx.store.customers[customerID] = customer
+	tx.undo = append(tx.undo, func() { tx.store.customers[customerID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) UpdateCustomer(customer models.Customer) error {
+	previous, ok := tx.store.customers[customer.CustomerID]
+	if !ok {
+		return ErrCustomerNotFound
+	}
+	if previous.Version != customer.Version {
+		return ErrVersionConflict
+	}
+	customer.Balance = previous.Balance
+	customer.HeldBalance = previous.HeldBalance
+	customer.Balances = previous.Balances
+	customer.LastSequence = previous.LastSequence
+	customer.LastPostedAt = previous.LastPostedAt
+	customer.Version++
+	tx.store.customers[customer.CustomerID] = customer
+	tx.undo = append(tx.undo, func() { tx.store.customers[customer.CustomerID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) UpdateHeldBalance(customerID, currency string, held models.Money) error {
+	customer, ok := tx.store.customers[customerID]
+	if !ok {
+		return ErrCustomerNotFound
+	}
+	previous := customer
+	updated := customer.BalanceIn(currency)
+	updated.HeldBalance = held
+	customer = customer.WithBalance(currency, updated)
+	customer.Version++
+	tx.store.customers[customerID] = customer
+	tx.undo = append(tx.undo, func() { tx.store.customers[customerID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) InsertHold(hold models.Hold) error {
+	if _, exists := tx.store.holds[hold.HoldID]; exists {
+		return ErrDuplicateKey
+	}
+	tx.store.holds[hold.HoldID] = hold
+	tx.undo = append(tx.undo, func() { delete(tx.store.holds, hold.HoldID) })
+	return nil
+}
+
+func (tx *memoryTx) GetHold(holdID string) (models.Hold, error) {
+	hold, ok := tx.store.holds[holdID]
+	if !ok {
+		return models.Hold{}, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+func (tx *memoryTx) ResolveHold(hold models.Hold) error {
+	previous, ok := tx.store.holds[hold.HoldID]
+	if !ok {
+		return ErrHoldNotFound
+	}
+	if !previous.IsActive() {
+		return models.ErrHoldNotActive
+	}
+	tx.store.holds[hold.HoldID] = hold
+	tx.undo = append(tx.undo, func() { tx.store.holds[hold.HoldID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) InsertStatusChange(change models.AccountStatusChange) error {
+	tx.store.statusLog = append(tx.store.statusLog, change)
+	tx.undo = append(tx.undo, func() { tx.store.statusLog = tx.store.statusLog[:len(tx.store.statusLog)-1] })
+	return nil
+}
+
+func (tx *memoryTx) InsertTransaction(t models.Transaction) error {
+	if _, exists := tx.store.transactions[t.TransactionID]; exists {
+		return ErrDuplicateKey
+	}
+	tx.store.transactions[t.TransactionID] = t
+	tx.store.order = append(tx.store.order, t.TransactionID)
+	tx.undo = append(tx.undo, func() {
+		delete(tx.store.transactions, t.TransactionID)
+		tx.store.order = tx.store.order[:len(tx.store.order)-1]
+	})
+	return nil
+}
+
+func (tx *memoryTx) GetTransaction(transactionID string) (models.Transaction, error) {
+	t, ok := tx.store.transactions[transactionID]
+	if !ok {
+		return models.Transaction{}, ErrTransactionNotFound
+	}
+	return t, nil
+}
+
+func (tx *memoryTx) UpdateReversalState(t models.Transaction) error {
+	previous, ok := tx.store.transactions[t.TransactionID]
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	updated := previous
+	updated.ReversedAmount = t.ReversedAmount
+	updated.ReversalStatus = t.ReversalStatus
+	updated.ReversalIDs = append([]string(nil), t.ReversalIDs...)
+	tx.store.transactions[t.TransactionID] = updated
+	tx.undo = append(tx.undo, func() { tx.store.transactions[t.TransactionID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) InsertJournalEntry(entry models.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	tx.store.journal = append(tx.store.journal, entry)
+	tx.undo = append(tx.undo, func() { tx.store.journal = tx.store.journal[:len(tx.store.journal)-1] })
+	return nil
+}
+
+func (tx *memoryTx) SaveTransactionStatus(record models.TransactionStatusRecord) error {
+	previous, existed := tx.store.statuses[record.TransactionID]
+	tx.store.statuses[record.TransactionID] = record
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			tx.store.statuses[record.TransactionID] = previous
+		} else {
+			delete(tx.store.statuses, record.TransactionID)
+		}
+	})
+	return nil
+}
+
+func (tx *memoryTx) ExecuteQuote(quoteID, conversionID string) error {
+	previous, ok := tx.store.quotes[quoteID]
+	if !ok {
+		return ErrQuoteNotFound
+	}
+	if previous.Status != models.QuoteStatusOpen {
+		return models.ErrQuoteUsed
+	}
+	executed := previous
+	executed.Status = models.QuoteStatusExecuted
+	executed.ConversionID = conversionID
+	tx.store.quotes[quoteID] = executed
+	tx.undo = append(tx.undo, func() { tx.store.quotes[quoteID] = previous })
+	return nil
+}
+
+func (tx *memoryTx) InsertConversion(conversion models.Conversion) error {
+	if _, exists := tx.store.conversions[conversion.ConversionID]; exists {
+		return ErrDuplicateKey
+	}
+	tx.store.conversions[conversion.ConversionID] = conversion
+	tx.undo = append(tx.undo, func() { delete(tx.store.conversions, conversion.ConversionID) })
+	return nil
+}
+
+func (tx *memoryTx) InsertAuditEntry(entry models.AuditEntry) error {
+	tx.store.auditLog = append(tx.store.auditLog, entry)
+	tx.undo = append(tx.undo, func() { tx.store.auditLog = tx.store.auditLog[:len(tx.store.auditLog)-1] })
+	return nil
+}
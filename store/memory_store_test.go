@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreCustomers(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	customer := models.Customer{CustomerID: "cust1", Name: "Test Customer", Balance: models.MustParseMoney("100")}
+	if err := s.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	if err := s.CreateCustomer(ctx, customer); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("CreateCustomer() duplicate error = %v, want %v", err, ErrDuplicateKey)
+	}
+
+	got, err := s.GetCustomer(ctx, "cust1")
+	if err != nil {
+		t.Fatalf("GetCustomer() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, customer) {
+		t.Errorf("GetCustomer() = %+v, want %+v", got, customer)
+	}
+
+	if _, err := s.GetCustomer(ctx, "missing"); !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("GetCustomer() missing error = %v, want %v", err, ErrCustomerNotFound)
+	}
+}
+
+func TestMemoryStoreWithTransactionCommit(t *testing.T) {
+	testWithTransactionCommit(t, NewMemoryStore())
+}
+
+// testWithTransactionCommit checks that s commits every write of a successful transaction
+func testWithTransactionCommit(t *testing.T, s Store) {
+	ctx := context.Background()
+	s.CreateCustomer(ctx, models.Customer{CustomerID: "cust1", Balance: models.MustParseMoney("100")})
+
+	err := s.WithTransaction(ctx, func(tx Tx) error {
+		if err := tx.UpdateBalance("cust1", models.DefaultCurrency, models.MustParseMoney("150"), 1, time.Now()); err != nil {
+			return err
+		}
+		return tx.InsertTransaction(models.Transaction{
+			TransactionID: "t1",
+			CustomerID:    "cust1",
+			Type:          "credit",
+			Amount:        models.MustParseMoney("50"),
+			Timestamp:     time.Now(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction() error = %v", err)
+	}
+
+	customer, _ := s.GetCustomer(ctx, "cust1")
+	if !customer.Balance.Equal(models.MustParseMoney("150")) {
+		t.Errorf("Balance = %v, want 150", customer.Balance)
+	}
+	history, _ := s.GetTransactionHistory(ctx, "cust1")
+	if len(history) != 1 || history[0].TransactionID != "t1" {
+		t.Errorf("GetTransactionHistory() = %+v, want single transaction t1", history)
+	}
+}
+
+func TestMemoryStoreWithTransactionRollback(t *testing.T) {
+	testWithTransactionRollback(t, NewMemoryStore())
+}
+
+// testWithTransactionRollback checks that s discards every write of a failed transaction
+func testWithTransactionRollback(t *testing.T, s Store) {
+	ctx := context.Background()
+	s.CreateCustomer(ctx, models.Customer{CustomerID: "cust1", Balance: models.MustParseMoney("100")})
+
+	errAbort := errors.New("abort")
+	err := s.WithTransaction(ctx, func(tx Tx) error {
+		if err := tx.UpdateBalance("cust1", models.DefaultCurrency, models.MustParseMoney("0"), 1, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.InsertTransaction(models.Transaction{TransactionID: "t1", CustomerID: "cust1"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction() error = %v, want %v", err, errAbort)
+	}
+
+	customer, _ := s.GetCustomer(ctx, "cust1")
+	if !customer.Balance.Equal(models.MustParseMoney("100")) {
+		t.Errorf("Balance = %v, want 100 after rollback", customer.Balance)
+	}
+	history, _ := s.GetTransactionHistory(ctx, "cust1")
+	if len(history) != 0 {
+		t.Errorf("GetTransactionHistory() = %+v, want empty after rollback", history)
+	}
+}
+
+func TestMemoryStoreQueryTransactions(t *testing.T) {
+	testQueryTransactions(t, NewMemoryStore())
+}
+
+// testQueryTransactions checks that s filters, orders and pages transactions
+func testQueryTransactions(t *testing.T, s Store) {
+	ctx := context.Background()
+	base := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	// Inserted out of timestamp order; t2 and t3 share a timestamp
+	postings := []struct {
+		id, transactionType, amount string
+		at                          time.Duration
+	}{
+		{"t3", "debit", "30", time.Hour},
+		{"t1", "credit", "10", 0},
+		{"t2", "credit", "20", time.Hour},
+		{"t4", "credit", "40", 2 * time.Hour},
+	}
+	err := s.WithTransaction(ctx, func(tx Tx) error {
+		for _, p := range postings {
+			if err := tx.InsertTransaction(models.Transaction{TransactionID: p.id, CustomerID: "cust1", Type: p.transactionType, Amount: models.MustParseMoney(p.amount), Timestamp: base.Add(p.at)}); err != nil {
+				return err
+			}
+		}
+		return tx.InsertTransaction(models.Transaction{TransactionID: "other", CustomerID: "cust2", Type: "credit", Amount: models.MustParseMoney("1"), Timestamp: base})
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction() error = %v", err)
+	}
+
+	minAmount := models.MustParseMoney("20")
+	tests := []struct {
+		name  string
+		query TransactionQuery
+		want  string
+	}{
+		{"ascending", TransactionQuery{CustomerID: "cust1"}, "t1,t2,t3,t4"},
+		{"descending", TransactionQuery{CustomerID: "cust1", Descending: true}, "t4,t3,t2,t1"},
+		{"type", TransactionQuery{CustomerID: "cust1", Type: "credit"}, "t1,t2,t4"},
+		{"min amount", TransactionQuery{CustomerID: "cust1", MinAmount: &minAmount}, "t2,t3,t4"},
+		{"time range", TransactionQuery{CustomerID: "cust1", From: base.Add(time.Hour), To: base.Add(2 * time.Hour)}, "t2,t3"},
+		{"limit", TransactionQuery{CustomerID: "cust1", Limit: 2}, "t1,t2"},
+		{"after tied timestamp", TransactionQuery{CustomerID: "cust1", After: &TransactionCursor{Timestamp: base.Add(time.Hour), TransactionID: "t2"}}, "t3,t4"},
+		{"after descending", TransactionQuery{CustomerID: "cust1", Descending: true, After: &TransactionCursor{Timestamp: base.Add(time.Hour), TransactionID: "t3"}}, "t2,t1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, err := s.QueryTransactions(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("QueryTransactions() error = %v", err)
+			}
+			ids := make([]string, len(transactions))
+			for i, transaction := range transactions {
+				ids[i] = transaction.TransactionID
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("QueryTransactions() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreClaimNonce(t *testing.T) {
+	testClaimNonce(t, NewMemoryStore())
+}
+
+// testClaimNonce checks that s accepts each unexpired nonce of a client once
+func testClaimNonce(t *testing.T, s Store) {
+	ctx := context.Background()
+	later := time.Now().Add(time.Minute)
+
+	claims := []struct {
+		clientID  string
+		nonce     string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"a", "n1", later, true},
+		{"a", "n1", later, false},
+		{"b", "n1", later, true},
+		{"a", "n2", time.Now().Add(-time.Second), true},
+		{"a", "n2", later, true},
+	}
+	for i, c := range claims {
+		got, err := s.ClaimNonce(ctx, c.clientID, c.nonce, c.expiresAt)
+		if err != nil {
+			t.Fatalf("ClaimNonce() #%d error = %v", i, err)
+		}
+		if got != c.want {
+			t.Errorf("ClaimNonce(%q, %q) #%d = %v, want %v", c.clientID, c.nonce, i, got, c.want)
+		}
+	}
+}
+
+func TestMemoryStoreAuditLog(t *testing.T) {
+	testAuditLog(t, NewMemoryStore())
+}
+
+// testAuditLog checks that s filters and pages the audit log
+func testAuditLog(t *testing.T, s Store) {
+	ctx := context.Background()
+	start := time.Date(2025, 4, 6, 10, 0, 0, 0, time.UTC)
+	writes := []struct {
+		id      string
+		actorID string
+		target  string
+		action  string
+	}{
+		{"a1", "key1", "c1", models.AuditCustomerCreate},
+		{"a2", "key2", "c2", models.AuditCustomerCreate},
+		{"a3", "key1", "c1", models.AuditCustomerUpdate},
+		{"a4", "key1", "c2", models.AuditCustomerUpdate},
+	}
+	for i, w := range writes {
+		err := s.InsertAuditEntry(ctx, models.AuditEntry{
+			AuditID:     w.id,
+			Timestamp:   start.Add(time.Duration(i) * time.Hour),
+			AuditOrigin: models.AuditOrigin{ActorType: models.ActorAPIKey, ActorID: w.actorID},
+			Action:      w.action,
+			TargetID:    w.target,
+		})
+		if err != nil {
+			t.Fatalf("InsertAuditEntry(%s) error = %v", w.id, err)
+		}
+	}
+
+	// A change that is rolled back leaves no entry behind
+	s.WithTransaction(ctx, func(tx Tx) error {
+		tx.InsertAuditEntry(models.AuditEntry{AuditID: "a5", Timestamp: start.Add(5 * time.Hour), TargetID: "c1"})
+		return errors.New("rolled back")
+	})
+
+	tests := []struct {
+		name  string
+		query AuditQuery
+		want  string
+	}{
+		{"everything newest first", AuditQuery{}, "a4,a3,a2,a1"},
+		{"by actor", AuditQuery{ActorID: "key1"}, "a4,a3,a1"},
+		{"by target", AuditQuery{TargetID: "c1"}, "a3,a1"},
+		{"by action", AuditQuery{Action: models.AuditCustomerCreate}, "a2,a1"},
+		{"from inclusive", AuditQuery{From: start.Add(time.Hour)}, "a4,a3,a2"},
+		{"to exclusive", AuditQuery{To: start.Add(2 * time.Hour)}, "a2,a1"},
+		{"page", AuditQuery{ActorID: "key1", Limit: 2}, "a4,a3"},
+		{"next page", AuditQuery{ActorID: "key1", BeforeID: "a3", Limit: 2}, "a1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.ListAuditEntries(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("ListAuditEntries() error = %v", err)
+			}
+			ids := make([]string, len(entries))
+			for i, entry := range entries {
+				ids[i] = entry.AuditID
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("ListAuditEntries() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,971 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is a LedgerStore backed by MongoDB collections
+type MongoStore struct {
+	client                 *mongo.Client
+	customersCollection    *mongo.Collection
+	transactionsCollection *mongo.Collection
+	idempotencyCollection  *mongo.Collection
+	journalCollection      *mongo.Collection
+	statusesCollection     *mongo.Collection
+	deadLettersCollection  *mongo.Collection
+	statusLogCollection    *mongo.Collection
+	holdsCollection        *mongo.Collection
+	ratesCollection        *mongo.Collection
+	quotesCollection       *mongo.Collection
+	conversionsCollection  *mongo.Collection
+	apiKeysCollection      *mongo.Collection
+	noncesCollection       *mongo.Collection
+	auditCollection        *mongo.Collection
+}
+
+var _ Store = (*MongoStore)(nil)
+
+// NewMongoStore creates a new MongoStore using the collections of the given database
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{
+		client:                 db.Client(),
+		customersCollection:    db.Collection("customers"),
+		transactionsCollection: db.Collection("transactions"),
+		idempotencyCollection:  db.Collection("idempotency_keys"),
+		journalCollection:      db.Collection("journal_entries"),
+		statusesCollection:     db.Collection("transaction_statuses"),
+		deadLettersCollection:  db.Collection("dead_letters"),
+		statusLogCollection:    db.Collection("account_status_changes"),
+		holdsCollection:        db.Collection("holds"),
+		ratesCollection:        db.Collection("exchange_rates"),
+		quotesCollection:       db.Collection("fx_quotes"),
+		conversionsCollection:  db.Collection("conversions"),
+		apiKeysCollection:      db.Collection("api_keys"),
+		noncesCollection:       db.Collection("request_nonces"),
+		auditCollection:        db.Collection("audit_log"),
+	}
+}
+
+// EnsureIndexes creates the indexes the store relies on
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	// Let MongoDB remove idempotency records once they have expired
+	_, err := s.idempotencyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.journalCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "lines.account_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Serves paginated history queries in both sort directions
+	_, err = s.transactionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Guarantees each posting sequence number is used once per customer.
+	// Transactions posted before sequencing have none and are exempt.
+	_, err = s.transactionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"sequence": bson.M{"$gt": 0}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.statusLogCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.holdsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Lets the expiry sweep find due holds without scanning resolved ones
+	_, err = s.holdsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"status": models.HoldStatusActive}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Serves effective rate lookups, which take the latest rate of a pair
+	_, err = s.ratesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "base_currency", Value: 1}, {Key: "quote_currency", Value: 1}, {Key: "effective_from", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conversionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Requests are authenticated by looking keys up by the hash of their secret
+	_, err = s.apiKeysCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Let MongoDB remove nonces once they are outside the replay window
+	_, err = s.noncesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Serve audit queries by actor and by target, newest first
+	_, err = s.auditCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+// CreateCustomer stores a new customer
+func (s *MongoStore) CreateCustomer(ctx context.Context, customer models.Customer) error {
+	_, err := s.customersCollection.InsertOne(ctx, customer)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+// GetCustomer returns the customer with the given ID
+func (s *MongoStore) GetCustomer(ctx context.Context, customerID string) (models.Customer, error) {
+	return findCustomer(ctx, s.customersCollection, customerID)
+}
+
+// ListCustomers returns the customers matching query ordered by customer ID
+func (s *MongoStore) ListCustomers(ctx context.Context, query CustomerQuery) ([]models.Customer, error) {
+	filter := bson.M{}
+	if query.AfterID != "" {
+		filter["_id"] = bson.M{"$gt": query.AfterID}
+	}
+	if query.NameContains != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(query.NameContains), "$options": "i"}
+	}
+	findOptions := options.Find().SetSort(bson.M{"_id": 1})
+	if query.Limit > 0 {
+		findOptions.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := s.customersCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	customers := []models.Customer{}
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+// GetStatusHistory returns the account state changes of a customer, oldest first
+func (s *MongoStore) GetStatusHistory(ctx context.Context, customerID string) ([]models.AccountStatusChange, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := s.statusLogCollection.Find(ctx, bson.M{"customer_id": customerID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.AccountStatusChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetTransactionHistory returns every transaction posted for a customer
+func (s *MongoStore) GetTransactionHistory(ctx context.Context, customerID string) ([]models.Transaction, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.transactionsCollection.Find(ctx, bson.M{"customer_id": customerID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []models.Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// QueryTransactions returns a page of a customer's transactions matching query
+func (s *MongoStore) QueryTransactions(ctx context.Context, query TransactionQuery) ([]models.Transaction, error) {
+	filter := bson.M{"customer_id": query.CustomerID}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.Currency == models.DefaultCurrency {
+		// Transactions stored before currencies were tracked have no currency field
+		filter["currency"] = bson.M{"$in": bson.A{query.Currency, nil}}
+	} else if query.Currency != "" {
+		filter["currency"] = query.Currency
+	}
+	amount := bson.M{}
+	if query.MinAmount != nil {
+		amount["$gte"] = *query.MinAmount
+	}
+	if query.MaxAmount != nil {
+		amount["$lte"] = *query.MaxAmount
+	}
+	if len(amount) > 0 {
+		filter["amount"] = amount
+	}
+	timestamp := bson.M{}
+	if !query.From.IsZero() {
+		timestamp["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		timestamp["$lt"] = query.To
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+
+	order, beyond := 1, "$gt"
+	if query.Descending {
+		order, beyond = -1, "$lt"
+	}
+	if query.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"timestamp": bson.M{beyond: query.After.Timestamp}},
+			bson.M{"timestamp": query.After.Timestamp, "_id": bson.M{beyond: query.After.TransactionID}},
+		}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: order}, {Key: "_id", Value: order}})
+	if query.Limit > 0 {
+		findOptions.SetLimit(int64(query.Limit))
+	}
+	cursor, err := s.transactionsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []models.Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// SaveTransactionStatus creates or replaces the status record of a transaction
+func (s *MongoStore) SaveTransactionStatus(ctx context.Context, record models.TransactionStatusRecord) error {
+	return saveTransactionStatus(ctx, s.statusesCollection, record)
+}
+
+// GetTransactionStatus returns the status record of a transaction
+func (s *MongoStore) GetTransactionStatus(ctx context.Context, transactionID string) (models.TransactionStatusRecord, error) {
+	var record models.TransactionStatusRecord
+	err := s.statusesCollection.FindOne(ctx, bson.M{"_id": transactionID}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.TransactionStatusRecord{}, ErrTransactionNotFound
+	}
+	return record, err
+}
+
+// GetHold returns the hold with the given ID
+func (s *MongoStore) GetHold(ctx context.Context, holdID string) (models.Hold, error) {
+	return findHold(ctx, s.holdsCollection, holdID)
+}
+
+// ListHolds returns the holds of a customer, oldest first
+func (s *MongoStore) ListHolds(ctx context.Context, customerID, status string) ([]models.Hold, error) {
+	filter := bson.M{"customer_id": customerID}
+	if status != "" {
+		filter["status"] = status
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.holdsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	holds := []models.Hold{}
+	if err := cursor.All(ctx, &holds); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// ListExpiredHolds returns up to limit active holds that expired by now
+func (s *MongoStore) ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]models.Hold, error) {
+	filter := bson.M{"status": models.HoldStatusActive, "expires_at": bson.M{"$lte": now}}
+	findOptions := options.Find().SetSort(bson.M{"expires_at": 1})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	cursor, err := s.holdsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	holds := []models.Hold{}
+	if err := cursor.All(ctx, &holds); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// SaveDeadLetter creates or replaces the dead letter of a transaction
+func (s *MongoStore) SaveDeadLetter(ctx context.Context, deadLetter models.DeadLetter) error {
+	_, err := s.deadLettersCollection.ReplaceOne(ctx, bson.M{"_id": deadLetter.TransactionID}, deadLetter, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListDeadLetters returns up to limit dead letters, oldest first
+func (s *MongoStore) ListDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error) {
+	findOptions := options.Find().SetSort(bson.M{"failed_at": 1})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	cursor, err := s.deadLettersCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deadLetters := []models.DeadLetter{}
+	if err := cursor.All(ctx, &deadLetters); err != nil {
+		return nil, err
+	}
+	return deadLetters, nil
+}
+
+// GetDeadLetter returns the dead letter of a transaction
+func (s *MongoStore) GetDeadLetter(ctx context.Context, transactionID string) (models.DeadLetter, error) {
+	var deadLetter models.DeadLetter
+	err := s.deadLettersCollection.FindOne(ctx, bson.M{"_id": transactionID}).Decode(&deadLetter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return deadLetter, err
+}
+
+// DeleteDeadLetter removes the dead letter of a transaction
+func (s *MongoStore) DeleteDeadLetter(ctx context.Context, transactionID string) error {
+	result, err := s.deadLettersCollection.DeleteOne(ctx, bson.M{"_id": transactionID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// SaveExchangeRate creates or replaces the rate with the same ID
+func (s *MongoStore) SaveExchangeRate(ctx context.Context, rate models.ExchangeRate) error {
+	_, err := s.ratesCollection.ReplaceOne(ctx, bson.M{"_id": rate.RateID}, rate, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListExchangeRates returns the rates matching the currency pair, ordered by
+// pair and then effective time
+func (s *MongoStore) ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error) {
+	filter := bson.M{}
+	if base != "" {
+		filter["base_currency"] = base
+	}
+	if quote != "" {
+		filter["quote_currency"] = quote
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "base_currency", Value: 1}, {Key: "quote_currency", Value: 1}, {Key: "effective_from", Value: 1}})
+	cursor, err := s.ratesCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rates := []models.ExchangeRate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetEffectiveRate returns the rate of base in quote in effect at the given moment
+func (s *MongoStore) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (models.ExchangeRate, error) {
+	filter := bson.M{"base_currency": base, "quote_currency": quote, "effective_from": bson.M{"$lte": at}}
+	findOptions := options.FindOne().SetSort(bson.M{"effective_from": -1})
+	var rate models.ExchangeRate
+	err := s.ratesCollection.FindOne(ctx, filter, findOptions).Decode(&rate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.ExchangeRate{}, ErrExchangeRateNotFound
+	}
+	return rate, err
+}
+
+// InsertQuote stores a new quote
+func (s *MongoStore) InsertQuote(ctx context.Context, quote models.FXQuote) error {
+	_, err := s.quotesCollection.InsertOne(ctx, quote)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+// GetQuote returns the quote with the given ID
+func (s *MongoStore) GetQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	var quote models.FXQuote
+	err := s.quotesCollection.FindOne(ctx, bson.M{"_id": quoteID}).Decode(&quote)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.FXQuote{}, ErrQuoteNotFound
+	}
+	return quote, err
+}
+
+// GetConversion returns the conversion with the given ID
+func (s *MongoStore) GetConversion(ctx context.Context, conversionID string) (models.Conversion, error) {
+	var conversion models.Conversion
+	err := s.conversionsCollection.FindOne(ctx, bson.M{"_id": conversionID}).Decode(&conversion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Conversion{}, ErrConversionNotFound
+	}
+	return conversion, err
+}
+
+// ListConversions returns the conversions of a customer, oldest first
+func (s *MongoStore) ListConversions(ctx context.Context, customerID string) ([]models.Conversion, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.conversionsCollection.Find(ctx, bson.M{"customer_id": customerID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	conversions := []models.Conversion{}
+	if err := cursor.All(ctx, &conversions); err != nil {
+		return nil, err
+	}
+	return conversions, nil
+}
+
+// InsertAPIKey stores a new API key
+func (s *MongoStore) InsertAPIKey(ctx context.Context, key models.APIKey) error {
+	_, err := s.apiKeysCollection.InsertOne(ctx, key)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+// GetAPIKey returns the key with the given ID
+func (s *MongoStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	return findAPIKey(ctx, s.apiKeysCollection, bson.M{"_id": keyID})
+}
+
+// FindAPIKeyByHash returns the key whose secret has the given hash
+func (s *MongoStore) FindAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	return findAPIKey(ctx, s.apiKeysCollection, bson.M{"hash": hash})
+}
+
+// ListAPIKeys returns every key, oldest first
+func (s *MongoStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.apiKeysCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// UpdateAPIKey replaces an existing key
+func (s *MongoStore) UpdateAPIKey(ctx context.Context, key models.APIKey) error {
+	result, err := s.apiKeysCollection.ReplaceOne(ctx, bson.M{"_id": key.KeyID}, key)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records that a key was used at the given moment
+func (s *MongoStore) TouchAPIKey(ctx context.Context, keyID string, at time.Time) error {
+	result, err := s.apiKeysCollection.UpdateOne(ctx, bson.M{"_id": keyID}, bson.M{"$max": bson.M{"last_used_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// InsertAuditEntry appends an entry to the audit log
+func (s *MongoStore) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	_, err := s.auditCollection.InsertOne(ctx, entry)
+	return err
+}
+
+// ListAuditEntries returns the entries matching query, newest first. Audit
+// IDs sort in the order entries were written, so they order the page.
+func (s *MongoStore) ListAuditEntries(ctx context.Context, query AuditQuery) ([]models.AuditEntry, error) {
+	filter := bson.M{}
+	if query.ActorID != "" {
+		filter["actor_id"] = query.ActorID
+	}
+	if query.TargetID != "" {
+		filter["target_id"] = query.TargetID
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	timestamp := bson.M{}
+	if !query.From.IsZero() {
+		timestamp["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		timestamp["$lt"] = query.To
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+	if query.BeforeID != "" {
+		filter["_id"] = bson.M{"$lt": query.BeforeID}
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		findOptions.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := s.auditCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ClaimNonce records a client's nonce unless an unexpired use of it exists.
+// The upsert only matches an expired record the TTL monitor has not removed
+// yet; an unexpired one makes it insert a duplicate _id and fail.
+func (s *MongoStore) ClaimNonce(ctx context.Context, clientID, nonce string, expiresAt time.Time) (bool, error) {
+	id := bson.D{{Key: "client_id", Value: clientID}, {Key: "nonce", Value: nonce}}
+	_, err := s.noncesCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "expires_at": bson.M{"$lte": time.Now()}},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WithTransaction runs fn inside a MongoDB session transaction
+func (s *MongoStore) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	session, err := s.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&mongoTx{ctx: sessCtx, store: s})
+	})
+	return err
+}
+
+// ClaimIdempotencyKey stores record unless an unexpired record with the same key exists
+func (s *MongoStore) ClaimIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	_, err := s.idempotencyCollection.InsertOne(ctx, record)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	existing, err := s.GetIdempotencyRecord(ctx, record.Key)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if !existing.IsExpired(time.Now()) {
+		return existing, false, nil
+	}
+
+	// The TTL monitor has not removed the expired record yet, so take it over.
+	// Matching on expires_at makes sure only one caller wins the replacement.
+	result, err := s.idempotencyCollection.ReplaceOne(ctx, bson.M{
+		"_id":        record.Key,
+		"expires_at": existing.ExpiresAt,
+	}, record)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if result.MatchedCount == 0 {
+		existing, err = s.GetIdempotencyRecord(ctx, record.Key)
+		return existing, false, err
+	}
+	return record, true, nil
+}
+
+// CompleteIdempotencyKey stores the final response for a claimed key
+func (s *MongoStore) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, response models.TransactionStatusResponse) error {
+	result, err := s.idempotencyCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{
+			"status":      models.IdempotencyStatusCompleted,
+			"status_code": statusCode,
+			"response":    response,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrIdempotencyKeyNotFound
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes the record for a key so it can be claimed again
+func (s *MongoStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := s.idempotencyCollection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// GetIdempotencyRecord returns the record for a key
+func (s *MongoStore) GetIdempotencyRecord(ctx context.Context, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := s.idempotencyCollection.FindOne(ctx, bson.M{"_id": key}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.IdempotencyRecord{}, ErrIdempotencyKeyNotFound
+	}
+	return record, err
+}
+
+// GetTrialBalance returns the net balance of every account with journal lines, ordered by account ID
+func (s *MongoStore) GetTrialBalance(ctx context.Context) ([]models.AccountBalance, error) {
+	return s.aggregateAccountBalances(ctx, bson.M{})
+}
+
+// GetAccountBalance returns the net of all journal lines posted to an account
+func (s *MongoStore) GetAccountBalance(ctx context.Context, accountID string) (models.Money, error) {
+	balances, err := s.aggregateAccountBalances(ctx, bson.M{"lines.account_id": accountID})
+	if err != nil || len(balances) == 0 {
+		return models.Money{}, err
+	}
+	return balances[0].Balance, nil
+}
+
+func (s *MongoStore) aggregateAccountBalances(ctx context.Context, lineFilter bson.M) ([]models.AccountBalance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$lines"}},
+		{{Key: "$match", Value: lineFilter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$lines.account_id",
+			"balance": bson.M{"$sum": "$lines.amount"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := s.journalCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	balances := []models.AccountBalance{}
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// mongoTx implements Tx on top of a MongoDB session context
+type mongoTx struct {
+	ctx   mongo.SessionContext
+	store *MongoStore
+}
+
+func (tx *mongoTx) InsertCustomer(customer models.Customer) error {
+	_, err := tx.store.customersCollection.InsertOne(tx.ctx, customer)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+func (tx *mongoTx) GetCustomer(customerID string) (models.Customer, error) {
+	return findCustomer(tx.ctx, tx.store.customersCollection, customerID)
+}
+
+func (tx *mongoTx) UpdateBalance(customerID, currency string, balance models.Money, sequence int64, postedAt time.Time) error {
+	result, err := tx.store.customersCollection.UpdateOne(
+		tx.ctx,
+		bson.M{"_id": customerID},
+		bson.M{"$set": bson.M{balanceField(currency, "balance"): balance, "last_sequence": sequence, "last_posted_at": postedAt}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCustomerNotFound
+	}
+	return nil
+}
+
+func (tx *mongoTx) UpdateCustomer(customer models.Customer) error {
+	filter := bson.M{"_id": customer.CustomerID, "version": customer.Version}
+	if customer.Version == 0 {
+		// Customers stored before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	set := bson.M{
+		"name":              customer.Name,
+		"overdraft_limit":   customer.OverdraftLimit,
+		"status":            customer.Status,
+		"status_reason":     customer.StatusReason,
+		"status_changed_by": customer.StatusChangedBy,
+		"status_changed_at": customer.StatusChangedAt,
+		"closed_at":         customer.ClosedAt,
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+
+	result, err := tx.store.customersCollection.UpdateOne(tx.ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := tx.GetCustomer(customer.CustomerID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (tx *mongoTx) UpdateHeldBalance(customerID, currency string, held models.Money) error {
+	result, err := tx.store.customersCollection.UpdateOne(
+		tx.ctx,
+		bson.M{"_id": customerID},
+		bson.M{"$set": bson.M{balanceField(currency, "held_balance"): held}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCustomerNotFound
+	}
+	return nil
+}
+
+// balanceField returns the path of a customer's balance field in currency.
+// The default currency is kept at the top level of the document and other
+// currencies in the balances subdocument, mirroring models.Customer.
+func balanceField(currency, field string) string {
+	if currency = models.CurrencyOrDefault(currency); currency == models.DefaultCurrency {
+		return field
+	}
+	return "balances." + currency + "." + field
+}
+
+func (tx *mongoTx) InsertHold(hold models.Hold) error {
+	_, err := tx.store.holdsCollection.InsertOne(tx.ctx, hold)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+func (tx *mongoTx) GetHold(holdID string) (models.Hold, error) {
+	return findHold(tx.ctx, tx.store.holdsCollection, holdID)
+}
+
+func (tx *mongoTx) ResolveHold(hold models.Hold) error {
+	result, err := tx.store.holdsCollection.ReplaceOne(tx.ctx, bson.M{"_id": hold.HoldID, "status": models.HoldStatusActive}, hold)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := tx.GetHold(hold.HoldID); err != nil {
+			return err
+		}
+		return models.ErrHoldNotActive
+	}
+	return nil
+}
+
+func (tx *mongoTx) InsertStatusChange(change models.AccountStatusChange) error {
+	_, err := tx.store.statusLogCollection.InsertOne(tx.ctx, change)
+	return err
+}
+
+func (tx *mongoTx) InsertTransaction(t models.Transaction) error {
+	_, err := tx.store.transactionsCollection.InsertOne(tx.ctx, t)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+func (tx *mongoTx) GetTransaction(transactionID string) (models.Transaction, error) {
+	var t models.Transaction
+	err := tx.store.transactionsCollection.FindOne(tx.ctx, bson.M{"_id": transactionID}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Transaction{}, ErrTransactionNotFound
+	}
+	return t, err
+}
+
+func (tx *mongoTx) UpdateReversalState(t models.Transaction) error {
+	result, err := tx.store.transactionsCollection.UpdateOne(
+		tx.ctx,
+		bson.M{"_id": t.TransactionID},
+		bson.M{"$set": bson.M{
+			"reversed_amount": t.ReversedAmount,
+			"reversal_status": t.ReversalStatus,
+			"reversal_ids":    t.ReversalIDs,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
+
+func (tx *mongoTx) InsertJournalEntry(entry models.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	_, err := tx.store.journalCollection.InsertOne(tx.ctx, entry)
+	return err
+}
+
+func (tx *mongoTx) SaveTransactionStatus(record models.TransactionStatusRecord) error {
+	return saveTransactionStatus(tx.ctx, tx.store.statusesCollection, record)
+}
+
+func (tx *mongoTx) ExecuteQuote(quoteID, conversionID string) error {
+	result, err := tx.store.quotesCollection.UpdateOne(
+		tx.ctx,
+		bson.M{"_id": quoteID, "status": models.QuoteStatusOpen},
+		bson.M{"$set": bson.M{"status": models.QuoteStatusExecuted, "conversion_id": conversionID}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := tx.store.quotesCollection.CountDocuments(tx.ctx, bson.M{"_id": quoteID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrQuoteNotFound
+		}
+		return models.ErrQuoteUsed
+	}
+	return nil
+}
+
+func (tx *mongoTx) InsertConversion(conversion models.Conversion) error {
+	_, err := tx.store.conversionsCollection.InsertOne(tx.ctx, conversion)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateKey
+	}
+	return err
+}
+
+func (tx *mongoTx) InsertAuditEntry(entry models.AuditEntry) error {
+	_, err := tx.store.auditCollection.InsertOne(tx.ctx, entry)
+	return err
+}
+
+func saveTransactionStatus(ctx context.Context, collection *mongo.Collection, record models.TransactionStatusRecord) error {
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": record.TransactionID}, record, options.Replace().SetUpsert(true))
+	return err
+}
+
+func findHold(ctx context.Context, collection *mongo.Collection, holdID string) (models.Hold, error) {
+	var hold models.Hold
+	err := collection.FindOne(ctx, bson.M{"_id": holdID}).Decode(&hold)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Hold{}, ErrHoldNotFound
+	}
+	return hold, err
+}
+
+func findCustomer(ctx context.Context, collection *mongo.Collection, customerID string) (models.Customer, error) {
+	var customer models.Customer
+	err := collection.FindOne(ctx, bson.M{"_id": customerID}).Decode(&customer)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Customer{}, ErrCustomerNotFound
+	}
+	return customer, err
+}
+
+func findAPIKey(ctx context.Context, collection *mongo.Collection, filter bson.M) (models.APIKey, error) {
+	var key models.APIKey
+	err := collection.FindOne(ctx, filter).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, err
+}
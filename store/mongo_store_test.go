@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupMongoStore returns a store on an emptied test database of the cluster
+// in MONGO_CLUSTER, taken from the environment or the .env file one level
+// up. The test is skipped when no cluster is configured. Session
+// transactions need the cluster to be a replica set.
+func setupMongoStore(t *testing.T) *MongoStore {
+	t.Helper()
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	godotenv.Load(filepath.Join(filepath.Dir(currentDir), ".env"))
+
+	databaseURL := os.Getenv("MONGO_CLUSTER")
+	if databaseURL == "" {
+		t.Skip("MONGO_CLUSTER is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(databaseURL))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("Failed to ping MongoDB: %v", err)
+	}
+
+	// Every package has its own database, as go test runs packages in parallel
+	db := client.Database("kryptovate_test_store")
+	if err := db.Drop(ctx); err != nil {
+		t.Fatalf("Failed to drop test database: %v", err)
+	}
+	s := NewMongoStore(db)
+	if err := s.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes() error = %v", err)
+	}
+	return s
+}
+
+func TestMongoStoreWithTransactionCommit(t *testing.T) {
+	testWithTransactionCommit(t, setupMongoStore(t))
+}
+
+func TestMongoStoreWithTransactionRollback(t *testing.T) {
+	testWithTransactionRollback(t, setupMongoStore(t))
+}
+
+func TestMongoStoreQueryTransactions(t *testing.T) {
+	testQueryTransactions(t, setupMongoStore(t))
+}
+
+func TestMongoStoreClaimNonce(t *testing.T) {
+	testClaimNonce(t, setupMongoStore(t))
+}
+
+func TestMongoStoreAuditLog(t *testing.T) {
+	testAuditLog(t, setupMongoStore(t))
+}
+
+func TestMongoStoreListCustomersPages(t *testing.T) {
+	s := setupMongoStore(t)
+	ctx := context.Background()
+	for _, id := range []string{"c3", "c1", "c4", "c2"} {
+		if err := s.CreateCustomer(ctx, models.Customer{CustomerID: id, Name: "Customer " + id}); err != nil {
+			t.Fatalf("CreateCustomer(%s) error = %v", id, err)
+		}
+	}
+
+	var pages [][]string
+	query := CustomerQuery{Limit: 3}
+	for {
+		customers, err := s.ListCustomers(ctx, query)
+		if err != nil {
+			t.Fatalf("ListCustomers() error = %v", err)
+		}
+		if len(customers) == 0 {
+			break
+		}
+		var page []string
+		for _, customer := range customers {
+			page = append(page, customer.CustomerID)
+		}
+		pages = append(pages, page)
+		query.AfterID = page[len(page)-1]
+	}
+
+	if got, want := fmt.Sprint(pages), "[[c1 c2 c3] [c4]]"; got != want {
+		t.Errorf("ListCustomers() pages = %s, want %s", got, want)
+	}
+}
+
+func TestMongoStoreMoneyRoundTrip(t *testing.T) {
+	s := setupMongoStore(t)
+	ctx := context.Background()
+
+	customer := models.Customer{
+		CustomerID:     "cust1",
+		Balance:        models.MustParseMoney("12345678.12345678"),
+		HeldBalance:    models.MustParseMoney("0.10"),
+		OverdraftLimit: models.MustParseMoney("500.00"),
+		Balances: map[string]models.CurrencyBalance{
+			"EUR": {Balance: models.MustParseMoney("-7.25")},
+			"JPY": {Balance: models.MustParseMoney("9223372036854775807")},
+		},
+	}
+	if err := s.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	got, err := s.GetCustomer(ctx, "cust1")
+	if err != nil {
+		t.Fatalf("GetCustomer() error = %v", err)
+	}
+	amounts := []struct {
+		name      string
+		got, want models.Money
+	}{
+		{"balance", got.Balance, customer.Balance},
+		{"held balance", got.HeldBalance, customer.HeldBalance},
+		{"overdraft limit", got.OverdraftLimit, customer.OverdraftLimit},
+		{"EUR balance", got.Balances["EUR"].Balance, customer.Balances["EUR"].Balance},
+		{"JPY balance", got.Balances["JPY"].Balance, customer.Balances["JPY"].Balance},
+	}
+	for _, a := range amounts {
+		if a.got.String() != a.want.String() {
+			t.Errorf("%s = %s, want %s", a.name, a.got, a.want)
+		}
+	}
+
+	// Decimal128 sums are exact where doubles would drift
+	err = s.WithTransaction(ctx, func(tx Tx) error {
+		for i, amount := range []string{"0.1", "0.2"} {
+			err := tx.InsertJournalEntry(models.JournalEntry{
+				EntryID: []string{"e1", "e2"}[i],
+				Lines: []models.JournalLine{
+					{AccountID: "customer:cust1", Amount: models.MustParseMoney(amount)},
+					{AccountID: "system:cash", Amount: models.MustParseMoney(amount).Neg()},
+				},
+				Timestamp: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction() error = %v", err)
+	}
+	balance, err := s.GetAccountBalance(ctx, "customer:cust1")
+	if err != nil {
+		t.Fatalf("GetAccountBalance() error = %v", err)
+	}
+	if !balance.Equal(models.MustParseMoney("0.3")) {
+		t.Errorf("GetAccountBalance() = %s, want 0.3", balance)
+	}
+}
+
+func TestMongoStoreReadsLegacyAmounts(t *testing.T) {
+	s := setupMongoStore(t)
+	ctx := context.Background()
+
+	// Amounts written before Decimal128 were stored as numbers
+	_, err := s.customersCollection.InsertOne(ctx, bson.M{
+		"_id":             "legacy",
+		"balance":         100.25,
+		"held_balance":    int32(10),
+		"overdraft_limit": int64(500),
+	})
+	if err != nil {
+		t.Fatalf("InsertOne() error = %v", err)
+	}
+	customer, err := s.GetCustomer(ctx, "legacy")
+	if err != nil {
+		t.Fatalf("GetCustomer() error = %v", err)
+	}
+	if customer.Balance.String() != "100.25" || customer.HeldBalance.String() != "10" || customer.OverdraftLimit.String() != "500" {
+		t.Errorf("GetCustomer() amounts = %s, %s, %s, want 100.25, 10, 500", customer.Balance, customer.HeldBalance, customer.OverdraftLimit)
+	}
+}
+
+func TestMongoStoreDuplicateKeys(t *testing.T) {
+	s := setupMongoStore(t)
+	ctx := context.Background()
+
+	customer := models.Customer{CustomerID: "cust1"}
+	if err := s.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	if err := s.CreateCustomer(ctx, customer); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("CreateCustomer() duplicate error = %v, want %v", err, ErrDuplicateKey)
+	}
+	err := s.WithTransaction(ctx, func(tx Tx) error { return tx.InsertCustomer(customer) })
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("InsertCustomer() duplicate error = %v, want %v", err, ErrDuplicateKey)
+	}
+}
+
+func TestMongoStoreClaimIdempotencyKey(t *testing.T) {
+	s := setupMongoStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	first := models.IdempotencyRecord{Key: "k1", Fingerprint: "f1", Status: models.IdempotencyStatusProcessing, TransactionID: "t1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if _, claimed, err := s.ClaimIdempotencyKey(ctx, first); err != nil || !claimed {
+		t.Fatalf("ClaimIdempotencyKey() = %v, %v, want claimed", claimed, err)
+	}
+
+	// A second claim of the key hits the duplicate _id and returns the first record
+	second := first
+	second.Fingerprint, second.TransactionID = "f2", "t2"
+	existing, claimed, err := s.ClaimIdempotencyKey(ctx, second)
+	if err != nil || claimed {
+		t.Fatalf("ClaimIdempotencyKey() duplicate = %v, %v, want not claimed", claimed, err)
+	}
+	if existing.Fingerprint != "f1" || existing.TransactionID != "t1" {
+		t.Errorf("ClaimIdempotencyKey() duplicate returned %+v, want the first record", existing)
+	}
+
+	// An expired record the TTL monitor has not removed yet is taken over
+	expired := models.IdempotencyRecord{Key: "k2", Fingerprint: "f1", Status: models.IdempotencyStatusProcessing, TransactionID: "t3", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	if _, err := s.idempotencyCollection.InsertOne(ctx, expired); err != nil {
+		t.Fatalf("InsertOne() error = %v", err)
+	}
+	fresh := models.IdempotencyRecord{Key: "k2", Fingerprint: "f2", Status: models.IdempotencyStatusProcessing, TransactionID: "t4", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if _, claimed, err := s.ClaimIdempotencyKey(ctx, fresh); err != nil || !claimed {
+		t.Fatalf("ClaimIdempotencyKey() over expired record = %v, %v, want claimed", claimed, err)
+	}
+	stored, err := s.GetIdempotencyRecord(ctx, "k2")
+	if err != nil {
+		t.Fatalf("GetIdempotencyRecord() error = %v", err)
+	}
+	if stored.TransactionID != "t4" {
+		t.Errorf("GetIdempotencyRecord() transaction = %s, want t4", stored.TransactionID)
+	}
+}
@@ -0,0 +1,360 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"ledger-service/models"
+	"time"
+)
+
+// ErrCustomerNotFound is returned when a customer does not exist in the store
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// ErrVersionConflict is returned when a customer changed since the version the caller read
+var ErrVersionConflict = errors.New("customer was modified concurrently")
+
+// ErrDuplicateKey is returned when inserting a record whose ID already exists
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrIdempotencyKeyNotFound is returned when no record exists for an idempotency key
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// ErrDeadLetterNotFound is returned when no dead letter exists for a transaction
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrHoldNotFound is returned when a hold does not exist in the store
+var ErrHoldNotFound = errors.New("hold not found")
+
+// ErrTransactionNotFound is returned when a posted transaction or a transaction status record does not exist
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrExchangeRateNotFound is returned when no rate of a currency pair is in effect
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+// ErrQuoteNotFound is returned when a quote does not exist in the store
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// ErrConversionNotFound is returned when a conversion does not exist in the store
+var ErrConversionNotFound = errors.New("conversion not found")
+
+// ErrAPIKeyNotFound is returned when no API key has the given ID or secret hash
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// Store is implemented by the complete storage backends, MongoStore and MemoryStore
+type Store interface {
+	LedgerStore
+	IdempotencyStore
+	JournalStore
+	DeadLetterStore
+	FXStore
+	APIKeyStore
+	NonceStore
+	AuditStore
+}
+
+// LedgerStore abstracts the persistence layer used by the handlers and workers
+type LedgerStore interface {
+	// CreateCustomer stores a new customer
+	CreateCustomer(ctx context.Context, customer models.Customer) error
+
+	// GetCustomer returns the customer with the given ID or ErrCustomerNotFound
+	GetCustomer(ctx context.Context, customerID string) (models.Customer, error)
+
+	// ListCustomers returns the customers matching query ordered by customer ID
+	ListCustomers(ctx context.Context, query CustomerQuery) ([]models.Customer, error)
+
+	// GetStatusHistory returns the account state changes of a customer, oldest first
+	GetStatusHistory(ctx context.Context, customerID string) ([]models.AccountStatusChange, error)
+
+	// GetTransactionHistory returns every transaction posted for a customer, oldest first
+	GetTransactionHistory(ctx context.Context, customerID string) ([]models.Transaction, error)
+
+	// QueryTransactions returns a page of a customer's transactions matching
+	// query, ordered by timestamp and then transaction ID
+	QueryTransactions(ctx context.Context, query TransactionQuery) ([]models.Transaction, error)
+
+	// SaveTransactionStatus creates or replaces the status record of a transaction
+	SaveTransactionStatus(ctx context.Context, record models.TransactionStatusRecord) error
+
+	// GetHold returns the hold with the given ID or ErrHoldNotFound
+	GetHold(ctx context.Context, holdID string) (models.Hold, error)
+
+	// ListHolds returns the holds of a customer, oldest first, keeping only
+	// those in the given state when status is set
+	ListHolds(ctx context.Context, customerID, status string) ([]models.Hold, error)
+
+	// ListExpiredHolds returns up to limit active holds whose expiry time is
+	// not after now, soonest expiring first
+	ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]models.Hold, error)
+
+	// GetTransactionStatus returns the status record of a transaction or ErrTransactionNotFound
+	GetTransactionStatus(ctx context.Context, transactionID string) (models.TransactionStatusRecord, error)
+
+	// WithTransaction runs fn atomically. If fn returns an error none of the
+	// writes made through tx are applied and the error is returned unchanged.
+	WithTransaction(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// CustomerQuery selects a page of customers
+type CustomerQuery struct {
+	// NameContains keeps customers whose name contains it, ignoring case
+	NameContains string
+	// AfterID keeps customers whose ID sorts after it, for keyset pagination
+	AfterID string
+	// Limit caps the number of customers returned; zero means no limit
+	Limit int
+}
+
+// TransactionQuery selects a page of a customer's transactions
+type TransactionQuery struct {
+	CustomerID string
+	// Type keeps only credits or only debits when set
+	Type string
+	// Currency keeps only transactions in the given ISO 4217 currency when
+	// set; transactions without a currency are in the default currency
+	Currency string
+	// MinAmount and MaxAmount bound the amount, inclusively, when set
+	MinAmount *models.Money
+	MaxAmount *models.Money
+	// From and To bound the timestamp when set; From is inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// Descending returns the newest transactions first
+	Descending bool
+	// After resumes after the given position in the sort order
+	After *TransactionCursor
+	// Limit caps the number of transactions returned; zero means no limit
+	Limit int
+}
+
+// TransactionCursor is a position in the transaction sort order
+type TransactionCursor struct {
+	Timestamp     time.Time
+	TransactionID string
+}
+
+// Matches reports whether t satisfies the filters of q, ignoring After and Limit
+func (q TransactionQuery) Matches(t models.Transaction) bool {
+	switch {
+	case t.CustomerID != q.CustomerID:
+		return false
+	case q.Type != "" && t.Type != q.Type:
+		return false
+	case q.Currency != "" && t.CurrencyCode() != q.Currency:
+		return false
+	case q.MinAmount != nil && t.Amount.Cmp(*q.MinAmount) < 0:
+		return false
+	case q.MaxAmount != nil && t.Amount.Cmp(*q.MaxAmount) > 0:
+		return false
+	case !q.From.IsZero() && t.Timestamp.Before(q.From):
+		return false
+	case !q.To.IsZero() && !t.Timestamp.Before(q.To):
+		return false
+	}
+	return true
+}
+
+// Tx is the set of operations available inside LedgerStore.WithTransaction
+type Tx interface {
+	// InsertCustomer stores a new customer
+	InsertCustomer(customer models.Customer) error
+
+	// GetCustomer returns the customer with the given ID or ErrCustomerNotFound
+	GetCustomer(customerID string) (models.Customer, error)
+
+	// UpdateBalance sets the balance in currency and the sequence number and
+	// timestamp of the latest posting of an existing customer, and increments
+	// its version
+	UpdateBalance(customerID, currency string, balance models.Money, sequence int64, postedAt time.Time) error
+
+	// UpdateCustomer replaces the profile, overdraft limit and state of an
+	// existing customer, keeping its balances and sequence number. It fails
+	// with ErrVersionConflict unless the stored version equals
+	// customer.Version, and increments the version.
+	UpdateCustomer(customer models.Customer) error
+
+	// UpdateHeldBalance sets the total reserved in currency by the active
+	// holds of an existing customer, and increments its version
+	UpdateHeldBalance(customerID, currency string, held models.Money) error
+
+	// InsertHold stores a new hold
+	InsertHold(hold models.Hold) error
+
+	// GetHold returns the hold with the given ID or ErrHoldNotFound
+	GetHold(holdID string) (models.Hold, error)
+
+	// ResolveHold replaces a hold that is still active with its captured,
+	// voided or expired form. It fails with models.ErrHoldNotActive if the
+	// stored hold is no longer active.
+	ResolveHold(hold models.Hold) error
+
+	// InsertStatusChange records an account state change
+	InsertStatusChange(change models.AccountStatusChange) error
+
+	// InsertTransaction records a posted transaction
+	InsertTransaction(t models.Transaction) error
+
+	// GetTransaction returns the posted transaction with the given ID or ErrTransactionNotFound
+	GetTransaction(transactionID string) (models.Transaction, error)
+
+	// UpdateReversalState stores the reversed amount, reversal status and
+	// reversal IDs of t on the posted transaction with the same ID
+	UpdateReversalState(t models.Transaction) error
+
+	// InsertJournalEntry records a balanced journal entry
+	InsertJournalEntry(entry models.JournalEntry) error
+
+	// SaveTransactionStatus creates or replaces the status record of a transaction
+	SaveTransactionStatus(record models.TransactionStatusRecord) error
+
+	// ExecuteQuote marks an open quote as executed by conversionID. It fails
+	// with models.ErrQuoteUsed if the stored quote is no longer open.
+	ExecuteQuote(quoteID, conversionID string) error
+
+	// InsertConversion records an executed conversion
+	InsertConversion(conversion models.Conversion) error
+
+	// InsertAuditEntry appends an entry to the audit log, so it is only kept
+	// if the change it records is committed
+	InsertAuditEntry(entry models.AuditEntry) error
+}
+
+// IdempotencyStore persists the outcome of requests sent with an Idempotency-Key
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey stores record unless an unexpired record with the same
+	// key exists. It returns the record now associated with the key and whether
+	// the caller's record was stored.
+	ClaimIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+
+	// CompleteIdempotencyKey stores the final response for a claimed key
+	CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, response models.TransactionStatusResponse) error
+
+	// ReleaseIdempotencyKey deletes the record for a key so it can be claimed again
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+
+	// GetIdempotencyRecord returns the record for a key or ErrIdempotencyKeyNotFound
+	GetIdempotencyRecord(ctx context.Context, key string) (models.IdempotencyRecord, error)
+}
+
+// JournalStore answers questions about the double-entry journal
+type JournalStore interface {
+	// GetTrialBalance returns the net balance of every account with journal lines
+	GetTrialBalance(ctx context.Context) ([]models.AccountBalance, error)
+
+	// GetAccountBalance returns the net of all journal lines posted to an account
+	GetAccountBalance(ctx context.Context, accountID string) (models.Money, error)
+}
+
+// DeadLetterStore keeps transactions that could not be posted after all retries
+type DeadLetterStore interface {
+	// SaveDeadLetter creates or replaces the dead letter of a transaction
+	SaveDeadLetter(ctx context.Context, deadLetter models.DeadLetter) error
+
+	// ListDeadLetters returns up to limit dead letters, oldest first
+	ListDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error)
+
+	// GetDeadLetter returns the dead letter of a transaction or ErrDeadLetterNotFound
+	GetDeadLetter(ctx context.Context, transactionID string) (models.DeadLetter, error)
+
+	// DeleteDeadLetter removes the dead letter of a transaction or returns ErrDeadLetterNotFound
+	DeleteDeadLetter(ctx context.Context, transactionID string) error
+}
+
+// FXStore keeps the exchange rate table, conversion quotes and executed conversions
+type FXStore interface {
+	// SaveExchangeRate creates or replaces the rate with the same ID
+	SaveExchangeRate(ctx context.Context, rate models.ExchangeRate) error
+
+	// ListExchangeRates returns the rates of the currency pair, or of every
+	// pair with a base or quote currency left empty matching any, ordered by
+	// pair and then effective time
+	ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error)
+
+	// GetEffectiveRate returns the rate of base in quote in effect at the
+	// given moment, the one with the latest effective time not after it, or
+	// ErrExchangeRateNotFound
+	GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (models.ExchangeRate, error)
+
+	// InsertQuote stores a new quote
+	InsertQuote(ctx context.Context, quote models.FXQuote) error
+
+	// GetQuote returns the quote with the given ID or ErrQuoteNotFound
+	GetQuote(ctx context.Context, quoteID string) (models.FXQuote, error)
+
+	// GetConversion returns the conversion with the given ID or ErrConversionNotFound
+	GetConversion(ctx context.Context, conversionID string) (models.Conversion, error)
+
+	// ListConversions returns the conversions of a customer, oldest first
+	ListConversions(ctx context.Context, customerID string) ([]models.Conversion, error)
+}
+
+// APIKeyStore keeps the API keys that authenticate requests
+type APIKeyStore interface {
+	// InsertAPIKey stores a new API key
+	InsertAPIKey(ctx context.Context, key models.APIKey) error
+
+	// GetAPIKey returns the key with the given ID or ErrAPIKeyNotFound
+	GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error)
+
+	// FindAPIKeyByHash returns the key whose secret has the given hash or ErrAPIKeyNotFound
+	FindAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+
+	// ListAPIKeys returns every key, revoked ones included, oldest first
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+
+	// UpdateAPIKey replaces an existing key or returns ErrAPIKeyNotFound
+	UpdateAPIKey(ctx context.Context, key models.APIKey) error
+
+	// TouchAPIKey records that a key was used at the given moment, unless a
+	// later use is already recorded
+	TouchAPIKey(ctx context.Context, keyID string, at time.Time) error
+}
+
+// NonceStore remembers the nonces of signed requests so replays can be rejected
+type NonceStore interface {
+	// ClaimNonce records that a client used nonce until expiresAt. It returns
+	// false if the client already used the nonce and that use has not expired.
+	ClaimNonce(ctx context.Context, clientID, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// AuditStore keeps the append-only audit log. Entries are never changed or
+// removed; changes made in a session write theirs through Tx instead.
+type AuditStore interface {
+	// InsertAuditEntry appends an entry to the audit log
+	InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error
+
+	// ListAuditEntries returns the entries matching query, newest first
+	ListAuditEntries(ctx context.Context, query AuditQuery) ([]models.AuditEntry, error)
+}
+
+// AuditQuery selects a page of audit entries
+type AuditQuery struct {
+	// ActorID, TargetID and Action keep only the matching entries when set
+	ActorID  string
+	TargetID string
+	Action   string
+	// From and To bound the timestamp when set; From is inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// BeforeID keeps entries written before the entry with this ID, for keyset pagination
+	BeforeID string
+	// Limit caps the number of entries returned; zero means no limit
+	Limit int
+}
+
+// Matches reports whether entry satisfies the filters of q, ignoring BeforeID and Limit
+func (q AuditQuery) Matches(entry models.AuditEntry) bool {
+	switch {
+	case q.ActorID != "" && entry.ActorID != q.ActorID:
+		return false
+	case q.TargetID != "" && entry.TargetID != q.TargetID:
+		return false
+	case q.Action != "" && entry.Action != q.Action:
+		return false
+	case !q.From.IsZero() && entry.Timestamp.Before(q.From):
+		return false
+	case !q.To.IsZero() && !entry.Timestamp.Before(q.To):
+		return false
+	}
+	return true
+}